@@ -0,0 +1,100 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAfter(t *testing.T) {
+	var calls []string
+	rr := New("/")
+	rr.Before(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "before")
+		if r.URL.Query().Get("halt") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			HaltRequest(r)
+		}
+	})
+	rr.After(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "after "+RoutePattern(r.Context()))
+		w.Write([]byte("<!-- footer -->"))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "cleanup")
+	})
+	rr.Get("/pages/:slug", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+		w.Write([]byte("page"))
+	})
+
+	tests := []struct {
+		path     string
+		calls    string
+		status   int
+		response string
+	}{
+		{"/pages/about", "before,handler,after /pages/:slug,cleanup", 200, "page<!-- footer -->"},
+		{"/pages/about?halt=1", "before,after /pages/:slug,cleanup", 401, "<!-- footer -->"},
+	}
+	for _, test := range tests {
+		calls = nil
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if c := strings.Join(calls, ","); c != test.calls {
+			t.Errorf("%s: invalid calls %s", test.path, c)
+		}
+		if rec.Code != test.status || rec.Body.String() != test.response {
+			t.Errorf("%s: invalid response %d %s", test.path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestMiddlewareInheritance(t *testing.T) {
+	var calls []string
+	record := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, name)
+		}
+	}
+
+	rr := New("/")
+	rr.Before(record("root before"))
+	rr.After(record("root after"))
+	rr.Get("/", record("handler"))
+	api := rr.SubRouter("/api")
+	api.Before(record("api before"))
+	api.After(record("api after"))
+	v1 := api.SubRouter("/v1")
+	v1.Before(record("v1 before"))
+	v1.Get("/users", record("handler"))
+	hooks := api.SubRouterIsolated("/hooks")
+	hooks.Before(record("hooks before"))
+	hooks.Post("/stripe", record("handler"))
+	legacy := hooks.SubRouter("/legacy")
+	legacy.Post("/paypal", record("handler"))
+
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/", "root before,handler,root after"},
+		{"GET", "/api/v1/users", "root before,api before,v1 before,handler,api after,root after"},
+		{"POST", "/api/hooks/stripe", "hooks before,handler"},
+		{"POST", "/api/hooks/legacy/paypal", "hooks before,handler"},
+	}
+	for _, test := range tests {
+		calls = nil
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), req)
+		if c := strings.Join(calls, ","); c != test.expected {
+			t.Errorf("%s: invalid calls %s", test.path, c)
+		}
+	}
+
+	if !strings.Contains(rr.Snapshot(), "router /api/hooks isolated\n") {
+		t.Errorf("isolated router missing from snapshot\n%s", rr.Snapshot())
+	}
+}
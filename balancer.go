@@ -0,0 +1,291 @@
+package router
+
+import (
+	"hash/fnv"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var upstreamCtxKey = NewKey[*proxyAttempt]("upstream")
+
+// BalancedProxyOptions configures a reverse proxy balancing requests between upstreams
+type BalancedProxyOptions struct {
+	ProxyOptions
+
+	// Balancer picks the upstream of each request, RoundRobin by default
+	Balancer Balancer
+
+	// MaxFails is the number of consecutive failed requests after which an upstream is ejected,
+	// 3 by default. Requests fail when the upstream can't be reached or responds with a 502, 503
+	// or 504.
+	MaxFails int
+
+	// EjectFor is how long an ejected upstream is left out of the rotation, 30 seconds by default.
+	// Once it's back, a single failure ejects it again.
+	EjectFor time.Duration
+}
+
+// Balancer picks the upstream of a request among those that haven't been ejected
+type Balancer interface {
+	Pick(r *http.Request, upstreams []*Upstream) *Upstream
+}
+
+// Upstream is a target of a balanced proxy
+type Upstream struct {
+	url   *url.URL
+	proxy http.Handler
+
+	// active is the number of requests in flight, read by LeastConnections
+	active int64
+
+	mu           sync.Mutex
+	fails        int
+	ejectedUntil time.Time
+	stats        UpstreamStats
+}
+
+// URL is the address of the upstream
+func (u *Upstream) URL() *url.URL {
+	return u.url
+}
+
+// Active is the number of requests the upstream is serving
+func (u *Upstream) Active() int64 {
+	return atomic.LoadInt64(&u.active)
+}
+
+// UpstreamStats are the counters of a single upstream
+type UpstreamStats struct {
+	URL      string `json:"url"`
+	Requests uint64 `json:"requests"`
+	Failures uint64 `json:"failures"`
+	Active   int64  `json:"active"`
+	Ejected  bool   `json:"ejected"`
+
+	// MeanLatency and MaxLatency are the time taken by the upstream's responses, up to the end of the
+	// body
+	MeanLatency time.Duration `json:"mean_latency"`
+	MaxLatency  time.Duration `json:"max_latency"`
+
+	totalLatency time.Duration
+}
+
+// BalancedProxy is a reverse proxy balancing requests between upstreams, ejecting those that fail
+type BalancedProxy struct {
+	opts      BalancedProxyOptions
+	upstreams []*Upstream
+}
+
+// proxyAttempt records whether the request to the upstream failed
+type proxyAttempt struct {
+	failed bool
+}
+
+// NewBalancedProxy creates a reverse proxy balancing requests between the targets, streaming the
+// requests and responses like NewProxy. Upstreams that keep failing are ejected for a while, and
+// requests are only sent to the ejected upstreams when all of them are.
+//
+//	rr.Mount("/api", router.NewBalancedProxy(targets, router.BalancedProxyOptions{
+//		Balancer: router.ConsistentHash("X-Tenant"),
+//	}))
+func NewBalancedProxy(targets []*url.URL, opts BalancedProxyOptions) *BalancedProxy {
+	if len(targets) == 0 {
+		panic("router: balanced proxy has no targets")
+	}
+	if opts.Balancer == nil {
+		opts.Balancer = RoundRobin()
+	}
+	if opts.MaxFails <= 0 {
+		opts.MaxFails = 3
+	}
+	if opts.EjectFor <= 0 {
+		opts.EjectFor = 30 * time.Second
+	}
+
+	p := &BalancedProxy{opts: opts}
+	for _, target := range targets {
+		rp := newReverseProxy(target, opts.ProxyOptions)
+		rp.ModifyResponse = func(resp *http.Response) error {
+			switch resp.StatusCode {
+			case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+				attempt, _ := Get(resp.Request.Context(), upstreamCtxKey)
+				attempt.failed = true
+			}
+			return nil
+		}
+		rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			attempt, _ := Get(r.Context(), upstreamCtxKey)
+			attempt.failed = true
+			if opts.ErrorLog != nil {
+				opts.ErrorLog.Printf("http: proxy error: %v", err)
+			} else {
+				log.Printf("http: proxy error: %v", err)
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		p.upstreams = append(p.upstreams, &Upstream{
+			url:   target,
+			proxy: rp,
+			stats: UpstreamStats{URL: target.String()},
+		})
+	}
+	return p
+}
+
+func (p *BalancedProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u := p.opts.Balancer.Pick(r, p.available())
+	attempt := &proxyAttempt{}
+
+	atomic.AddInt64(&u.active, 1)
+	start := now()
+	u.proxy.ServeHTTP(w, r.WithContext(Set(r.Context(), upstreamCtxKey, attempt)))
+	latency := now().Sub(start)
+	atomic.AddInt64(&u.active, -1)
+
+	u.record(attempt.failed, latency, p.opts)
+}
+
+// available lists the upstreams that haven't been ejected, or all of them if they all have
+func (p *BalancedProxy) available() []*Upstream {
+	t := now()
+	available := make([]*Upstream, 0, len(p.upstreams))
+	for _, u := range p.upstreams {
+		u.mu.Lock()
+		ejected := t.Before(u.ejectedUntil)
+		u.mu.Unlock()
+		if !ejected {
+			available = append(available, u)
+		}
+	}
+	if len(available) == 0 {
+		return p.upstreams
+	}
+	return available
+}
+
+// record updates the counters of the upstream, ejecting it once it has failed too many times in a
+// row
+func (u *Upstream) record(failed bool, latency time.Duration, opts BalancedProxyOptions) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.stats.Requests++
+	u.stats.totalLatency += latency
+	if latency > u.stats.MaxLatency {
+		u.stats.MaxLatency = latency
+	}
+	if !failed {
+		// the upstream recovered from its ejection
+		u.fails, u.ejectedUntil = 0, time.Time{}
+		return
+	}
+	u.stats.Failures++
+	u.fails++
+	if u.fails >= opts.MaxFails || !u.ejectedUntil.IsZero() {
+		u.ejectedUntil = now().Add(opts.EjectFor)
+		u.fails = 0
+	}
+}
+
+// Stats returns a copy of the counters of the upstreams, in the order of the targets
+func (p *BalancedProxy) Stats() []UpstreamStats {
+	t := now()
+	stats := make([]UpstreamStats, len(p.upstreams))
+	for i, u := range p.upstreams {
+		u.mu.Lock()
+		stats[i] = u.stats
+		stats[i].Ejected = t.Before(u.ejectedUntil)
+		u.mu.Unlock()
+		stats[i].Active = u.Active()
+		if stats[i].Requests > 0 {
+			stats[i].MeanLatency = stats[i].totalLatency / time.Duration(stats[i].Requests)
+		}
+		stats[i].totalLatency = 0
+	}
+	return stats
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+// RoundRobin sends requests to each upstream in turn
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+func (b *roundRobin) Pick(r *http.Request, upstreams []*Upstream) *Upstream {
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return upstreams[n%uint64(len(upstreams))]
+}
+
+type leastConnections struct {
+	rr roundRobin
+}
+
+// LeastConnections sends requests to the upstream with the fewest requests in flight, taking turns
+// between those tied
+func LeastConnections() Balancer {
+	return &leastConnections{}
+}
+
+func (b *leastConnections) Pick(r *http.Request, upstreams []*Upstream) *Upstream {
+	var least []*Upstream
+	min := int64(-1)
+	for _, u := range upstreams {
+		active := u.Active()
+		switch {
+		case min < 0 || active < min:
+			min, least = active, []*Upstream{u}
+		case active == min:
+			least = append(least, u)
+		}
+	}
+	return b.rr.Pick(r, least)
+}
+
+type consistentHash struct {
+	header string
+	rr     roundRobin
+}
+
+// ConsistentHash sends the requests with the same value of the header to the same upstream, ex. to
+// keep a tenant's requests on a warm cache. When an upstream is ejected only its requests move to
+// other upstreams. Requests without the header are balanced with RoundRobin.
+func ConsistentHash(header string) Balancer {
+	return &consistentHash{header: header}
+}
+
+func (b *consistentHash) Pick(r *http.Request, upstreams []*Upstream) *Upstream {
+	key := r.Header.Get(b.header)
+	if key == "" {
+		return b.rr.Pick(r, upstreams)
+	}
+
+	// rendezvous hashing: each key goes to the upstream it scores highest with
+	var best *Upstream
+	var bestScore uint64
+	for _, u := range upstreams {
+		h := fnv.New64a()
+		h.Write([]byte(u.url.String() + "|" + key))
+		if score := mix64(h.Sum64()); best == nil || score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+	return best
+}
+
+// mix64 spreads the bits of the hash, as FNV barely changes its high bits when only the end of the
+// input differs
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,152 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newBackends(t *testing.T, handlers ...http.HandlerFunc) []*url.URL {
+	var targets []*url.URL
+	for _, h := range handlers {
+		backend := httptest.NewServer(h)
+		t.Cleanup(backend.Close)
+		target, _ := url.Parse(backend.URL)
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func backendNamed(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	}
+}
+
+func proxyGet(h http.Handler, header string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	if header != "" {
+		req.Header.Set("X-Tenant", header)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBalancedProxyRoundRobin(t *testing.T) {
+	targets := newBackends(t, backendNamed("a"), backendNamed("b"), backendNamed("c"))
+	rr := New("/")
+	p := NewBalancedProxy(targets, BalancedProxyOptions{})
+	rr.Mount("/api", p)
+
+	var served string
+	for i := 0; i < 6; i++ {
+		served += proxyGet(rr, "").Body.String()
+	}
+	if served != "abcabc" {
+		t.Errorf("invalid rotation %q", served)
+	}
+	for _, stats := range p.Stats() {
+		if stats.Requests != 2 || stats.Failures != 0 || stats.MeanLatency <= 0 || stats.MaxLatency < stats.MeanLatency {
+			t.Errorf("invalid stats %+v", stats)
+		}
+	}
+}
+
+func TestBalancedProxyEjection(t *testing.T) {
+	defer func() { now = time.Now }()
+	t0 := time.Now()
+	now = func() time.Time { return t0 }
+
+	healthy := true
+	targets := newBackends(t, backendNamed("a"), func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprint(w, "b")
+	})
+	targets = append(targets, &url.URL{Scheme: "http", Host: "127.0.0.1:1"})
+	p := NewBalancedProxy(targets, BalancedProxyOptions{
+		ProxyOptions: ProxyOptions{ErrorLog: log.New(io.Discard, "", 0)},
+		MaxFails:     2,
+		EjectFor:     time.Minute,
+	})
+
+	healthy = false
+	codes := make(map[int]int)
+	for i := 0; i < 6; i++ {
+		codes[proxyGet(p, "").Code]++
+	}
+	if codes[http.StatusOK] != 2 || codes[http.StatusServiceUnavailable] != 2 || codes[http.StatusBadGateway] != 2 {
+		t.Errorf("invalid statuses %v", codes)
+	}
+	stats := p.Stats()
+	if stats[0].Ejected || !stats[1].Ejected || !stats[2].Ejected || stats[1].Failures != 2 || stats[2].Failures != 2 {
+		t.Errorf("invalid stats %+v", stats)
+	}
+
+	for i := 0; i < 4; i++ {
+		if body := proxyGet(p, "").Body.String(); body != "a" {
+			t.Errorf("ejected upstreams should be skipped, got %q", body)
+		}
+	}
+
+	// once the ejection is over the upstream is back in the rotation
+	healthy = true
+	now = func() time.Time { return t0.Add(2 * time.Minute) }
+	served := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		served[proxyGet(p, "").Body.String()] = true
+	}
+	if !served["b"] {
+		t.Errorf("recovered upstream should be served, got %v", served)
+	}
+}
+
+func TestConsistentHash(t *testing.T) {
+	targets := newBackends(t, backendNamed("a"), backendNamed("b"), backendNamed("c"))
+	p := NewBalancedProxy(targets, BalancedProxyOptions{Balancer: ConsistentHash("X-Tenant")})
+
+	assigned := make(map[string]string)
+	used := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		tenant := fmt.Sprintf("tenant-%d", i)
+		assigned[tenant] = proxyGet(p, tenant).Body.String()
+		used[assigned[tenant]] = true
+		if again := proxyGet(p, tenant).Body.String(); again != assigned[tenant] {
+			t.Errorf("%s: sent to %s then %s", tenant, assigned[tenant], again)
+		}
+	}
+	if len(used) != 3 {
+		t.Errorf("tenants should be spread over the upstreams, got %v", used)
+	}
+
+	// removing an upstream only moves its own tenants
+	b := p.upstreams[1]
+	for tenant, upstream := range assigned {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Tenant", tenant)
+		picked := p.opts.Balancer.Pick(req, []*Upstream{p.upstreams[0], p.upstreams[2]})
+		if upstream != "b" && picked.URL() != targets[map[string]int{"a": 0, "c": 2}[upstream]] {
+			t.Errorf("%s moved from %s", tenant, upstream)
+		}
+		if picked == b {
+			t.Errorf("%s sent to a removed upstream", tenant)
+		}
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	upstreams := []*Upstream{{active: 3}, {active: 1}, {active: 2}, {active: 1}}
+	b := LeastConnections()
+	req := httptest.NewRequest("GET", "/", nil)
+	first, second := b.Pick(req, upstreams), b.Pick(req, upstreams)
+	if first != upstreams[1] || second != upstreams[3] {
+		t.Error("should take turns between the upstreams with the fewest connections")
+	}
+}
@@ -0,0 +1,127 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func benchmarkWildcard(b *testing.B, depth int) {
+	rr := New("/")
+	rr.Get("/files/*", func(w http.ResponseWriter, r *http.Request) {})
+
+	path := "/files" + strings.Repeat("/segment", depth)
+	route := Route{method: "GET", path: "/files/*"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matches(&rr, route, "GET", path, false)
+	}
+}
+
+func BenchmarkWildcardDepth1(b *testing.B)  { benchmarkWildcard(b, 1) }
+func BenchmarkWildcardDepth10(b *testing.B) { benchmarkWildcard(b, 10) }
+func BenchmarkWildcardDepth50(b *testing.B) { benchmarkWildcard(b, 50) }
+
+func BenchmarkWildcardServeHTTP(b *testing.B) {
+	rr := New("/")
+	rr.Get("/files/*", func(w http.ResponseWriter, r *http.Request) {})
+	path := "/files" + strings.Repeat("/segment", 10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// the router binds values to the request, so a fresh one is needed each time
+		req := httptest.NewRequest("GET", path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkParamsServeHTTP(b *testing.B) {
+	rr := New("/")
+	rr.Get("/orgs/:org/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		Param(r.Context(), "org")
+		Param(r.Context(), "id")
+	})
+	req := httptest.NewRequest("GET", "/orgs/acme/users/123", nil)
+	c := req.Context()
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// the router binds values to the request, so its context is restored each time
+		rr.ServeHTTP(w, req.WithContext(c))
+	}
+}
+
+func BenchmarkParamsMatch(b *testing.B) {
+	rr := New("/")
+	route := Route{method: "GET", path: "/orgs/:org/users/:id"}
+	params := acquireParams()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchRoute(&rr, route, "GET", "/orgs/acme/users/123", false, false, params)
+	}
+}
+
+// benchmarkRoutes registers n routes of the kind on the router, returning the path of a request
+// matching the last of them. Routes are matched by scanning, so the table size is what's measured.
+func benchmarkRoutes(rr *Router, kind string, n int) string {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	var path string
+	for i := 0; i < n; i++ {
+		switch kind {
+		case "static":
+			path = fmt.Sprintf("/static/route%d/items", i)
+			rr.Get(path, h)
+		case "param":
+			rr.Get(fmt.Sprintf("/param%d/:org/users/:id", i), h)
+			path = fmt.Sprintf("/param%d/acme/users/123", i)
+		case "wildcard":
+			rr.Get(fmt.Sprintf("/files%d/*", i), h)
+			path = fmt.Sprintf("/files%d/a/b/c/d.txt", i)
+		case "subrouter":
+			// the routes are spread over a chain of nested subrouters, matched at the deepest
+			sub := rr
+			for _, part := range []string{"/v1", "/orgs", "/teams", "/projects"} {
+				sub = sub.SubRouter(part)
+			}
+			for j := 0; j < n; j++ {
+				sub.Get(fmt.Sprintf("/route%d/:id", j), h)
+			}
+			return fmt.Sprintf("/v1/orgs/teams/projects/route%d/123", n-1)
+		}
+	}
+	return path
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	for _, kind := range []string{"static", "param", "wildcard", "subrouter"} {
+		for _, n := range []int{10, 100, 1000} {
+			b.Run(fmt.Sprintf("%s/%d", kind, n), func(b *testing.B) {
+				rr := New("/")
+				path := benchmarkRoutes(&rr, kind, n)
+				req := httptest.NewRequest("GET", path, nil)
+				c := req.Context()
+				w := httptest.NewRecorder()
+
+				rr.ServeHTTP(w, req.WithContext(c))
+				if w.Code != http.StatusOK {
+					b.Fatalf("%s: invalid status %d", path, w.Code)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					rr.ServeHTTP(w, req.WithContext(c))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// BindOptions configures how request bodies are decoded
+type BindOptions struct {
+	// MaxBytes limits the size of the body, 1MB when zero
+	MaxBytes int64
+
+	// AllowUnknownFields accepts fields without a matching struct field rather than rejecting the body
+	AllowUnknownFields bool
+}
+
+// DefaultBindOptions are the options used by Bind
+var DefaultBindOptions = BindOptions{MaxBytes: 1 << 20}
+
+// BindError is a request body that couldn't be decoded. Failing the request with it responds with
+// its status, typically a 400.
+type BindError struct {
+	Status int
+
+	// Field is the JSON pointer of the offending field, if known
+	Field   string
+	Message string
+}
+
+func (e *BindError) Error() string {
+	if e.Field != "" {
+		return e.Field + ": " + e.Message
+	}
+	return e.Message
+}
+
+// As allows a BindError to be handled as an *Error
+func (e *BindError) As(target interface{}) bool {
+	t, ok := target.(**Error)
+	if !ok {
+		return false
+	}
+	*t = &Error{Status: e.Status, Message: e.Error(), Err: e}
+	return true
+}
+
+var errBodyTooLarge = errors.New("body too large")
+
+// Bind decodes the request's JSON body into the struct pointed to by dst, decrypts the fields
+// tagged for encryption when a KMS is in use, and validates it. Bodies that can't be decoded
+// result in a *BindError and invalid fields in ValidationErrors, both of which can be passed
+// straight on to Fail.
+//
+//	var in signup
+//	if err := router.Bind(r, &in); err != nil {
+//		router.Fail(r, err)
+//		return
+//	}
+func Bind(r *http.Request, dst interface{}) error {
+	return BindWith(r, dst, DefaultBindOptions)
+}
+
+// BindWith binds the request's JSON body using the options
+func BindWith(r *http.Request, dst interface{}, opts BindOptions) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+			return &BindError{Status: http.StatusUnsupportedMediaType, Message: "content type must be application/json"}
+		}
+	}
+	if r.Body == nil {
+		return &BindError{Status: http.StatusBadRequest, Message: "body is empty"}
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultBindOptions.MaxBytes
+	}
+
+	dec := json.NewDecoder(&limitedReader{r: r.Body, n: opts.MaxBytes})
+	if !opts.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err, opts.MaxBytes)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		if errors.Is(err, errBodyTooLarge) {
+			return decodeError(err, opts.MaxBytes)
+		}
+		return &BindError{Status: http.StatusBadRequest, Message: "body must contain a single JSON value"}
+	}
+
+	if kms := RequestKMS(r.Context()); kms != nil {
+		if err := DecryptFields(r.Context(), kms, dst); err != nil {
+			return err
+		}
+	}
+	return Validate(dst)
+}
+
+// decodeError converts the json decoding error into a client error
+func decodeError(err error, maxBytes int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		return &BindError{Status: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("body must not be larger than %d bytes", maxBytes)}
+	case errors.Is(err, io.EOF):
+		return &BindError{Status: http.StatusBadRequest, Message: "body is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BindError{Status: http.StatusBadRequest, Message: "body contains malformed JSON"}
+	case errors.As(err, &syntaxErr):
+		return &BindError{Status: http.StatusBadRequest, Message: fmt.Sprintf("body contains malformed JSON at offset %d", syntaxErr.Offset)}
+	case errors.As(err, &typeErr):
+		return &BindError{
+			Status:  http.StatusBadRequest,
+			Field:   JSONPointer(strings.Split(typeErr.Field, ".")...),
+			Message: "must be " + typeName(typeErr.Type.Kind().String()),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &BindError{Status: http.StatusBadRequest, Field: JSONPointer(name), Message: "is not allowed"}
+	}
+	return &BindError{Status: http.StatusBadRequest, Message: err.Error()}
+}
+
+// typeName describes the kind of value expected by a field
+func typeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		return "an integer"
+	case strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "string":
+		return "a string"
+	case kind == "slice", kind == "array":
+		return "an array"
+	}
+	return "an object"
+}
+
+// limitedReader fails reads once more than n bytes have been read
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
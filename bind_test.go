@@ -0,0 +1,108 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	type input struct {
+		Name  string `json:"name" validate:"required"`
+		Count int    `json:"count"`
+		Inner struct {
+			Flag bool `json:"flag"`
+		} `json:"inner"`
+	}
+
+	tests := []struct {
+		desc        string
+		contentType string
+		body        string
+		status      int
+		field       string
+	}{
+		{"valid", "application/json", `{"name": "a", "count": 2}`, 0, ""},
+		{"json suffix", "application/merge-patch+json; charset=utf-8", `{"name": "a"}`, 0, ""},
+		{"wrong content type", "text/plain", `{"name": "a"}`, 415, ""},
+		{"empty", "", ``, 400, ""},
+		{"malformed", "", `{"name": `, 400, ""},
+		{"syntax", "", `{"name" "a"}`, 400, ""},
+		{"wrong type", "", `{"name": "a", "count": "2"}`, 400, "/count"},
+		{"nested wrong type", "", `{"name": "a", "inner": {"flag": 1}}`, 400, "/inner/flag"},
+		{"unknown field", "", `{"name": "a", "admin": true}`, 400, "/admin"},
+		{"multiple values", "", `{"name": "a"} {"name": "b"}`, 400, ""},
+		{"too large", "", `{"name": "` + strings.Repeat("a", 100) + `"}`, 413, ""},
+		{"invalid", "", `{"count": 1}`, 422, "/name"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", "/", strings.NewReader(test.body))
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		var in input
+		err := BindWith(req, &in, BindOptions{MaxBytes: 64})
+		if test.status == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.desc, err)
+			}
+			continue
+		}
+		if status := ErrorStatus(err); status != test.status {
+			t.Errorf("%s: invalid status %d != %d (%v)", test.desc, status, test.status, err)
+		}
+		errs, _ := validationErrors(err)
+		if test.field != "" && (len(errs) != 1 || errs[0].Field != test.field) {
+			t.Errorf("%s: invalid field errors %v", test.desc, errs)
+		}
+	}
+}
+
+func TestBindDecryptsFields(t *testing.T) {
+	kms, _ := NewAESKMS(make([]byte, 32))
+	secret := struct {
+		SSN string `json:"ssn" encrypt:"true"`
+	}{SSN: "123-45-6789"}
+	if err := EncryptFields(context.Background(), kms, &secret); err != nil {
+		t.Error(err)
+		return
+	}
+
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(`{"ssn": "`+secret.SSN+`"}`))
+	req = req.WithContext(Set(req.Context(), kmsCtxKey, KMS(kms)))
+	var in struct {
+		SSN string `json:"ssn" encrypt:"true"`
+	}
+	if err := Bind(req, &in); err != nil {
+		t.Error(err)
+		return
+	}
+	if in.SSN != "123-45-6789" {
+		t.Errorf("field not decrypted %q", in.SSN)
+	}
+}
+
+func TestBindErrorResponse(t *testing.T) {
+	rr := New("/")
+	rr.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			Age int `json:"age"`
+		}
+		if err := Bind(r, &in); err != nil {
+			Fail(r, err)
+		}
+	})
+
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"age": "ten"}`))
+	req.Header.Set("Accept", "application/problem+json")
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	expected := `{"detail":"/age: must be an integer","errors":[{"source":"body","field":"/age","message":"must be an integer"}],"status":400,"title":"Bad Request","type":"about:blank"}` + "\n"
+	if rec.Code != 400 || rec.Body.String() != expected {
+		t.Errorf("invalid response %d %s", rec.Code, rec.Body.String())
+	}
+}
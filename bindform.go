@@ -0,0 +1,222 @@
+package router
+
+import (
+	"encoding"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// time layouts accepted for time.Time fields without a `layout` tag
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindQuery populates the struct pointed to by dst from the url's query params, then validates it.
+// Fields are named by their `query` tag, and nested struct fields are prefixed with their parent's
+// name, ex. `address.city`. Slices are filled from repeated params and time.Time fields are parsed
+// as RFC 3339 or dates, unless a `layout` tag is given. Values that can't be converted result in
+// ValidationErrors.
+//
+//	type search struct {
+//		Terms []string  `query:"q"`
+//		Page  int       `query:"page" validate:"min=1"`
+//		Since time.Time `query:"since" layout:"2006-01-02"`
+//	}
+func BindQuery(r *http.Request, dst interface{}) error {
+	return bindValues(r.URL.Query(), dst, "query", SourceQuery)
+}
+
+// BindForm populates the struct pointed to by dst from the posted form values, including those of
+// multipart forms, naming fields by their `form` tag. It otherwise behaves like BindQuery.
+func BindForm(r *http.Request, dst interface{}) error {
+	var values url.Values
+	mediaType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(DefaultBindOptions.MaxBytes); err != nil {
+			return &BindError{Status: http.StatusBadRequest, Message: "body contains a malformed form"}
+		}
+		values = r.MultipartForm.Value
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return &BindError{Status: http.StatusBadRequest, Message: "body contains a malformed form"}
+		}
+		values = r.PostForm
+	}
+	return bindValues(values, dst, "form", SourceBody)
+}
+
+// bindValues decodes the values into dst and validates the result
+func bindValues(values url.Values, dst interface{}, tag, source string) error {
+	var errs ValidationErrors
+	decodeStruct(values, reflect.ValueOf(dst).Elem(), tag, source, "", nil, &errs)
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	return validateSource(dst, source, tag)
+}
+
+// decodeStruct sets the fields of the struct from the values prefixed with the key
+func decodeStruct(values url.Values, v reflect.Value, tag, source, prefix string, path []string, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		sf := t.Field(i)
+		if !exported(sf) {
+			continue
+		}
+		name, ok := fieldName(sf, tag)
+		if !ok {
+			continue
+		}
+		key, fieldPath := prefix+name, append(path[:len(path):len(path)], name)
+		field := v.Field(i)
+
+		ft := field.Type()
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType && !reflect.PtrTo(ft).Implements(textUnmarshalerType) {
+			// embedded structs share their parent's names
+			nested, nestedPath := key+".", fieldPath
+			if sf.Anonymous && sf.Tag.Get(tag) == "" {
+				nested, nestedPath = prefix, path
+			} else if !hasPrefix(values, nested) {
+				continue
+			}
+			if field.Kind() == reflect.Ptr {
+				if !field.CanSet() {
+					continue
+				}
+				if field.IsNil() {
+					field.Set(reflect.New(ft))
+				}
+				field = field.Elem()
+			}
+			decodeStruct(values, field, tag, source, nested, nestedPath, errs)
+			continue
+		}
+
+		vals, ok := values[key]
+		if !ok {
+			continue
+		}
+		layout := sf.Tag.Get("layout")
+		if field.Kind() == reflect.Slice && ft != reflect.TypeOf([]byte(nil)) {
+			slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+			for j, val := range vals {
+				if msg := setValue(slice.Index(j), val, layout); msg != "" {
+					errs.Add(source, msg, append(fieldPath, strconv.Itoa(j))...)
+				}
+			}
+			field.Set(slice)
+			continue
+		}
+		if msg := setValue(field, vals[0], layout); msg != "" {
+			errs.Add(source, msg, fieldPath...)
+		}
+	}
+}
+
+// hasPrefix reports whether any of the keys start with the prefix
+func hasPrefix(values url.Values, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setValue converts the string into the field's type, returning a message if it can't be. Empty
+// strings leave other types unset.
+func setValue(v reflect.Value, s, layout string) string {
+	if s == "" && indirectType(v.Type()).Kind() != reflect.String {
+		return ""
+	}
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if msg := setValue(ptr.Elem(), s, layout); msg != "" {
+			return msg
+		}
+		v.Set(ptr)
+		return ""
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) && v.Type() != timeType {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return "is invalid"
+		}
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		// checkboxes are sent as `on`
+		b, err := strconv.ParseBool(s)
+		if s == "on" {
+			b, err = true, nil
+		}
+		if err != nil {
+			return "must be a boolean"
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return "must be a duration"
+			}
+			v.SetInt(int64(d))
+			return ""
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return "must be a positive integer"
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		v.SetFloat(n)
+	case reflect.Struct:
+		if v.Type() != timeType {
+			return "is not supported"
+		}
+		layouts := timeLayouts
+		if layout != "" {
+			layouts = []string{layout}
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return ""
+			}
+		}
+		return "must be a date"
+	default:
+		return "is not supported"
+	}
+	return ""
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPaging struct {
+	Page int `query:"page" validate:"min=1"`
+}
+
+type testSearch struct {
+	testPaging
+	Terms   []string      `query:"q"`
+	IDs     []int         `query:"id"`
+	Since   time.Time     `query:"since"`
+	Until   *time.Time    `query:"until" layout:"02/01/2006"`
+	Exact   bool          `query:"exact"`
+	Timeout time.Duration `query:"timeout"`
+	Owner   *struct {
+		Name string `query:"name"`
+	} `query:"owner"`
+	Skipped string `query:"-"`
+}
+
+func TestBindQuery(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/?page=2&q=go&q=router&id=1&id=2&since=2020-01-02&until=03/02/2021&exact=true&timeout=5s&owner.name=bob&Skipped=x&other=1", nil)
+	var s testSearch
+	if err := BindQuery(req, &s); err != nil {
+		t.Error(err)
+		return
+	}
+	if s.Page != 2 || strings.Join(s.Terms, ",") != "go,router" || len(s.IDs) != 2 || s.IDs[1] != 2 {
+		t.Errorf("invalid values %+v", s)
+	}
+	if !s.Since.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) || s.Until == nil || s.Until.Month() != time.February {
+		t.Errorf("invalid times %v %v", s.Since, s.Until)
+	}
+	if !s.Exact || s.Timeout != 5*time.Second || s.Owner == nil || s.Owner.Name != "bob" || s.Skipped != "" {
+		t.Errorf("invalid values %+v", s)
+	}
+
+	req, _ = http.NewRequest("GET", "/?page=-1", nil)
+	s = testSearch{}
+	if err := BindQuery(req, &s); err == nil || err.Error() != "query /page: must be at least 1" {
+		t.Errorf("invalid validation error %v", err)
+	}
+	if s.Owner != nil {
+		t.Error("nested struct without values should not be allocated")
+	}
+
+	req, _ = http.NewRequest("GET", "/?page=x&id=1&id=b&since=yesterday", nil)
+	err := BindQuery(req, &testSearch{})
+	expected := "query /page: must be an integer; query /id/1: must be an integer; query /since: must be a date"
+	if err == nil || err.Error() != expected {
+		t.Errorf("invalid conversion errors %v", err)
+	}
+	if ErrorStatus(err) != 422 {
+		t.Errorf("invalid status %d", ErrorStatus(err))
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	type signup struct {
+		Email   string `form:"email" validate:"required"`
+		Terms   bool   `form:"terms"`
+		Address struct {
+			City string `form:"city"`
+		} `form:"address"`
+	}
+
+	form := url.Values{"email": {"a@b.co"}, "terms": {"on"}, "address.city": {"Calgary"}}
+	req, _ := http.NewRequest("POST", "/?email=query@b.co", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var s signup
+	if err := BindForm(req, &s); err != nil {
+		t.Error(err)
+		return
+	}
+	if s.Email != "a@b.co" || !s.Terms || s.Address.City != "Calgary" {
+		t.Errorf("invalid values %+v", s)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("address.city", "Edmonton")
+	mw.Close()
+	req, _ = http.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	s = signup{}
+	err := BindForm(req, &s)
+	if s.Address.City != "Edmonton" {
+		t.Errorf("multipart values not bound %+v", s)
+	}
+	if err == nil || err.Error() != "body /email: is required" {
+		t.Errorf("invalid validation error %v", err)
+	}
+}
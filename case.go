@@ -0,0 +1,103 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CasePolicy controls how paths that only differ from a route by case are treated
+type CasePolicy int
+
+const (
+	// CaseSensitive only matches paths with the same case as the route
+	CaseSensitive CasePolicy = iota
+
+	// CaseInsensitive matches `/Users/123` to the `/users/:id` route directly
+	CaseInsensitive
+
+	// RedirectCase redirects `/Users/123` to the canonical `/users/123` path, using a 301 for GET
+	// and HEAD requests and a 308 for all others
+	RedirectCase
+)
+
+// PathCase sets the case policy used for all routes, including those of the subrouters. Routes with
+// the exact case of the request's path are always preferred.
+func (r *Router) PathCase(policy CasePolicy) {
+	r.casePolicy = policy
+}
+
+// serveFolded serves or redirects to the route matching the path without regard to case, returning
+// false if no route matches
+func (r Router) serveFolded(method string, w http.ResponseWriter, req *http.Request) bool {
+	rr := r.findRouter(req.URL.Path, true)
+	if rr == nil {
+		return false
+	}
+	path := req.URL.Path[len(rr.basePath):]
+	params := acquireParams()
+	for route, ep := range rr.routes {
+		if foldedMatch(rr, route, ep, method, path, req, params) != "" {
+			continue
+		}
+
+		target := canonicalPath(rr.fullPath(route.path), req.URL.Path)
+		if r.casePolicy == CaseInsensitive || target == req.URL.Path {
+			r.serve(rr, route, params, w, req)
+			return true
+		}
+		params.release()
+		location, status := caseLocation(req, target)
+		http.Redirect(w, req, location, status)
+		return true
+	}
+	params.release()
+	return false
+}
+
+// caseLocation is the location and status of the redirect to the canonical path
+func caseLocation(req *http.Request, target string) (string, int) {
+	target = localizePath(req, target)
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	status := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+	return target, status
+}
+
+// foldedMatch checks the route against the request without regard to case, returning why it was
+// rejected, empty if it matches
+func foldedMatch(rr *Router, route Route, ep *Endpoint, method, path string, req *http.Request, params *routeParams) string {
+	if reason := matchRoute(rr, route, method, path, ep.handler != nil, true, params); reason != "" {
+		return reason
+	}
+	if len(rr.queryEndpoints(route, req)) == 0 {
+		return rejectQuery
+	}
+	return ""
+}
+
+// canonicalPath builds the path using the case of the pattern's static segments, keeping the
+// values of the params and wildcard as they were requested
+func canonicalPath(pattern, path string) string {
+	patternParts, pathParts := slicePath(pattern), slicePath(path)
+	parts := make([]string, 0, len(pathParts))
+	for i, part := range patternParts {
+		if part == "*" {
+			parts = append(parts, pathParts[i:]...)
+			break
+		}
+		if strings.HasPrefix(part, ":") {
+			part = pathParts[i]
+		}
+		parts = append(parts, part)
+	}
+
+	canonical := "/" + strings.Join(parts, "/")
+	if hasTrailingSlash(path) && canonical != "/" {
+		canonical += "/"
+	}
+	return canonical
+}
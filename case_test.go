@@ -0,0 +1,55 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathCase(t *testing.T) {
+	tests := []struct {
+		policy         CasePolicy
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		location       string
+	}{
+		{CaseSensitive, "GET", "/users/AbC", 200, "AbC", ""},
+		{CaseSensitive, "GET", "/Users/AbC", 404, "", ""},
+		{CaseInsensitive, "GET", "/Users/AbC", 200, "AbC", ""},
+		{CaseInsensitive, "GET", "/ADMIN/Reports", 200, "reports", ""},
+		{RedirectCase, "GET", "/Users/AbC", 301, "", "/users/AbC"},
+		{RedirectCase, "GET", "/Users/AbC?x=1", 301, "", "/users/AbC?x=1"},
+		{RedirectCase, "POST", "/USERS/AbC", 308, "", "/users/AbC"},
+		{RedirectCase, "GET", "/Admin/reports/", 301, "", "/admin/reports/"},
+		{RedirectCase, "GET", "/Files/A/B", 301, "", "/files/A/B"},
+		{RedirectCase, "GET", "/missing", 404, "", ""},
+	}
+
+	for _, test := range tests {
+		user := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(Param(r.Context(), "id"))) }
+		rr := New("/")
+		rr.PathCase(test.policy)
+		rr.Get("/users/:id", user)
+		rr.Post("/users/:id", user)
+		rr.Get("/files/*", func(w http.ResponseWriter, r *http.Request) {})
+		rr.SubRouter("/admin").Get("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("reports"))
+		})
+
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%d %s %s: invalid status %d != %d", test.policy, test.method, test.path, rec.Code, test.expectedStatus)
+		}
+		if test.expectedBody != "" && rec.Body.String() != test.expectedBody {
+			t.Errorf("%d %s %s: invalid body %q != %q", test.policy, test.method, test.path, rec.Body.String(), test.expectedBody)
+		}
+		if loc := rec.Header().Get("Location"); loc != test.location {
+			t.Errorf("%d %s %s: invalid location %q != %q", test.policy, test.method, test.path, loc, test.location)
+		}
+	}
+}
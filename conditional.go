@@ -0,0 +1,91 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServeContentIfModified sets the ETag and Last-Modified headers of the response, then responds with
+// a 304 when the request's If-None-Match or If-Modified-Since headers show the client's copy is
+// current. Otherwise content writes the response, so it's only loaded and encoded when needed.
+// Either validator can be left empty.
+//
+//	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+//		user := findUser(router.Param(r.Context(), "id"))
+//		router.ServeContentIfModified(w, r, router.ETag(user.Version), user.UpdatedAt, func(w http.ResponseWriter, r *http.Request) {
+//			render.JSON(w, http.StatusOK, user)
+//		})
+//	})
+func ServeContentIfModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time, content http.HandlerFunc) {
+	if NotModified(w, r, etag, lastModified) {
+		return
+	}
+	content(w, r)
+}
+
+// NotModified sets the ETag and Last-Modified headers of the response and reports whether the
+// client's copy is current, in which case a 304 has been sent and the handler should return.
+// Only GET and HEAD requests are conditional, and If-Modified-Since is ignored when the request
+// has an If-None-Match header.
+//
+//	if router.NotModified(w, r, etag, time.Time{}) {
+//		return
+//	}
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatches(inm, etag) {
+			return false
+		}
+	} else if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(ims) {
+		return false
+	}
+
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ETag quotes the version as a strong entity tag, ex. `"42"`
+func ETag(version string) string {
+	return `"` + version + `"`
+}
+
+// WeakETag quotes the version as a weak entity tag, for responses that are equivalent but not
+// byte for byte identical between versions, ex. `W/"42"`
+func WeakETag(version string) string {
+	return "W/" + ETag(version)
+}
+
+// ContentETag is a strong entity tag derived from a hash of the content
+func ContentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return ETag(hex.EncodeToString(sum[:16]))
+}
+
+// etagMatches reports whether the If-None-Match header lists the entity tag, using the weak
+// comparison that conditional GETs call for
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
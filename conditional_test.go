@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServeContentIfModified(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	var loads int
+	rr := New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		ServeContentIfModified(w, r, ETag("v2"), modified, func(w http.ResponseWriter, r *http.Request) {
+			loads++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 1}`))
+		})
+	})
+	rr.Post("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if NotModified(w, r, ETag("v2"), time.Time{}) {
+			t.Error("only GET and HEAD requests are conditional")
+		}
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		status int
+	}{
+		{"unconditional", "GET", nil, http.StatusOK},
+		{"matching etag", "GET", map[string]string{"If-None-Match": `"v2"`}, http.StatusNotModified},
+		{"listed etag", "GET", map[string]string{"If-None-Match": `"v1", W/"v2"`}, http.StatusNotModified},
+		{"any etag", "HEAD", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"stale etag", "GET", map[string]string{"If-None-Match": `"v1"`}, http.StatusOK},
+		{"stale etag ignores date", "GET", map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusOK},
+		{"unmodified", "GET", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified", "GET", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"write", "POST", map[string]string{"If-None-Match": `"v2"`}, http.StatusOK},
+	}
+	for _, test := range tests {
+		loads = 0
+		req, _ := http.NewRequest(test.method, "/users/1", nil)
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.name, rec.Code)
+		}
+		if test.method == "POST" {
+			continue
+		}
+		if rec.Header().Get("ETag") != `"v2"` || rec.Header().Get("Last-Modified") != "Fri, 01 Mar 2024 12:00:00 GMT" {
+			t.Errorf("%s: invalid validators %v", test.name, rec.Header())
+		}
+		if notModified := test.status == http.StatusNotModified; notModified != (loads == 0) {
+			t.Errorf("%s: content should only be loaded when modified, loaded %d times", test.name, loads)
+		}
+		if test.status == http.StatusNotModified && (rec.Body.Len() > 0 || rec.Header().Get("Content-Type") != "") {
+			t.Errorf("%s: 304 should have no content %q", test.name, rec.Body.String())
+		}
+	}
+}
+
+func TestETags(t *testing.T) {
+	if tag := WeakETag("3"); tag != `W/"3"` {
+		t.Errorf("invalid weak etag %s", tag)
+	}
+	a, b := ContentETag([]byte("a")), ContentETag([]byte("b"))
+	if a == b || a != ContentETag([]byte("a")) || len(a) != 34 {
+		t.Errorf("invalid content etags %s %s", a, b)
+	}
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Config is a named configuration value that can be swapped while the router is serving, allowing
+// middleware to pick up new rate limits, IP lists or maintenance flags without being re-registered.
+// Middleware holds onto the Config and loads its current value on each request.
+type Config struct {
+	value atomic.Value
+}
+
+// configValue boxes the values stored, as atomic.Value requires them to share a concrete type
+type configValue struct {
+	v interface{}
+}
+
+// Load returns the current value, nil until one is set
+func (c *Config) Load() interface{} {
+	box, _ := c.value.Load().(configValue)
+	return box.v
+}
+
+// configs are the named configs shared by a router and its subrouters
+type configs struct {
+	mu     sync.Mutex
+	byName map[string]*Config
+}
+
+func newConfigs() *configs {
+	return &configs{byName: make(map[string]*Config)}
+}
+
+func (cs *configs) get(name string) *Config {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, ok := cs.byName[name]
+	if !ok {
+		c = &Config{}
+		cs.byName[name] = c
+	}
+	return c
+}
+
+// Config returns the named config, shared by the router and its subrouters, creating it if it
+// doesn't exist. Its value is set with UpdateConfig, before or after it's retrieved.
+//
+//	limits := rr.Config("ratelimit")
+//	rr.UpdateConfig("ratelimit", middleware.Limit{Requests: 100, Window: time.Minute})
+//	rr.Before(middleware.RateLimitConfig(limits, nil))
+func (r Router) Config(name string) *Config {
+	return r.configs.get(name)
+}
+
+// UpdateConfig atomically swaps the value of the named config, which is seen by the requests that
+// load it afterwards. It's safe to call while serving, ex. from an admin handler.
+func (r Router) UpdateConfig(name string, cfg interface{}) {
+	r.configs.get(name).value.Store(configValue{v: cfg})
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	rr := New("/")
+	api := rr.SubRouter("/api")
+	maintenance := api.Config("maintenance")
+	if maintenance.Load() != nil {
+		t.Errorf("unset config should be nil, got %v", maintenance.Load())
+	}
+
+	api.Before(func(w http.ResponseWriter, r *http.Request) {
+		if on, _ := maintenance.Load().(bool); on {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			HaltRequest(r)
+		}
+	})
+	api.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	request := func() int {
+		r, _ := http.NewRequest("GET", "/api/users", nil)
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := request(); code != 200 {
+		t.Errorf("invalid status %d", code)
+	}
+	// configs are shared with subrouters, and values can change type
+	rr.UpdateConfig("maintenance", true)
+	if code := request(); code != 503 {
+		t.Errorf("invalid status during maintenance %d", code)
+	}
+	rr.UpdateConfig("maintenance", "off")
+	if code := request(); code != 200 {
+		t.Errorf("invalid status after maintenance %d", code)
+	}
+	if rr.Config("maintenance") != maintenance {
+		t.Error("config should be shared by name")
+	}
+}
+
+func TestUpdateConfigConcurrent(t *testing.T) {
+	rr := New("/")
+	cfg := rr.Config("ips")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rr.UpdateConfig("ips", []string{"10.0.0.1"})
+		}()
+		go func() {
+			defer wg.Done()
+			if v := cfg.Load(); v != nil {
+				if _, ok := v.([]string); !ok {
+					t.Errorf("invalid value %v", v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
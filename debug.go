@@ -0,0 +1,142 @@
+package router
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+)
+
+// DebugRoute describes a route listed by EnableDebugRoutes
+type DebugRoute struct {
+	// Method is `*` for routes matching all methods
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Name    string `json:"name,omitempty"`
+
+	// Handler is the name of the handler function, ex. `main.listUsers`
+	Handler string `json:"handler"`
+
+	// Middleware is the chain run before the handler, the Before and Use middleware of its router
+	// and those it inherits from, followed by the policies of the routers leading to it and of the route
+	// itself, outermost first
+	Middleware []string `json:"middleware"`
+
+	// After is the After middleware run once the handler is done
+	After []string `json:"after,omitempty"`
+
+	Env      string `json:"env,omitempty"`
+	Inactive bool   `json:"inactive,omitempty"`
+	Stub     bool   `json:"stub,omitempty"`
+}
+
+// EnableDebugRoutes serves the route table of the router and its subrouters at the path, as JSON
+// for clients accepting application/json and as an HTML table otherwise. Requests are served when
+// the optional authorize callback returns nil, and failed with its error otherwise.
+//
+//	rr.EnableDebugRoutes("/_routes", func(r *http.Request) error {
+//		if !isAdmin(r) {
+//			return router.NotFoundErr
+//		}
+//		return nil
+//	})
+func (r *Router) EnableDebugRoutes(path string, authorize ...func(*http.Request) error) *Endpoint {
+	return r.Get(path, func(w http.ResponseWriter, req *http.Request) {
+		for _, fn := range authorize {
+			if err := fn(req); err != nil {
+				Fail(req, err)
+				return
+			}
+		}
+
+		current := r
+		if reloaded := r.reloaded(); reloaded != nil {
+			current = reloaded
+		}
+		routes := current.debugRoutes(nil, nil, nil, nil, false)
+		if negotiate(req.Header.Get("Accept"), []string{"text/html", "application/json"}) == "application/json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(routes)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugRoutesHTML.Execute(w, routes)
+	}).NoIndex()
+}
+
+// debugRoutes describes the routes of the router and its subrouters, which inherit the policies
+// and, unless they're isolated, the middleware
+func (r Router) debugRoutes(inherited []Policy, before, use, after []string, inactive bool) []DebugRoute {
+	inherited = append(append([]Policy(nil), inherited...), r.attached...)
+	if r.isolated {
+		before, use, after = nil, nil, nil
+	}
+	before = append([]string(nil), before...)
+	for _, fn := range r.mw {
+		before = append(before, funcName(fn))
+	}
+	use = append([]string(nil), use...)
+	for _, fn := range r.use {
+		use = append(use, funcName(fn))
+	}
+	var own []string
+	for _, fn := range r.after {
+		own = append(own, funcName(fn))
+	}
+	after = append(own, after...)
+
+	routes := make([]DebugRoute, 0, len(r.routes))
+	for route, ep := range r.routes {
+		info := r.routeInfo(route, ep, inherited)
+		routes = append(routes, DebugRoute{
+			Method:     method(info.Method),
+			Pattern:    info.Pattern,
+			Name:       info.Name,
+			Handler:    ep.handlerName(),
+			Middleware: append(append(append([]string{}, before...), use...), policyNames(info.Policies)...),
+			After:      after,
+			Env:        info.Env,
+			Inactive:   inactive,
+			Stub:       info.Stub,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern == routes[j].Pattern {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Pattern < routes[j].Pattern
+	})
+
+	for _, sub := range r.subRouters {
+		routes = append(routes, sub.debugRoutes(inherited, before, use, after, inactive)...)
+	}
+	for _, shadow := range r.inactive {
+		routes = append(routes, shadow.debugRoutes(inherited, before, use, after, true)...)
+	}
+	return routes
+}
+
+var debugRoutesHTML = template.Must(template.New("routes").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Routes</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; vertical-align: top; padding: .3em .8em; border-bottom: 1px solid #ddd; }
+td { font-family: monospace; }
+.inactive { color: #999; }
+</style>
+</head>
+<body>
+<h1>Routes</h1>
+<table>
+<tr><th>Method</th><th>Pattern</th><th>Name</th><th>Handler</th><th>Middleware</th><th></th></tr>
+{{- range .}}
+<tr{{if .Inactive}} class="inactive"{{end}}><td>{{.Method}}</td><td>{{.Pattern}}</td><td>{{.Name}}</td><td>{{.Handler}}</td><td>{{range .Middleware}}{{.}}<br>{{end}}{{range .After}}after {{.}}<br>{{end}}</td><td>{{if .Env}}{{.Env}}{{end}}{{if .Stub}} stub{{end}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
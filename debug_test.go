@@ -0,0 +1,76 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnableDebugRoutes(t *testing.T) {
+	rr := New("/")
+	rr.Before(UseKMS(nil))
+	rr.Get("/users", listUsers).Name("users")
+	admin := rr.SubRouter("/admin")
+	admin.Policy(testPolicy("admin"))
+	admin.Handle("/files", http.NotFoundHandler())
+	admin.Get("/users/:id", showUser).Policy(testPolicy("audit"))
+	rr.When("dev", false, func(r *Router) {
+		r.Stub("GET", "/fixtures", 200, "ok")
+	})
+	rr.EnableDebugRoutes("/_routes", func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "secret" {
+			return Forbidden(nil)
+		}
+		return nil
+	})
+
+	req, _ := http.NewRequest("GET", "/_routes", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unauthorized requests should be failed, got %d", rec.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/_routes", nil)
+	req.Header.Set("Authorization", "secret")
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	var routes []DebugRoute
+	if err := json.Unmarshal(rec.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	// closures are named differently depending on whether they're inlined
+	kms := routes[0].Middleware[0]
+	if !strings.HasPrefix(kms, "github.com/chrisolsen/router.UseKMS") {
+		t.Errorf("invalid middleware %s", kms)
+	}
+	expected := []DebugRoute{
+		{Method: "GET", Pattern: "/_routes", Handler: routes[0].Handler, Middleware: []string{kms}},
+		{Method: "GET", Pattern: "/users", Name: "users", Handler: "github.com/chrisolsen/router.listUsers", Middleware: []string{kms}},
+		{Method: "*", Pattern: "/admin/files", Handler: "net/http.NotFound", Middleware: []string{kms, "router.testPolicy"}},
+		{Method: "GET", Pattern: "/admin/users/:id", Handler: "github.com/chrisolsen/router.showUser", Middleware: []string{kms, "router.testPolicy", "router.testPolicy"}},
+		{Method: "GET", Pattern: "/fixtures", Handler: routes[4].Handler, Middleware: []string{kms}, Env: "dev", Inactive: true, Stub: true},
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("invalid routes\n%+v\n!=\n%+v", routes, expected)
+	}
+	if !strings.HasPrefix(routes[0].Handler, "github.com/chrisolsen/router.(*Router).EnableDebugRoutes") {
+		t.Errorf("invalid handler name %s", routes[0].Handler)
+	}
+
+	req, _ = http.NewRequest("GET", "/_routes", nil)
+	req.Header.Set("Authorization", "secret")
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("invalid content type %s", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "<td>/admin/users/:id</td>") || !strings.Contains(body, `class="inactive"`) {
+		t.Errorf("invalid html\n%s", body)
+	}
+}
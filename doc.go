@@ -0,0 +1,31 @@
+package router
+
+// RouteDoc documents a route for generated API docs, such as those of the openapi package
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+
+	// Params describes the url params, keyed by name
+	Params map[string]string
+
+	// Request is an example of the request body, ex. a zero value of its struct, whose type
+	// describes the body's schema
+	Request interface{}
+
+	// Responses are examples of the response bodies keyed by status, nil for responses without a
+	// body
+	Responses map[int]interface{}
+}
+
+// Doc documents the route
+//
+//	rr.Post("/users", createUser).Doc(router.RouteDoc{
+//		Summary:   "Create a user",
+//		Request:   signup{},
+//		Responses: map[int]interface{}{201: user{}, 422: router.ValidationErrors{}},
+//	})
+func (e *Endpoint) Doc(doc RouteDoc) *Endpoint {
+	e.doc = &doc
+	return e
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var drainCtxKey = NewKey[*drainRequest]("drain")
+
+// DrainOptions configures how long requests are given to complete once shutdown starts
+type DrainOptions struct {
+	// RequestTimeout is how long ordinary requests have to complete, 10 seconds by default
+	RequestTimeout time.Duration
+
+	// LongLivedTimeout is how long SSE, websocket and long-poll requests have to complete, 1 minute
+	// by default. It allows clients to receive the close event and reconnect elsewhere.
+	LongLivedTimeout time.Duration
+}
+
+// Drainer tracks the requests being served, coordinating a graceful shutdown that treats
+// long-lived connections separately from ordinary requests
+type Drainer struct {
+	opts DrainOptions
+
+	mu       sync.Mutex
+	requests map[*drainRequest]struct{}
+	draining chan struct{}
+	started  bool
+
+	// idle is closed once shutdown has started and the last request has completed
+	idle chan struct{}
+}
+
+// drainRequest is a tracked request, cancelled once its drain timeout passes
+type drainRequest struct {
+	d         *Drainer
+	cancel    context.CancelFunc
+	longLived bool
+	onDrain   []func()
+}
+
+// NewDrainer creates a drainer, whose middleware must wrap the router
+//
+//	d := router.NewDrainer(router.DrainOptions{})
+//	srv := &http.Server{Addr: ":8080", Handler: d.Middleware(rr)}
+//	go srv.ListenAndServe()
+//	<-stop
+//	d.Shutdown(context.Background(), srv)
+func NewDrainer(opts DrainOptions) *Drainer {
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = 10 * time.Second
+	}
+	if opts.LongLivedTimeout <= 0 {
+		opts.LongLivedTimeout = time.Minute
+	}
+	return &Drainer{
+		opts:     opts,
+		requests: make(map[*drainRequest]struct{}),
+		draining: make(chan struct{}),
+		idle:     make(chan struct{}),
+	}
+}
+
+// Middleware tracks the requests of the handler, cancelling their contexts once their drain
+// timeout passes
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, cancel := context.WithCancel(r.Context())
+		dr := &drainRequest{d: d, cancel: cancel}
+
+		d.mu.Lock()
+		d.requests[dr] = struct{}{}
+		d.mu.Unlock()
+		defer func() {
+			cancel()
+			d.mu.Lock()
+			delete(d.requests, dr)
+			d.checkIdle()
+			d.mu.Unlock()
+		}()
+
+		next.ServeHTTP(w, r.WithContext(Set(c, drainCtxKey, dr)))
+	})
+}
+
+// LongLived marks the request as a long-lived connection, such as SSE, a websocket or a long-poll,
+// giving it the longer drain timeout. The onDrain callback is called once shutdown starts, and
+// should send the protocol's close event, ex. a websocket close frame with the going away status.
+// It's called immediately if shutdown has already started.
+func LongLived(r *http.Request, onDrain func()) {
+	dr, ok := Get(r.Context(), drainCtxKey)
+	if !ok {
+		return
+	}
+	dr.d.mu.Lock()
+	dr.longLived = true
+	if onDrain != nil {
+		dr.onDrain = append(dr.onDrain, onDrain)
+	}
+	started := dr.d.started
+	dr.d.mu.Unlock()
+
+	if started && onDrain != nil {
+		onDrain()
+	}
+}
+
+// Draining returns a channel that is closed once shutdown starts, or nil if the request isn't
+// tracked by a Drainer
+func Draining(c context.Context) <-chan struct{} {
+	dr, ok := Get(c, drainCtxKey)
+	if !ok {
+		return nil
+	}
+	return dr.d.draining
+}
+
+// Shutdown stops the server accepting new connections, calls the drain callbacks of the long-lived
+// connections, and waits for the requests to complete. Ordinary requests are cancelled after the
+// request timeout and long-lived connections after the long-lived timeout. It returns once all the
+// requests have completed, or the context is done.
+func (d *Drainer) Shutdown(c context.Context, srv *http.Server) error {
+	d.mu.Lock()
+	if d.started {
+		d.mu.Unlock()
+		return nil
+	}
+	d.started = true
+	close(d.draining)
+	var callbacks []func()
+	for dr := range d.requests {
+		callbacks = append(callbacks, dr.onDrain...)
+	}
+	d.checkIdle()
+	d.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+
+	// the server's shutdown doesn't wait for hijacked connections, so the tracked requests are
+	// waited on instead
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- srv.Shutdown(c) }()
+
+	requests := time.AfterFunc(d.opts.RequestTimeout, func() { d.cancel(false) })
+	defer requests.Stop()
+	longLived := time.AfterFunc(d.opts.LongLivedTimeout, func() { d.cancel(true) })
+	defer longLived.Stop()
+
+	select {
+	case <-d.idle:
+	case <-c.Done():
+		return c.Err()
+	}
+	select {
+	case err := <-shutdownErr:
+		return err
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
+// checkIdle closes idle once shutdown has started and no requests remain, and must be called with
+// the lock held
+func (d *Drainer) checkIdle() {
+	if !d.started || len(d.requests) > 0 {
+		return
+	}
+	select {
+	case <-d.idle:
+	default:
+		close(d.idle)
+	}
+}
+
+// cancel cancels the contexts of the ordinary or long-lived requests
+func (d *Drainer) cancel(longLived bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for dr := range d.requests {
+		if dr.longLived == longLived {
+			dr.cancel()
+		}
+	}
+}
+
+// drainState is the drain mode shared by a router and its subrouters
+type drainState struct {
+	draining int32
+	inFlight int64
+}
+
+// StartDraining puts the router into drain mode ahead of a deploy: readiness reports the instance
+// as unhealthy, so the load balancer stops sending it traffic, and new requests, other than those
+// of the health endpoints, are answered with a 503 and `Connection: close`. Requests already being
+// served complete as usual, and WaitIdle waits for them.
+//
+//	rr.StartDraining()
+//	rr.WaitIdle(ctx)
+//	srv.Shutdown(ctx)
+func (r Router) StartDraining() {
+	atomic.StoreInt32(&r.drain.draining, 1)
+}
+
+// IsDraining reports whether StartDraining has been called
+func (r Router) IsDraining() bool {
+	return atomic.LoadInt32(&r.drain.draining) == 1
+}
+
+// InFlight is the number of requests the router is serving
+func (r Router) InFlight() int64 {
+	return atomic.LoadInt64(&r.drain.inFlight)
+}
+
+// WaitIdle waits until the router has no requests in flight, or the context is done
+func (r Router) WaitIdle(c context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for r.InFlight() > 0 {
+		select {
+		case <-ticker.C:
+		case <-c.Done():
+			return c.Err()
+		}
+	}
+	return nil
+}
+
+// refuseDraining answers requests with a 503 while the router is draining, reporting whether it
+// did. The health endpoints are still served, so readiness can report the drain.
+func (r Router) refuseDraining(w http.ResponseWriter, req *http.Request) bool {
+	if !r.IsDraining() || r.health.paths[req.URL.Path] {
+		return false
+	}
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Retry-After", "1")
+	r.renderError(r.findMatchingRouter(req.URL.Path), w, req, http.StatusServiceUnavailable, nil)
+	return true
+}
@@ -0,0 +1,161 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainer(t *testing.T) {
+	started := make(chan string, 3)
+	cancelled := make(chan string, 2)
+
+	rr := New("/")
+	rr.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		started <- "slow"
+		<-r.Context().Done()
+		cancelled <- "slow"
+	})
+	rr.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		LongLived(r, func() {
+			fmt.Fprint(w, "event: close\ndata: reconnect\n\n")
+			w.(http.Flusher).Flush()
+		})
+		started <- "stream"
+		<-r.Context().Done()
+		cancelled <- "stream"
+	})
+	rr.Get("/poll", func(w http.ResponseWriter, r *http.Request) {
+		started <- "poll"
+		LongPoll(w, r, ChanSource(make(chan []byte)), time.Minute)
+	})
+
+	d := NewDrainer(DrainOptions{RequestTimeout: 20 * time.Millisecond, LongLivedTimeout: 100 * time.Millisecond})
+	ts := httptest.NewServer(d.Middleware(rr))
+	defer ts.Close()
+
+	get := func(path string) *http.Response {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		return res
+	}
+	go get("/slow")
+	polled := make(chan int)
+	go func() {
+		if res := get("/poll"); res != nil {
+			polled <- res.StatusCode
+		}
+	}()
+	events := make(chan string, 1)
+	go func() {
+		res := get("/stream")
+		if res == nil {
+			return
+		}
+		defer res.Body.Close()
+		sc := bufio.NewScanner(res.Body)
+		for sc.Scan() {
+			if sc.Text() != "" {
+				events <- sc.Text()
+				ioutil.ReadAll(res.Body)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	shutdown := make(chan error)
+	begin := time.Now()
+	go func() { shutdown <- d.Shutdown(context.Background(), ts.Config) }()
+
+	if status := <-polled; status != http.StatusNoContent {
+		t.Errorf("long-poll should end with a 204, got %d", status)
+	}
+	if event := <-events; event != "event: close" {
+		t.Errorf("invalid close event %q", event)
+	}
+	if name := <-cancelled; name != "slow" {
+		t.Errorf("ordinary requests should be cancelled first, got %s", name)
+	}
+	if name := <-cancelled; name != "stream" || time.Since(begin) < 100*time.Millisecond {
+		t.Errorf("long-lived requests should be cancelled after their timeout, got %s", name)
+	}
+	if err := <-shutdown; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDraining(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	if Draining(req.Context()) != nil {
+		t.Error("untracked requests should not have a draining channel")
+	}
+	LongLived(req, func() { t.Error("untracked requests should not be drained") })
+}
+
+func TestStartDraining(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	rr := New("/")
+	rr.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Health(HealthOptions{})
+
+	done := make(chan struct{})
+	go func() {
+		req, _ := http.NewRequest("GET", "/slow", nil)
+		rr.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	<-started
+	if n := rr.InFlight(); n != 1 {
+		t.Errorf("invalid in flight %d", n)
+	}
+
+	rr.StartDraining()
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/users", http.StatusServiceUnavailable},
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.path, rec.Code)
+		}
+		if draining := rec.Header().Get("Connection") == "close"; draining != (test.path == "/users") {
+			t.Errorf("%s: invalid connection header %q", test.path, rec.Header().Get("Connection"))
+		}
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := rr.WaitIdle(c); err != context.DeadlineExceeded {
+		t.Errorf("should wait on the request in flight, got %v", err)
+	}
+	close(release)
+	<-done
+	if err := rr.WaitIdle(context.Background()); err != nil || rr.InFlight() != 0 {
+		t.Errorf("invalid idle %v %d", err, rr.InFlight())
+	}
+}
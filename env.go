@@ -0,0 +1,27 @@
+package router
+
+// When registers the routes added by fn only when active, labelling them with the environment.
+// Inactive routes are never served, but are still listed by Routes with Inactive set, so debug-only
+// endpoints such as fixtures and mail previews remain visible to tooling without being reachable.
+//
+//	rr.When("dev", env == "dev", func(r *router.Router) {
+//		r.Get("/dev/fixtures", loadFixtures)
+//		r.Mount("/dev/mail", mailPreviews)
+//	})
+func (r *Router) When(env string, active bool, fn func(r *Router)) {
+	if !active {
+		shadow := New(r.basePath)
+		shadow.env = env
+		shadow.configs = r.configs
+		shadow.drain = r.drain
+		shadow.maintenance = r.maintenance
+		fn(&shadow)
+		r.inactive = append(r.inactive, &shadow)
+		return
+	}
+
+	prev := r.env
+	r.env = env
+	fn(r)
+	r.env = prev
+}
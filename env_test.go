@@ -0,0 +1,51 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWhen(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := New("/")
+	rr.Get("/", fn)
+	rr.When("dev", true, func(r *Router) {
+		r.Get("/dev/fixtures", fn)
+		r.SubRouter("/mail").Get("/preview", fn)
+	})
+	rr.When("test", false, func(r *Router) {
+		r.Get("/test/reset", fn)
+	})
+	rr.Get("/users", fn)
+
+	expected := []struct {
+		pattern  string
+		env      string
+		inactive bool
+		status   int
+	}{
+		{"/", "", false, 200},
+		{"/dev/fixtures", "dev", false, 200},
+		{"/mail/preview", "dev", false, 200},
+		{"/test/reset", "test", true, 404},
+		{"/users", "", false, 200},
+	}
+	routes := rr.Routes()
+	if len(routes) != len(expected) {
+		t.Fatalf("invalid routes %+v", routes)
+	}
+	for i, e := range expected {
+		route := routes[i]
+		if route.Pattern != e.pattern || route.Env != e.env || route.Inactive != e.inactive {
+			t.Errorf("invalid route %+v", route)
+		}
+
+		req, _ := http.NewRequest("GET", e.pattern, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != e.status {
+			t.Errorf("%s: invalid status %d != %d", e.pattern, rec.Code, e.status)
+		}
+	}
+}
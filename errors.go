@@ -0,0 +1,245 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"sync"
+)
+
+var (
+	errorCtxKey = NewKey[error]("error")
+	stateCtxKey = NewKey[*requestState]("state")
+)
+
+// requestState is shared by all the requests derived from the one being served, allowing the
+// router to see the errors of handlers that were passed a derived request, and middleware wrapping
+// the router to see the outcome of the request through Observe
+type requestState struct {
+	mu      sync.Mutex
+	err     error
+	pattern string
+	req     *http.Request
+}
+
+// Observe returns a copy of the request through which middleware wrapping the router reads the
+// outcome of the request once the router has served it, as the router never changes the request
+// it's passed. RoutePattern and RequestError report the matched route and the request's error.
+//
+//	r = router.Observe(r)
+//	next.ServeHTTP(w, r)
+//	log.Printf("%s %v", router.RoutePattern(r.Context()), router.RequestError(r.Context()))
+func Observe(r *http.Request) *http.Request {
+	return r.WithContext(Set(r.Context(), stateCtxKey, &requestState{}))
+}
+
+func (s *requestState) setError(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *requestState) error() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// setRequest records the latest request the router derived, which the panic handler is passed,
+// along with the pattern of the matched route
+func (s *requestState) setRequest(req *http.Request, pattern string) {
+	s.mu.Lock()
+	s.req = req
+	if pattern != "" {
+		s.pattern = pattern
+	}
+	s.mu.Unlock()
+}
+
+func (s *requestState) request() (*http.Request, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.req, s.pattern
+}
+
+// InternalError allows for a custom 500 handler to be set. The handler is called when a handler
+// panics or fails the request with Fail, and owns the response. The error is available via
+// RequestError.
+func (r *Router) InternalError(h http.HandlerFunc) {
+	r.internalErrorHandler = h
+}
+
+// Fail records the error against the request and halts it, leaving the response to the
+// router's InternalError handler. Validation errors are instead rendered as a 422, and an *Error
+// with its status.
+func Fail(r *http.Request, err error) {
+	if state, ok := Get(r.Context(), stateCtxKey); ok {
+		state.setError(err)
+	}
+	BindContext(Set(r.Context(), errorCtxKey, err), r)
+	HaltRequest(r)
+}
+
+// RequestError retrieves the error that caused the request to fail, including those reported by
+// Fail on requests derived from it
+func RequestError(c context.Context) error {
+	if err, ok := Get(c, errorCtxKey); ok {
+		return err
+	}
+	if state, ok := Get(c, stateCtxKey); ok {
+		return state.error()
+	}
+	return nil
+}
+
+// recover converts a panic within the handler chain into an internal error response, passing the
+// error handler the request of the matched route when there was one
+func (r Router) recover(w http.ResponseWriter, state *requestState) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+	if rec == http.ErrAbortHandler {
+		panic(rec)
+	}
+
+	err, ok := rec.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", rec)
+	}
+	state.setError(err)
+	req, _ := state.request()
+	r.internalError(w, req.WithContext(Set(req.Context(), errorCtxKey, err)))
+}
+
+// internalError runs the custom 500 handler or falls back on the default error renderers.
+// Client errors, such as validation errors, skip the 500 handler.
+func (r Router) internalError(w http.ResponseWriter, req *http.Request) {
+	err := RequestError(req.Context())
+	status := ErrorStatus(err)
+	if status < 500 {
+		r.renderError(r.findMatchingRouter(req.URL.Path), w, req, status, err)
+		return
+	}
+	if r.internalErrorHandler != nil {
+		r.internalErrorHandler(w, req)
+		return
+	}
+	r.renderError(r.findMatchingRouter(req.URL.Path), w, req, status, err)
+}
+
+// ErrorStatus is the status a request failed with the error is responded with, ex. to count the
+// failures of middleware that runs before the error response is written
+func ErrorStatus(err error) int {
+	var httpErr *Error
+	if errors.As(err, &httpErr) && httpErr.Status != 0 {
+		return httpErr.Status
+	}
+	if _, ok := validationErrors(err); ok {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorRenderer writes the response for an error status that has no custom handler
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+// media types of the default error renderers, in order of preference when the client has none
+var errorMediaTypes = []string{"text/plain", "application/problem+json", "application/json", "text/html"}
+
+var defaultErrorRenderers = map[string]ErrorRenderer{
+	"text/plain":               renderTextError,
+	"application/problem+json": renderProblemError,
+	"application/json":         renderProblemError,
+	"text/html":                renderHTMLError,
+}
+
+// ErrorRenderer overrides the renderer used for the media type when the request's Accept header
+// prefers it. Renderers set on a subrouter take precedence over those of the root router.
+func (r *Router) ErrorRenderer(mediaType string, fn ErrorRenderer) {
+	if r.errorRenderers == nil {
+		r.errorRenderers = make(map[string]ErrorRenderer)
+	}
+	r.errorRenderers[mediaType] = fn
+}
+
+// renderError negotiates the error response format with the client, using the matched router's
+// renderers, then the root's, and finally the defaults
+func (r Router) renderError(rr *Router, w http.ResponseWriter, req *http.Request, status int, err error) {
+	if rr == nil {
+		rr = &r
+	}
+	offers := errorMediaTypes
+	for _, renderers := range []map[string]ErrorRenderer{r.errorRenderers, rr.errorRenderers} {
+		for mediaType := range renderers {
+			if defaultErrorRenderers[mediaType] == nil {
+				offers = append(offers[:len(offers):len(offers)], mediaType)
+			}
+		}
+	}
+
+	mediaType := negotiate(req.Header.Get("Accept"), offers)
+	if mediaType == "" {
+		mediaType = offers[0]
+	}
+	for _, renderers := range []map[string]ErrorRenderer{rr.errorRenderers, r.errorRenderers, defaultErrorRenderers} {
+		if fn := renderers[mediaType]; fn != nil {
+			fn(w, req, status, err)
+			return
+		}
+	}
+}
+
+func renderTextError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	msg := http.StatusText(status)
+	if errs, ok := validationErrors(err); ok {
+		for _, e := range errs {
+			msg += "\n" + e.Error()
+		}
+	}
+	http.Error(w, msg, status)
+}
+
+// renderProblemError renders an RFC 7807 problem details response. The error's message is only
+// included for client errors to prevent leaking internal details, with validation errors listed
+// under the `errors` extension member.
+func renderProblemError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	problem := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  http.StatusText(status),
+		"status": status,
+	}
+	if err != nil && status < 500 {
+		problem["detail"] = err.Error()
+		var httpErr *Error
+		if errors.As(err, &httpErr) && httpErr.Code != "" {
+			problem["code"] = httpErr.Code
+		}
+	}
+	if errs, ok := validationErrors(err); ok {
+		problem["errors"] = errs
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func renderHTMLError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	title := html.EscapeString(fmt.Sprintf("%d %s", status, http.StatusText(status)))
+	var list string
+	if errs, ok := validationErrors(err); ok {
+		list = "<ul>"
+		for _, e := range errs {
+			list += "<li>" + html.EscapeString(e.Error()) + "</li>"
+		}
+		list += "</ul>"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><title>%s</title></head><body><h1>%s</h1>%s</body></html>\n", title, title, list)
+}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInternalError(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		desc             string
+		handlerFunc      http.HandlerFunc
+		errorHandler     http.HandlerFunc
+		expectedStatus   int
+		expectedResponse string
+	}{
+		{
+			desc: "panic with default handler",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			expectedStatus:   500,
+			expectedResponse: "Internal Server Error\n",
+		},
+		{
+			desc: "panic with custom handler",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				panic(errFailed)
+			},
+			errorHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(503)
+				w.Write([]byte(RequestError(r.Context()).Error()))
+			},
+			expectedStatus:   503,
+			expectedResponse: "failed",
+		},
+		{
+			desc: "failed request",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				Fail(r, errFailed)
+			},
+			errorHandler: func(w http.ResponseWriter, r *http.Request) {
+				if RequestError(r.Context()) != errFailed {
+					t.Error("error not available in context")
+				}
+				w.WriteHeader(500)
+				w.Write([]byte("oops"))
+			},
+			expectedStatus:   500,
+			expectedResponse: "oops",
+		},
+	}
+
+	for _, test := range tests {
+		rr := New("/")
+		rr.Get("/", test.handlerFunc)
+		if test.errorHandler != nil {
+			rr.InternalError(test.errorHandler)
+		}
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%s: invalid status code %d != %d", test.desc, rec.Code, test.expectedStatus)
+		}
+		if rec.Body.String() != test.expectedResponse {
+			t.Errorf("%s: invalid response %q != %q", test.desc, rec.Body.String(), test.expectedResponse)
+		}
+	}
+}
+
+func TestFailInMiddleware(t *testing.T) {
+	rr := New("/")
+	rr.Before(func(w http.ResponseWriter, r *http.Request) {
+		Fail(r, errors.New("failed"))
+	})
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	if rec.Code != 500 {
+		t.Errorf("invalid status code %d", rec.Code)
+	}
+}
+
+func TestErrorNegotiation(t *testing.T) {
+	tests := []struct {
+		accept      string
+		path        string
+		contentType string
+		body        string
+	}{
+		{"", "/missing", "text/plain; charset=utf-8", "Not Found\n"},
+		{"application/json", "/missing", "application/problem+json", `{"status":404,"title":"Not Found","type":"about:blank"}` + "\n"},
+		{"text/html,*/*;q=0.8", "/missing", "text/html; charset=utf-8", "<!DOCTYPE html>\n<html><head><title>404 Not Found</title></head><body><h1>404 Not Found</h1></body></html>\n"},
+		{"text/html", "/admin/missing", "text/html", "admin page"},
+		{"application/json", "/admin/missing", "application/problem+json", `{"status":404,"title":"Not Found","type":"about:blank"}` + "\n"},
+		{"application/vnd.api+json", "/missing", "application/vnd.api+json", "api error"},
+		{"application/json", "/panic", "application/problem+json", `{"status":500,"title":"Internal Server Error","type":"about:blank"}` + "\n"},
+	}
+
+	for _, test := range tests {
+		rr := New("/")
+		rr.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+			panic(errors.New("secret details"))
+		})
+		rr.ErrorRenderer("application/vnd.api+json", func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			w.WriteHeader(status)
+			w.Write([]byte("api error"))
+		})
+		admin := rr.SubRouter("/admin")
+		admin.ErrorRenderer("text/html", func(w http.ResponseWriter, r *http.Request, status int, err error) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(status)
+			w.Write([]byte("admin page"))
+		})
+
+		req, _ := http.NewRequest("GET", test.path, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if ct := rec.Header().Get("Content-Type"); ct != test.contentType {
+			t.Errorf("%s %q: invalid content type %q != %q", test.path, test.accept, ct, test.contentType)
+		}
+		if rec.Body.String() != test.body {
+			t.Errorf("%s %q: invalid body %q != %q", test.path, test.accept, rec.Body.String(), test.body)
+		}
+	}
+}
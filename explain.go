@@ -0,0 +1,191 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ExplainResult describes how the router would handle a request
+type ExplainResult struct {
+	Method string
+	Path   string
+
+	// Locale is the locale prefix removed from the path, for routers with locales
+	Locale string
+
+	// BasePath is the base path of the router, or subrouter, that was selected for the path
+	BasePath string
+
+	// Redirect is set when the request would be redirected, by a registered redirect, a legacy URL
+	// rule, or the trailing slash and case policies
+	Redirect *Redirect
+
+	// Candidates are all the routes of the selected router that were considered
+	Candidates []ExplainCandidate
+
+	// Matched is the candidate that would handle the request, nil if the request would be a 404 or
+	// several candidates match
+	Matched *ExplainCandidate
+	Params  map[string]string
+
+	// Ambiguous is set when several candidates match, any of which may handle the request
+	Ambiguous bool
+
+	// AutoHead is set when a HEAD request would be handled by the GET route, and Folded when the
+	// route only matches without regard to case
+	AutoHead bool
+	Folded   bool
+}
+
+// ExplainCandidate describes a route that was considered while matching
+type ExplainCandidate struct {
+	// Method is empty for routes registered with Handle, which match all methods
+	Method  string
+	Pattern string
+	Matched bool
+
+	// Reason explains why the route was rejected
+	Reason string
+}
+
+// Explain reports which routes would be considered for the request, and why they were rejected,
+// without running any of the handlers. The request goes through the same steps as when it's served:
+// legacy URLs are translated, the locale prefix is removed and HEAD requests and paths in another
+// case fall back on the GET route and the case policy. The path may include a query string, which
+// is checked against the routes' query constraints, while the request has no headers, so versioned
+// routers select their default version.
+func (r Router) Explain(method, path string) ExplainResult {
+	if reloaded := r.reloaded(); reloaded != nil {
+		return reloaded.Explain(method, path)
+	}
+	method = strings.ToUpper(method)
+	req := &http.Request{Method: method, URL: &url.URL{}, Header: http.Header{}}
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, req.URL.RawQuery = path[:i], path[i+1:]
+	}
+	req.URL.Path = path
+	result := ExplainResult{Method: method, Path: path}
+
+	if r.legacy != nil {
+		if rule, target, ok := r.legacy.find(req.URL); ok {
+			if rule.Redirect != 0 {
+				result.Redirect = &Redirect{From: path, To: target.String(), Status: rule.Redirect}
+				return result
+			}
+			req.URL.Path, req.URL.RawPath, req.URL.RawQuery = target.Path, target.RawPath, target.RawQuery
+		}
+	}
+	if r.locales != nil {
+		req = r.routeLocale(req)
+		result.Locale = PathLocale(req.Context())
+	}
+	rr := r.findMatchingRouter(req.URL.Path)
+	if rr.hasVersions() {
+		req = r.selectVersion(rr, http.Header{}, req)
+		rr = r.findMatchingRouter(req.URL.Path)
+	}
+	result.BasePath = rr.basePath
+
+	relPath := strings.Replace(req.URL.Path, rr.basePath, "", 1)
+	if redirect, ok := rr.findRedirect(relPath); ok {
+		redirect.To = redirectLocation(localizePath(req, redirect.To), req)
+		result.Redirect = &redirect
+		return result
+	}
+
+	result.explain(rr, func(route Route, ep *Endpoint, params *routeParams) string {
+		reason, _ := r.matchEndpoint(rr, route, ep, method, relPath, req, params)
+		return reason
+	})
+	if result.Matched == nil && !result.Ambiguous && method == http.MethodHead && !r.disableAutoHead {
+		// the GET route's outcome decides the slash and query mismatches, as when served
+		result.explain(rr, func(route Route, ep *Endpoint, params *routeParams) string {
+			reason, _ := r.matchEndpoint(rr, route, ep, http.MethodGet, relPath, req, params)
+			return reason
+		})
+		result.AutoHead = result.Matched != nil || result.Ambiguous
+	}
+	if result.Matched != nil || result.Ambiguous {
+		return result
+	}
+
+	for _, c := range result.Candidates {
+		if c.Reason == rejectSlash && r.slashPolicy == RedirectTrailingSlash {
+			location, status := slashLocation(req)
+			result.Redirect = &Redirect{From: path, To: location, Status: status}
+			return result
+		}
+	}
+	if r.casePolicy == CaseSensitive {
+		return result
+	}
+	frr := r.findRouter(req.URL.Path, true)
+	if frr == nil {
+		return result
+	}
+	foldedPath := req.URL.Path[len(frr.basePath):]
+	folded := result
+	folded.BasePath = frr.basePath
+	folded.explain(frr, func(route Route, ep *Endpoint, params *routeParams) string {
+		return foldedMatch(frr, route, ep, method, foldedPath, req, params)
+	})
+	if folded.Matched == nil && !folded.Ambiguous {
+		return result
+	}
+	folded.Folded = true
+	if folded.Matched == nil || r.casePolicy != RedirectCase {
+		return folded
+	}
+	if target := canonicalPath(frr.fullPath(folded.Matched.Pattern), req.URL.Path); target != req.URL.Path {
+		location, status := caseLocation(req, target)
+		folded.Redirect = &Redirect{From: path, To: location, Status: status}
+	}
+	return folded
+}
+
+// explain sets the candidates from the router's routes, ordered by pattern, matched by match.
+// Routes are dispatched in no particular order, so when several match the result is ambiguous.
+func (result *ExplainResult) explain(rr *Router, match func(route Route, ep *Endpoint, params *routeParams) string) {
+	routes := make([]Route, 0, len(rr.routes))
+	for route := range rr.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].path == routes[j].path {
+			return routes[i].method < routes[j].method
+		}
+		return routes[i].path < routes[j].path
+	})
+
+	result.Candidates, result.Matched, result.Params, result.Ambiguous = nil, nil, nil, false
+	params := &routeParams{}
+	matched := -1
+	for _, route := range routes {
+		reason := match(route, rr.routes[route], params)
+		result.Candidates = append(result.Candidates, ExplainCandidate{
+			Method:  route.method,
+			Pattern: route.path,
+			Matched: reason == "",
+			Reason:  reason,
+		})
+		if reason != "" {
+			continue
+		}
+		if matched >= 0 {
+			result.Ambiguous = true
+			continue
+		}
+		matched = len(result.Candidates) - 1
+		result.Params = params.toMap()
+	}
+
+	// the pointer is taken once all the candidates have been appended
+	if matched >= 0 && !result.Ambiguous {
+		result.Matched = &result.Candidates[matched]
+	}
+	if result.Ambiguous {
+		result.Params = nil
+	}
+}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	called := false
+	fn := func(w http.ResponseWriter, r *http.Request) { called = true }
+
+	rr := New("/")
+	rr.Get("/users", fn)
+	rr.Get("/users/:id", fn)
+	rr.Post("/users/:id", fn)
+	rr.Get("/files/*", fn)
+	admin := rr.SubRouter("/admin")
+	admin.Get("/reports/:year", fn)
+
+	result := rr.Explain("get", "/users/123")
+	if called {
+		t.Error("handlers should not be run")
+	}
+	if result.BasePath != "/" {
+		t.Errorf("invalid base path %s", result.BasePath)
+	}
+	if len(result.Candidates) != 4 {
+		t.Errorf("invalid candidate count %d", len(result.Candidates))
+		return
+	}
+	if result.Matched == nil || result.Matched.Pattern != "/users/:id" || result.Matched.Method != "GET" {
+		t.Errorf("invalid match %+v", result.Matched)
+		return
+	}
+	if result.Params["id"] != "123" {
+		t.Errorf("invalid params %v", result.Params)
+	}
+
+	reasons := map[string]string{}
+	for _, c := range result.Candidates {
+		reasons[c.Method+" "+c.Pattern] = c.Reason
+	}
+	expected := map[string]string{
+		"GET /files/*":    rejectSegment,
+		"GET /users":      rejectPath,
+		"GET /users/:id":  "",
+		"POST /users/:id": rejectMethod,
+	}
+	for key, reason := range expected {
+		if reasons[key] != reason {
+			t.Errorf("%s: invalid reason %q != %q", key, reasons[key], reason)
+		}
+	}
+
+	result = rr.Explain("GET", "/admin/reports/2020")
+	if result.BasePath != "/admin" || result.Params["year"] != "2020" {
+		t.Errorf("subrouter not explained: %+v", result)
+	}
+
+	result = rr.Explain("GET", "/missing/path")
+	if result.Matched != nil {
+		t.Error("nothing should match")
+	}
+}
+
+func TestExplainRedirect(t *testing.T) {
+	rr := New("/")
+	rr.Redirects(map[string]string{"/old": "/new"})
+
+	result := rr.Explain("GET", "/old")
+	if result.Redirect == nil || result.Redirect.To != "/new" {
+		t.Errorf("redirect not explained: %+v", result)
+	}
+}
+
+func TestExplainAmbiguous(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := New("/")
+	rr.Get("/users/:id", fn)
+	rr.Get("/users/new", fn)
+
+	result := rr.Explain("GET", "/users/new")
+	if !result.Ambiguous || result.Matched != nil || result.Params != nil {
+		t.Errorf("overlapping routes should be ambiguous: %+v", result)
+	}
+	if result := rr.Explain("GET", "/users/1"); result.Ambiguous || result.Matched == nil || result.Params["id"] != "1" {
+		t.Errorf("a single match should not be ambiguous: %+v", result)
+	}
+}
+
+func TestExplainServingSteps(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := New("/")
+	rr.Legacy(NewLegacy(
+		LegacyRule{Path: "/show.php", Query: map[string]string{"id": ""}, To: "/users/:id"},
+		LegacyRule{Path: "/old.php", To: "/users", Redirect: http.StatusMovedPermanently},
+	))
+	rr.LocalePrefixes(LocaleOptions{Locales: []string{"fr"}, Default: "en"})
+	rr.PathCase(RedirectCase)
+	rr.Get("/users", fn)
+	rr.Get("/users/:id", fn).Formats("json")
+	api := rr.SubRouter("/api")
+	api.Versioning(VersionOptions{Default: "v2"})
+	api.Version("v2").Get("/reports", fn)
+
+	tests := []struct {
+		method   string
+		path     string
+		matched  string
+		params   string
+		redirect string
+		check    func(ExplainResult) bool
+	}{
+		{"GET", "/show.php?id=7", "/users/:id", "7", "", nil},
+		{"GET", "/old.php", "", "", "/users", nil},
+		{"GET", "/fr/users/7.json", "/users/:id", "7", "", func(res ExplainResult) bool { return res.Locale == "fr" }},
+		{"HEAD", "/users", "/users", "", "", func(res ExplainResult) bool { return res.AutoHead }},
+		{"GET", "/Users/7", "/users/:id", "7", "/users/7", func(res ExplainResult) bool { return res.Folded }},
+		{"GET", "/api/reports", "/reports", "", "", func(res ExplainResult) bool { return res.BasePath == "/api/v2" }},
+	}
+	for _, test := range tests {
+		result := rr.Explain(test.method, test.path)
+		if test.redirect != "" {
+			if result.Redirect == nil || result.Redirect.To != test.redirect {
+				t.Errorf("%s %s: invalid redirect %+v", test.method, test.path, result.Redirect)
+			}
+		} else if result.Redirect != nil {
+			t.Errorf("%s %s: unexpected redirect %+v", test.method, test.path, result.Redirect)
+		}
+		if test.matched != "" && (result.Matched == nil || result.Matched.Pattern != test.matched) {
+			t.Errorf("%s %s: invalid match %+v", test.method, test.path, result.Matched)
+		}
+		if test.params != "" && result.Params["id"] != test.params {
+			t.Errorf("%s %s: invalid params %v", test.method, test.path, result.Params)
+		}
+		if test.check != nil && !test.check(result) {
+			t.Errorf("%s %s: not explained as served: %+v", test.method, test.path, result)
+		}
+	}
+}
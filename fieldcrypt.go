@@ -0,0 +1,195 @@
+package router
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+var kmsCtxKey = NewKey[KMS]("kms")
+
+// KMS encrypts and decrypts the values of struct fields tagged with `encrypt:"true"`
+type KMS interface {
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// UseKMS is middleware that makes the KMS available to the bind and render hooks of the request
+func UseKMS(kms KMS) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		BindContext(Set(r.Context(), kmsCtxKey, kms), r)
+	}
+}
+
+// RequestKMS retrieves the KMS bound by UseKMS
+func RequestKMS(c context.Context) KMS {
+	kms, _ := Get(c, kmsCtxKey)
+	return kms
+}
+
+// EncryptFields encrypts, in place, the tagged string and []byte fields of the value pointed to by v,
+// including those of nested structs, pointers, interfaces, slices and maps. Encrypted strings are
+// base64 encoded. An error is returned, rather than leaving fields in plaintext, for tagged fields
+// that can't be set, such as those of a struct passed by value, and for values that can't be walked,
+// such as channels and funcs.
+func EncryptFields(ctx context.Context, kms KMS, v interface{}) error {
+	return walkEncrypted(reflect.ValueOf(v), func(b []byte) ([]byte, error) {
+		return kms.Encrypt(ctx, b)
+	}, true)
+}
+
+// DecryptFields decrypts, in place, the tagged fields encrypted by EncryptFields
+func DecryptFields(ctx context.Context, kms KMS, v interface{}) error {
+	return walkEncrypted(reflect.ValueOf(v), func(b []byte) ([]byte, error) {
+		return kms.Decrypt(ctx, b)
+	}, false)
+}
+
+func walkEncrypted(v reflect.Value, fn func([]byte) ([]byte, error), encrypt bool) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walkEncrypted(v.Elem(), fn, encrypt)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Map {
+			return walkEncrypted(elem, fn, encrypt)
+		}
+		// the value held by an interface can't be set, so a copy is walked and stored in its place
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		if err := walkEncrypted(cp, fn, encrypt); err != nil {
+			return err
+		}
+		if !v.CanSet() {
+			return errors.New("unaddressable value, pass a pointer")
+		}
+		v.Set(cp)
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// map values can't be set in place, so each is walked as a copy and stored again
+			cp := reflect.New(v.Type().Elem()).Elem()
+			cp.Set(iter.Value())
+			if err := walkEncrypted(cp, fn, encrypt); err != nil {
+				return fmt.Errorf("%v: %v", iter.Key(), err)
+			}
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := walkEncrypted(v.Index(i), fn, encrypt); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field, sf := v.Field(i), t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			if sf.Tag.Get("encrypt") != "true" {
+				if err := walkEncrypted(field, fn, encrypt); err != nil {
+					return fmt.Errorf("%s: %v", sf.Name, err)
+				}
+				continue
+			}
+			if err := cryptField(field, fn, encrypt); err != nil {
+				return fmt.Errorf("%s: %v", sf.Name, err)
+			}
+		}
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Errorf("can't walk %s values", v.Kind())
+	}
+	return nil
+}
+
+func cryptField(field reflect.Value, fn func([]byte) ([]byte, error), encrypt bool) error {
+	if !field.CanSet() {
+		return errors.New("unaddressable value, pass a pointer")
+	}
+	switch {
+	case field.Kind() == reflect.String:
+		if field.Len() == 0 {
+			return nil
+		}
+		in := []byte(field.String())
+		if !encrypt {
+			var err error
+			if in, err = base64.StdEncoding.DecodeString(field.String()); err != nil {
+				return err
+			}
+		}
+		out, err := fn(in)
+		if err != nil {
+			return err
+		}
+		if encrypt {
+			field.SetString(base64.StdEncoding.EncodeToString(out))
+		} else {
+			field.SetString(string(out))
+		}
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+		if field.Len() == 0 {
+			return nil
+		}
+		out, err := fn(field.Bytes())
+		if err != nil {
+			return err
+		}
+		field.SetBytes(out)
+	default:
+		return errors.New("only string and []byte fields can be encrypted")
+	}
+	return nil
+}
+
+// aesKMS encrypts locally with AES-GCM, suitable for development and tests
+type aesKMS struct {
+	aead cipher.AEAD
+}
+
+// NewAESKMS creates a KMS using AES-GCM with a 16, 24 or 32 byte key
+func NewAESKMS(key []byte) (KMS, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aesKMS{aead: aead}, nil
+}
+
+func (k aesKMS) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return k.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (k aesKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	size := k.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+	return k.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type patient struct {
+	Name     string
+	SSN      string `encrypt:"true"`
+	Notes    []byte `encrypt:"true"`
+	Contacts []contact
+	Primary  *contact
+}
+
+type contact struct {
+	Phone string `encrypt:"true"`
+}
+
+func TestEncryptFields(t *testing.T) {
+	kms, err := NewAESKMS([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ctx := context.Background()
+
+	p := patient{
+		Name:     "Jane",
+		SSN:      "123-45-6789",
+		Notes:    []byte("allergic"),
+		Contacts: []contact{{Phone: "555-1234"}},
+		Primary:  &contact{Phone: "555-9876"},
+	}
+	if err := EncryptFields(ctx, kms, &p); err != nil {
+		t.Error(err)
+		return
+	}
+	if p.Name != "Jane" {
+		t.Error("untagged field should not be encrypted")
+	}
+	if p.SSN == "123-45-6789" || string(p.Notes) == "allergic" || p.Contacts[0].Phone == "555-1234" || p.Primary.Phone == "555-9876" {
+		t.Errorf("tagged fields not encrypted: %+v", p)
+	}
+
+	if err := DecryptFields(ctx, kms, &p); err != nil {
+		t.Error(err)
+		return
+	}
+	if p.SSN != "123-45-6789" || string(p.Notes) != "allergic" || p.Contacts[0].Phone != "555-1234" || p.Primary.Phone != "555-9876" {
+		t.Errorf("fields not decrypted: %+v", p)
+	}
+}
+
+func TestEncryptFieldsInvalidType(t *testing.T) {
+	kms, _ := NewAESKMS([]byte("0123456789abcdef"))
+	v := struct {
+		Age int `encrypt:"true"`
+	}{Age: 30}
+	if err := EncryptFields(context.Background(), kms, &v); err == nil {
+		t.Error("expected error for int field")
+	}
+}
+
+func TestEncryptFieldsInterfacesAndMaps(t *testing.T) {
+	kms, _ := NewAESKMS([]byte("0123456789abcdef"))
+	ctx := context.Background()
+	v := struct {
+		Any      interface{}
+		Contacts map[string]contact
+		Nested   map[string]interface{}
+	}{
+		Any:      contact{Phone: "555-1234"},
+		Contacts: map[string]contact{"home": {Phone: "555-9876"}},
+		Nested:   map[string]interface{}{"work": &contact{Phone: "555-0000"}, "count": 2},
+	}
+	if err := EncryptFields(ctx, kms, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Any.(contact).Phone == "555-1234" || v.Contacts["home"].Phone == "555-9876" || v.Nested["work"].(*contact).Phone == "555-0000" {
+		t.Errorf("tagged fields not encrypted: %+v", v)
+	}
+	if err := DecryptFields(ctx, kms, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Any.(contact).Phone != "555-1234" || v.Contacts["home"].Phone != "555-9876" || v.Nested["work"].(*contact).Phone != "555-0000" || v.Nested["count"] != 2 {
+		t.Errorf("fields not decrypted: %+v", v)
+	}
+}
+
+func TestEncryptFieldsUnaddressable(t *testing.T) {
+	kms, _ := NewAESKMS([]byte("0123456789abcdef"))
+	ctx := context.Background()
+	if err := EncryptFields(ctx, kms, contact{Phone: "555-1234"}); err == nil {
+		t.Error("structs passed by value should fail rather than stay in plaintext")
+	}
+	if err := EncryptFields(ctx, kms, struct{ Fn func() }{}); err == nil {
+		t.Error("values that can't be walked should fail")
+	}
+	contacts := []contact{{Phone: "555-1234"}}
+	if err := EncryptFields(ctx, kms, contacts); err != nil || contacts[0].Phone == "555-1234" {
+		t.Errorf("slice elements should be encrypted in place %v", err)
+	}
+}
+
+func TestUseKMS(t *testing.T) {
+	kms, _ := NewAESKMS([]byte("0123456789abcdef"))
+	r, _ := http.NewRequest("GET", "/", nil)
+	UseKMS(kms)(httptest.NewRecorder(), r)
+
+	if RequestKMS(r.Context()) == nil {
+		t.Error("kms not bound to the request")
+	}
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var formatCtxKey = NewKey[string]("format")
+
+// Formats allows the route to be requested with a format suffix, ex. `/reports/123.csv` for the
+// `/reports/:id` route. The requested format is available via Format.
+//
+//	rr.Get("/reports/:id", showReport).Name("report").Formats("csv", "json")
+func (e *Endpoint) Formats(formats ...string) *Endpoint {
+	e.formats = append(e.formats, formats...)
+	return e
+}
+
+// Format retrieves the format suffix the route was requested with, empty when it had none
+func Format(c context.Context) string {
+	format, _ := Get(c, formatCtxKey)
+	return format
+}
+
+// splitFormat removes the format suffix from the path's last segment if it's one of the formats
+func splitFormat(path string, formats []string) (string, string) {
+	dot := strings.LastIndex(path, ".")
+	if dot < 0 || dot < strings.LastIndex(path, "/") {
+		return path, ""
+	}
+	ext := path[dot+1:]
+	for _, f := range formats {
+		if f == ext {
+			return path[:dot], ext
+		}
+	}
+	return path, ""
+}
+
+// Name names the route, allowing its url to be built with URLFor
+func (e *Endpoint) Name(name string) *Endpoint {
+	e.name = name
+	return e
+}
+
+// URLFor builds the path of the named route, filling its url params with the key/value pairs.
+//
+//	rr.URLFor("report", "id", "123") // => /reports/123
+func (r Router) URLFor(name string, params ...string) (string, error) {
+	return r.URLForFormat(name, "", params...)
+}
+
+// URLForFormat builds the path of the named route with the format suffix, which must be one of the
+// route's formats.
+//
+//	rr.URLForFormat("report", "csv", "id", "123") // => /reports/123.csv
+func (r Router) URLForFormat(name, format string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("router: url for %q: params must be key/value pairs", name)
+	}
+	named := r
+	if reloaded := r.reloaded(); reloaded != nil {
+		named = *reloaded
+	}
+	ep := named.namedEndpoint(name)
+	if ep == nil {
+		return "", fmt.Errorf("router: no route named %q", name)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	segments := strings.Split(ep.pattern, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			v, ok := values[segment[1:]]
+			if !ok {
+				return "", fmt.Errorf("router: url for %q: missing param %s", name, segment[1:])
+			}
+			segments[i] = url.PathEscape(v)
+		case segment == "*":
+			v, ok := values["*"]
+			if !ok {
+				return "", fmt.Errorf("router: url for %q: missing wildcard", name)
+			}
+			segments[i] = strings.TrimLeft(v, "/")
+		}
+	}
+	path := strings.Join(segments, "/")
+
+	if format == "" {
+		return path, nil
+	}
+	for _, f := range ep.formats {
+		if f == format {
+			return strings.TrimRight(path, "/") + "." + format, nil
+		}
+	}
+	return "", fmt.Errorf("router: url for %q: unsupported format %q", name, format)
+}
+
+// namedEndpoint finds the endpoint with the name within the router and its subrouters
+func (r Router) namedEndpoint(name string) *Endpoint {
+	for _, eps := range r.endpoints {
+		for _, ep := range eps {
+			if ep.name == name {
+				return ep
+			}
+		}
+	}
+	for _, sub := range r.subRouters {
+		if ep := sub.namedEndpoint(name); ep != nil {
+			return ep
+		}
+	}
+	return nil
+}
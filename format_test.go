@@ -0,0 +1,81 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	rr := New("/")
+	rr.Get("/reports/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Param(r.Context(), "id") + " " + Format(r.Context())))
+	}).Formats("csv", "json")
+	rr.Get("/files/:name", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Param(r.Context(), "name") + " " + Format(r.Context())))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/reports/123", 200, "123 "},
+		{"/reports/123.csv", 200, "123 csv"},
+		{"/reports/123.json", 200, "123 json"},
+		{"/reports/123.xml", 200, "123.xml "},
+		{"/files/notes.csv", 200, "notes.csv "},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d != %d", test.path, rec.Code, test.status)
+		}
+		if rec.Body.String() != test.body {
+			t.Errorf("%s: invalid body %q != %q", test.path, rec.Body.String(), test.body)
+		}
+	}
+}
+
+func TestURLFor(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := New("/")
+	rr.Get("/users/:id", fn).Name("user")
+	admin := rr.SubRouter("/admin")
+	admin.Get("/reports/:year/:id", fn).Name("report").Formats("csv", "json")
+	rr.Get("/files/*", fn).Name("files")
+
+	tests := []struct {
+		name     string
+		format   string
+		params   []string
+		expected string
+		err      string
+	}{
+		{"user", "", []string{"id", "a b"}, "/users/a%20b", ""},
+		{"report", "", []string{"year", "2024", "id", "7"}, "/admin/reports/2024/7", ""},
+		{"report", "csv", []string{"year", "2024", "id", "7"}, "/admin/reports/2024/7.csv", ""},
+		{"files", "", []string{"*", "docs/readme.md"}, "/files/docs/readme.md", ""},
+		{"report", "xml", []string{"year", "2024", "id", "7"}, "", `router: url for "report": unsupported format "xml"`},
+		{"report", "", []string{"year", "2024"}, "", `router: url for "report": missing param id`},
+		{"user", "", []string{"id"}, "", `router: url for "user": params must be key/value pairs`},
+		{"missing", "", nil, "", `router: no route named "missing"`},
+	}
+	for _, test := range tests {
+		url, err := rr.URLForFormat(test.name, test.format, test.params...)
+		if url != test.expected {
+			t.Errorf("%s: %q != %q", test.name, url, test.expected)
+		}
+		if (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("%s: invalid error %v", test.name, err)
+		}
+	}
+
+	routes := rr.Routes()
+	if r := routes[0]; r.Name != "report" || len(r.Formats) != 2 {
+		t.Errorf("formats not included in routes %+v", r)
+	}
+}
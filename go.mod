@@ -1,3 +1,3 @@
 module github.com/chrisolsen/router
 
-go 1.16
+go 1.19
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// AutoHead controls whether HEAD requests are served by the GET handler of routes without a HEAD
+// handler. It is enabled by default; the GET handler's body is discarded and its length is sent
+// as the Content-Length.
+func (r *Router) AutoHead(enabled bool) {
+	r.disableAutoHead = !enabled
+}
+
+// headWriter discards the body written by a GET handler, holding back the header until the handler
+// completes so the Content-Length of the discarded body can be sent
+type headWriter struct {
+	http.ResponseWriter
+	status  int
+	length  int
+	flushed bool
+}
+
+func (hw *headWriter) WriteHeader(status int) {
+	// informational responses, ex. early hints, aren't held back
+	if status < http.StatusOK {
+		hw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if hw.status == 0 {
+		hw.status = status
+	}
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	hw.length += len(b)
+	return len(b), nil
+}
+
+// Flush sends the header early, in which case the Content-Length can't be determined
+func (hw *headWriter) Flush() {
+	hw.writeHeader(false)
+	if f, ok := hw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish sends the header once the handler has completed
+func (hw *headWriter) finish() {
+	hw.writeHeader(true)
+}
+
+func (hw *headWriter) writeHeader(complete bool) {
+	if hw.flushed {
+		return
+	}
+	hw.flushed = true
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	h := hw.Header()
+	if complete && h.Get("Content-Length") == "" && hw.length > 0 {
+		h.Set("Content-Length", strconv.Itoa(hw.length))
+	}
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
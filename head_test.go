@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAutoHead(t *testing.T) {
+	tests := []struct {
+		autoHead       bool
+		path           string
+		expectedStatus int
+		expectedLength string
+	}{
+		{true, "/users", 200, "11"},
+		{true, "/created", 201, "2"},
+		{true, "/custom", 200, ""},
+		{false, "/users", 404, ""},
+	}
+
+	for _, test := range tests {
+		rr := New("/")
+		rr.AutoHead(test.autoHead)
+		rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("hello "))
+			w.Write([]byte("world"))
+		})
+		rr.Get("/created", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(201)
+			w.Write([]byte("ok"))
+		})
+		rr.Get("/custom", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("get"))
+		})
+		rr.HandleFunc("HEAD", "/custom", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Head", "1")
+		})
+
+		req, _ := http.NewRequest("HEAD", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%s: invalid status %d != %d", test.path, rec.Code, test.expectedStatus)
+		}
+		if test.expectedStatus == 404 {
+			continue
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("%s: body should be discarded: %q", test.path, rec.Body.String())
+		}
+		if l := rec.Header().Get("Content-Length"); l != test.expectedLength {
+			t.Errorf("%s: invalid content length %q != %q", test.path, l, test.expectedLength)
+		}
+	}
+}
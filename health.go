@@ -0,0 +1,225 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthOptions configures the health endpoints served by Health
+type HealthOptions struct {
+	// Timeout is how long each check has to complete before it's reported as failed, 2 seconds by
+	// default
+	Timeout time.Duration
+
+	// CacheTTL is how long a check's result is reused, so that frequent probes from several load
+	// balancers don't each run the checks, 1 second by default
+	CacheTTL time.Duration
+}
+
+// HealthStatus is the JSON response of the health endpoints
+type HealthStatus struct {
+	// Status is `ok` when all the checks pass and `fail` otherwise
+	Status string                 `json:"status"`
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// CheckStatus is the result of a single health check
+type CheckStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+var errCheckTimeout = errors.New("check timed out")
+
+// health holds the checks shared by a router and its subrouters
+type health struct {
+	mu        sync.Mutex
+	opts      HealthOptions
+	liveness  map[string]*healthCheck
+	readiness map[string]*healthCheck
+
+	// paths are the paths of the health endpoints, which are served while draining
+	paths map[string]bool
+}
+
+func newHealth() *health {
+	return &health{
+		opts:      HealthOptions{Timeout: 2 * time.Second, CacheTTL: time.Second},
+		liveness:  make(map[string]*healthCheck),
+		readiness: make(map[string]*healthCheck),
+		paths:     make(map[string]bool),
+	}
+}
+
+// healthCheck caches the result of a check, sharing a single run between concurrent probes
+type healthCheck struct {
+	fn func(context.Context) error
+
+	mu        sync.Mutex
+	result    CheckStatus
+	checkedAt time.Time
+	running   chan struct{}
+}
+
+// AddHealthCheck registers a readiness check, reported by `/readyz`. Failing checks take the
+// instance out of the load balancer without restarting it, ex. while the database is unreachable.
+//
+//	rr.AddHealthCheck("db", db.PingContext)
+func (r Router) AddHealthCheck(name string, check func(ctx context.Context) error) {
+	r.health.mu.Lock()
+	defer r.health.mu.Unlock()
+	r.health.readiness[name] = &healthCheck{fn: check}
+}
+
+// AddLivenessCheck registers a liveness check, reported by `/healthz`. Failing checks get the
+// instance restarted, so they should only fail when it can't recover by itself, ex. a deadlock.
+func (r Router) AddLivenessCheck(name string, check func(ctx context.Context) error) {
+	r.health.mu.Lock()
+	defer r.health.mu.Unlock()
+	r.health.liveness[name] = &healthCheck{fn: check}
+}
+
+// Health serves the liveness checks at `/healthz` and the readiness checks at `/readyz`, relative
+// to the router's base path. The checks run concurrently, and the JSON status is sent with a 200
+// when they all pass and a 503 otherwise.
+//
+//	rr.Health(router.HealthOptions{Timeout: time.Second})
+func (r *Router) Health(opts HealthOptions) {
+	r.health.mu.Lock()
+	if opts.Timeout > 0 {
+		r.health.opts.Timeout = opts.Timeout
+	}
+	if opts.CacheTTL > 0 {
+		r.health.opts.CacheTTL = opts.CacheTTL
+	}
+	r.health.mu.Unlock()
+
+	for path, readiness := range map[string]bool{"/healthz": false, "/readyz": true} {
+		r.health.paths[r.fullPath(path)] = true
+		r.Get(path, r.serveHealth(readiness)).NoIndex()
+	}
+}
+
+// serveHealth responds with the status of the liveness or readiness checks. Readiness fails while
+// the router is draining.
+func (r Router) serveHealth(readiness bool) http.HandlerFunc {
+	h := r.health
+	return func(w http.ResponseWriter, req *http.Request) {
+		status := h.status(req.Context(), readiness)
+		if readiness && r.IsDraining() {
+			if status.Checks == nil {
+				status.Checks = make(map[string]CheckStatus)
+			}
+			status.Status = "fail"
+			status.Checks["drain"] = CheckStatus{Status: "fail", Error: "draining", Duration: "0s"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if status.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// status runs the checks concurrently, reusing the cached results that haven't expired
+func (h *health) status(c context.Context, readiness bool) HealthStatus {
+	h.mu.Lock()
+	opts := h.opts
+	checks := h.liveness
+	if readiness {
+		checks = h.readiness
+	}
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	h.mu.Unlock()
+	sort.Strings(names)
+
+	results := make([]CheckStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check *healthCheck) {
+			defer wg.Done()
+			results[i] = check.run(c, opts)
+		}(i, checks[name])
+	}
+	wg.Wait()
+
+	status := HealthStatus{Status: "ok"}
+	if len(names) > 0 {
+		status.Checks = make(map[string]CheckStatus, len(names))
+	}
+	for i, name := range names {
+		status.Checks[name] = results[i]
+		if results[i].Status != "ok" {
+			status.Status = "fail"
+		}
+	}
+	return status
+}
+
+// run returns the cached result, waits on the run in progress or runs the check
+func (hc *healthCheck) run(c context.Context, opts HealthOptions) CheckStatus {
+	hc.mu.Lock()
+	for {
+		if !hc.checkedAt.IsZero() && now().Sub(hc.checkedAt) < opts.CacheTTL {
+			result := hc.result
+			hc.mu.Unlock()
+			return result
+		}
+		if hc.running == nil {
+			break
+		}
+		running := hc.running
+		hc.mu.Unlock()
+		select {
+		case <-running:
+		case <-c.Done():
+			return CheckStatus{Status: "fail", Error: c.Err().Error()}
+		}
+		hc.mu.Lock()
+	}
+	running := make(chan struct{})
+	hc.running = running
+	hc.mu.Unlock()
+
+	result := hc.check(opts.Timeout)
+
+	hc.mu.Lock()
+	hc.result, hc.checkedAt, hc.running = result, now(), nil
+	hc.mu.Unlock()
+	close(running)
+	return result
+}
+
+// check runs the check with the timeout, giving up on checks that don't honor their context. The
+// check isn't given the request's context, as its result is shared by other probes.
+func (hc *healthCheck) check(timeout time.Duration) CheckStatus {
+	c, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- hc.fn(c) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-c.Done():
+		err = errCheckTimeout
+	}
+
+	result := CheckStatus{Status: "ok", Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status, result.Error = "fail", err.Error()
+	}
+	return result
+}
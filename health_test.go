@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	var dbDown int32
+	rr := New("/")
+	rr.AddLivenessCheck("loop", func(c context.Context) error { return nil })
+	rr.AddHealthCheck("cache", func(c context.Context) error { return nil })
+	rr.SubRouter("/api").AddHealthCheck("db", func(c context.Context) error {
+		if atomic.LoadInt32(&dbDown) == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	rr.AddHealthCheck("slow", func(c context.Context) error {
+		<-c.Done()
+		return c.Err()
+	})
+	rr.Health(HealthOptions{Timeout: 10 * time.Millisecond, CacheTTL: time.Nanosecond})
+
+	get := func(path string) (int, HealthStatus) {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		var status HealthStatus
+		json.Unmarshal(rec.Body.Bytes(), &status)
+		return rec.Code, status
+	}
+
+	if code, status := get("/healthz"); code != 200 || status.Status != "ok" || status.Checks["loop"].Status != "ok" {
+		t.Errorf("invalid liveness %d %+v", code, status)
+	}
+	code, status := get("/readyz")
+	if code != 503 || status.Status != "fail" || len(status.Checks) != 3 {
+		t.Errorf("invalid readiness %d %+v", code, status)
+	}
+	if c := status.Checks["slow"]; c.Status != "fail" || c.Error != "context deadline exceeded" && c.Error != "check timed out" {
+		t.Errorf("invalid slow check %+v", c)
+	}
+	if c := status.Checks["db"]; c.Status != "ok" {
+		t.Errorf("invalid db check %+v", c)
+	}
+
+	atomic.StoreInt32(&dbDown, 1)
+	time.Sleep(time.Millisecond)
+	if _, status := get("/readyz"); status.Checks["db"].Error != "connection refused" {
+		t.Errorf("invalid db check %+v", status.Checks["db"])
+	}
+}
+
+func TestHealthCache(t *testing.T) {
+	var runs int32
+	release := make(chan struct{})
+	rr := New("/")
+	rr.AddHealthCheck("db", func(c context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	})
+	rr.Health(HealthOptions{CacheTTL: time.Hour})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "/readyz", nil)
+			rec := httptest.NewRecorder()
+			rr.ServeHTTP(rec, req)
+			if rec.Code != 200 {
+				t.Errorf("invalid status %d", rec.Code)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	rr.ServeHTTP(httptest.NewRecorder(), req)
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("check should run once, ran %d times", n)
+	}
+}
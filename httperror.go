@@ -0,0 +1,100 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Error is an error with the status it should be responded with. Failing the request with it
+// responds with its status, and client errors include the code and message in the response.
+//
+//	if user == nil {
+//		router.Fail(r, router.NotFoundErr)
+//		return
+//	}
+type Error struct {
+	Status int `json:"status"`
+
+	// Code is a stable, machine readable identifier of the error, ex. `invalid_param`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+
+	// Err is the underlying cause, which is never serialized
+	Err error `json:"-"`
+}
+
+// NotFoundErr responds with a 404
+var NotFoundErr = &Error{Status: http.StatusNotFound, Code: "not_found", Message: "not found"}
+
+// NewError creates an error with the status, code and message
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// BadRequest wraps the error, responding with a 400 and its message
+func BadRequest(err error) *Error {
+	return wrapError(http.StatusBadRequest, "bad_request", err)
+}
+
+// Unauthorized wraps the error, responding with a 401 and its message
+func Unauthorized(err error) *Error {
+	return wrapError(http.StatusUnauthorized, "unauthorized", err)
+}
+
+// Forbidden wraps the error, responding with a 403 and its message
+func Forbidden(err error) *Error {
+	return wrapError(http.StatusForbidden, "forbidden", err)
+}
+
+// Conflict wraps the error, responding with a 409 and its message
+func Conflict(err error) *Error {
+	return wrapError(http.StatusConflict, "conflict", err)
+}
+
+func wrapError(status int, code string, err error) *Error {
+	e := &Error{Status: status, Code: code, Err: err}
+	if err != nil {
+		e.Message = err.Error()
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return http.StatusText(e.Status)
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON serializes the error, defaulting the message to the text of its status
+func (e *Error) MarshalJSON() ([]byte, error) {
+	type plain Error
+	out := plain(*e)
+	if out.Message == "" {
+		out.Message = http.StatusText(e.Status)
+	}
+	return json.Marshal(out)
+}
+
+// ParamInt parses the url param as an integer, returning a 400 *Error if it isn't one
+func ParamInt(r *http.Request, key string) (int, error) {
+	v, err := strconv.Atoi(Param(r.Context(), key))
+	if err != nil {
+		return 0, &Error{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_param",
+			Message: key + " must be an integer",
+			Err:     err,
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorResponse(t *testing.T) {
+	errTaken := errors.New("email is taken")
+	tests := []struct {
+		path string
+		err  error
+		body string
+	}{
+		{"/missing", NotFoundErr, `{"code":"not_found","detail":"not found","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"/taken", Conflict(errTaken), `{"code":"conflict","detail":"email is taken","status":409,"title":"Conflict","type":"about:blank"}`},
+		{"/coded", NewError(http.StatusTooManyRequests, "quota", "quota exceeded"), `{"code":"quota","detail":"quota exceeded","status":429,"title":"Too Many Requests","type":"about:blank"}`},
+		{"/internal", &Error{Status: http.StatusBadGateway, Code: "upstream", Message: "secret"}, `{"status":502,"title":"Bad Gateway","type":"about:blank"}`},
+	}
+	for _, test := range tests {
+		err := test.err
+		rr := New("/")
+		rr.Get(test.path, func(w http.ResponseWriter, r *http.Request) {
+			Fail(r, err)
+		})
+
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.err.(*Error).Status {
+			t.Errorf("%s: invalid status %d", test.path, rec.Code)
+		}
+		if body := rec.Body.String(); body != test.body+"\n" {
+			t.Errorf("%s: invalid body %s", test.path, body)
+		}
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	errTaken := errors.New("email is taken")
+	err := Conflict(errTaken)
+	if !errors.Is(err, errTaken) {
+		t.Error("cause should be unwrapped")
+	}
+	data, _ := json.Marshal(err)
+	if string(data) != `{"status":409,"code":"conflict","message":"email is taken"}` {
+		t.Errorf("invalid json %s", data)
+	}
+	data, _ = json.Marshal(&Error{Status: http.StatusForbidden})
+	if string(data) != `{"status":403,"message":"Forbidden"}` {
+		t.Errorf("invalid json %s", data)
+	}
+
+	var httpErr *Error
+	var bindErr error = &BindError{Status: http.StatusRequestEntityTooLarge, Message: "too large"}
+	if !errors.As(bindErr, &httpErr) || httpErr.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("bind error should be handled as an *Error %+v", httpErr)
+	}
+}
+
+func TestParamInt(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users/abc", nil)
+	req = req.WithContext(WithParams(req.Context(), map[string]string{"id": "abc", "page": "2"}))
+	if n, err := ParamInt(req, "page"); n != 2 || err != nil {
+		t.Errorf("invalid page %d %v", n, err)
+	}
+	_, err := ParamInt(req, "id")
+	var httpErr *Error
+	if !errors.As(err, &httpErr) || httpErr.Status != 400 || httpErr.Code != "invalid_param" || httpErr.Message != "id must be an integer" {
+		t.Errorf("invalid error %+v", err)
+	}
+}
@@ -0,0 +1,264 @@
+// Package hub keeps track of realtime connections, grouping them into rooms that messages can be
+// broadcast to. Each connection has its own send queue and writer, so a slow client never holds up
+// a broadcast; clients that fall too far behind are disconnected.
+//
+// The package doesn't depend on a websocket library; a Conn adapts one by writing a single message.
+//
+//	rr.Get("/chat/:room", func(w http.ResponseWriter, r *http.Request) {
+//		ws, err := websocket.Accept(w, r, nil)
+//		if err != nil {
+//			return
+//		}
+//		c := h.Register(wsConn{ws})
+//		defer c.Close()
+//
+//		room := hub.Room(r.Context(), "room")
+//		c.Join(room)
+//		for {
+//			_, msg, err := ws.Read(r.Context())
+//			if err != nil {
+//				return
+//			}
+//			h.Broadcast(room, msg)
+//		}
+//	})
+package hub
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+// ErrSlowClient is passed to OnClose when a client is disconnected for having a full send queue
+var ErrSlowClient = errors.New("hub: client send queue is full")
+
+// Conn writes a single message to the client
+type Conn interface {
+	Write(c context.Context, msg []byte) error
+	Close() error
+}
+
+// Options configures the hub
+type Options struct {
+	// QueueSize is the number of messages buffered for each client, defaulting to 16
+	QueueSize int
+
+	// WriteTimeout limits each write to the client, defaulting to 10 seconds
+	WriteTimeout time.Duration
+
+	// OnClose is called once a client is closed, with the error that caused it, if any
+	OnClose func(c *Client, err error)
+}
+
+// Hub tracks the clients within each room
+type Hub struct {
+	opts Options
+
+	mu    sync.Mutex
+	rooms map[string]map[*Client]struct{}
+}
+
+// New creates an empty hub
+func New(opts Options) *Hub {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 16
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 10 * time.Second
+	}
+	return &Hub{opts: opts, rooms: make(map[string]map[*Client]struct{})}
+}
+
+// Room builds a room name from the matched route and the values of its url params, giving
+// connections to `/chat/:room` with the same room param the same room
+func Room(c context.Context, params ...string) string {
+	parts := []string{router.RoutePattern(c)}
+	for _, p := range params {
+		parts = append(parts, p+"="+router.Param(c, p))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Register starts the writer of the connection. The client must be closed once the connection
+// ends.
+func (h *Hub) Register(conn Conn) *Client {
+	c := &Client{
+		hub:   h,
+		conn:  conn,
+		queue: make(chan []byte, h.opts.QueueSize),
+		done:  make(chan struct{}),
+		rooms: make(map[string]struct{}),
+	}
+	go c.write()
+	return c
+}
+
+// Broadcast queues the message for all the clients within the room, returning the number of clients
+// it was queued for
+func (h *Hub) Broadcast(room string, msg []byte) int {
+	return h.BroadcastExcept(room, msg, nil)
+}
+
+// BroadcastExcept queues the message for all the clients within the room except the sender
+func (h *Hub) BroadcastExcept(room string, msg []byte, sender *Client) int {
+	h.mu.Lock()
+	clients := make([]*Client, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		if c != sender {
+			clients = append(clients, c)
+		}
+	}
+	h.mu.Unlock()
+
+	sent := 0
+	for _, c := range clients {
+		if c.Send(msg) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Members returns the number of clients within the room
+func (h *Hub) Members(room string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.rooms[room])
+}
+
+// Rooms lists the rooms with at least one client
+func (h *Hub) Rooms() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+func (h *Hub) join(room string, c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*Client]struct{})
+	}
+	h.rooms[room][c] = struct{}{}
+}
+
+func (h *Hub) leave(room string, c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.rooms[room], c)
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Client is a registered connection
+type Client struct {
+	hub   *Hub
+	conn  Conn
+	queue chan []byte
+
+	mu     sync.Mutex
+	rooms  map[string]struct{}
+	closed bool
+	done   chan struct{}
+}
+
+// Join adds the client to the room
+func (c *Client) Join(room string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.rooms[room] = struct{}{}
+	c.hub.join(room, c)
+}
+
+// Leave removes the client from the room
+func (c *Client) Leave(room string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rooms, room)
+	c.hub.leave(room, c)
+}
+
+// Send queues the message for the client, returning false if the client is closed. A client whose
+// queue is full is closed with ErrSlowClient.
+func (c *Client) Send(msg []byte) bool {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	select {
+	case c.queue <- msg:
+		c.mu.Unlock()
+		return true
+	default:
+		c.mu.Unlock()
+		c.close(ErrSlowClient)
+		return false
+	}
+}
+
+// Done is closed once the client has been closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close removes the client from all its rooms and closes the connection. Messages still queued are
+// dropped.
+func (c *Client) Close() {
+	c.close(nil)
+}
+
+func (c *Client) close(err error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	for room := range c.rooms {
+		c.hub.leave(room, c)
+	}
+	close(c.done)
+	c.mu.Unlock()
+
+	c.conn.Close()
+	if c.hub.opts.OnClose != nil {
+		c.hub.opts.OnClose(c, err)
+	}
+}
+
+// write sends the queued messages until the client is closed or a write fails
+func (c *Client) write() {
+	for {
+		select {
+		case msg := <-c.queue:
+			ctx, cancel := context.WithTimeout(context.Background(), c.hub.opts.WriteTimeout)
+			err := c.conn.Write(ctx, msg)
+			cancel()
+			if err != nil {
+				c.close(err)
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
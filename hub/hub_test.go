@@ -0,0 +1,138 @@
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+type testConn struct {
+	msgs   chan string
+	block  chan struct{}
+	closed chan struct{}
+}
+
+func newTestConn() *testConn {
+	return &testConn{msgs: make(chan string, 10), closed: make(chan struct{})}
+}
+
+func (c *testConn) Write(ctx context.Context, msg []byte) error {
+	if c.block != nil {
+		select {
+		case <-c.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.msgs <- string(msg)
+	return nil
+}
+
+func (c *testConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func receive(t *testing.T, c *testConn) string {
+	t.Helper()
+	select {
+	case msg := <-c.msgs:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	h := New(Options{})
+	a, b, c := newTestConn(), newTestConn(), newTestConn()
+	ca, cb, cc := h.Register(a), h.Register(b), h.Register(c)
+	ca.Join("general")
+	cb.Join("general")
+	cc.Join("random")
+
+	if n := h.BroadcastExcept("general", []byte("hi"), ca); n != 1 {
+		t.Errorf("invalid recipient count %d", n)
+	}
+	if msg := receive(t, b); msg != "hi" {
+		t.Errorf("invalid message %q", msg)
+	}
+	if n := h.Broadcast("general", []byte("all")); n != 2 {
+		t.Errorf("invalid recipient count %d", n)
+	}
+	if receive(t, a) != "all" || receive(t, b) != "all" {
+		t.Error("message not sent to all members")
+	}
+	select {
+	case msg := <-c.msgs:
+		t.Errorf("message %q sent outside the room", msg)
+	default:
+	}
+
+	if rooms := h.Rooms(); len(rooms) != 2 || rooms[0] != "general" || rooms[1] != "random" {
+		t.Errorf("invalid rooms %v", rooms)
+	}
+	cb.Leave("general")
+	if n := h.Members("general"); n != 1 {
+		t.Errorf("invalid member count %d", n)
+	}
+	cc.Close()
+	<-c.closed
+	if n := h.Members("random"); n != 0 {
+		t.Errorf("closed client should leave its rooms, %d members", n)
+	}
+	if cc.Send([]byte("late")) {
+		t.Error("closed client should not accept messages")
+	}
+}
+
+func TestSlowClient(t *testing.T) {
+	closed := make(chan error, 1)
+	h := New(Options{QueueSize: 1, OnClose: func(c *Client, err error) {
+		closed <- err
+	}})
+	slow, fast := newTestConn(), newTestConn()
+	slow.block = make(chan struct{})
+	cs, cf := h.Register(slow), h.Register(fast)
+	cs.Join("room")
+	cf.Join("room")
+
+	// the blocked writer holds at most one message, so the queue overflows
+	for i := 0; i < 3; i++ {
+		h.Broadcast("room", []byte("msg"))
+		receive(t, fast)
+	}
+
+	select {
+	case err := <-closed:
+		if err != ErrSlowClient {
+			t.Errorf("invalid error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow client not closed")
+	}
+	<-cs.Done()
+	if n := h.Members("room"); n != 1 {
+		t.Errorf("invalid member count %d", n)
+	}
+	close(slow.block)
+}
+
+func TestRoom(t *testing.T) {
+	var room string
+	rr := router.New("/")
+	rr.Get("/chat/:room", func(w http.ResponseWriter, r *http.Request) {
+		room = Room(r.Context(), "room")
+	})
+
+	req, _ := http.NewRequest("GET", "/chat/general", nil)
+	rr.ServeHTTP(httptest.NewRecorder(), req)
+	if room != "/chat/:room room=general" {
+		t.Errorf("invalid room %q", room)
+	}
+}
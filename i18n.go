@@ -0,0 +1,177 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	localeCtxKey  = NewKey[string]("locale")
+	catalogCtxKey = NewKey[*Catalog]("catalog")
+)
+
+// Catalog holds the translated messages for each locale
+type Catalog struct {
+	fallback string
+	messages map[string]map[string]string
+}
+
+// NewCatalog creates an empty catalog, using the fallback locale for messages missing from the
+// request's locale
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{
+		fallback: strings.ToLower(fallback),
+		messages: make(map[string]map[string]string),
+	}
+}
+
+// LoadCatalog loads all the `<locale>.json` and `<locale>.toml` files within the root of the file system.
+// Nested JSON objects and TOML tables are flattened into dot separated keys, ex. `errors.not_found`.
+// Only string values of the TOML format are supported.
+func LoadCatalog(fsys fs.FS, fallback string) (*Catalog, error) {
+	c := NewCatalog(fallback)
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".toml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		messages := make(map[string]string)
+		if ext == ".json" {
+			err = parseJSONMessages(data, messages)
+		} else {
+			err = parseTOMLMessages(data, messages)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("catalog: %s: %v", entry.Name(), err)
+		}
+		c.Add(strings.TrimSuffix(entry.Name(), ext), messages)
+	}
+	return c, nil
+}
+
+// Add merges the messages into the locale's existing messages
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	locale = strings.ToLower(locale)
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	for key, msg := range messages {
+		c.messages[locale][key] = msg
+	}
+}
+
+// Translate looks up the message within the locale, falling back on the base language (`fr` for
+// `fr-CA`) and then the fallback locale. The key is returned when no message exists.
+func (c *Catalog) Translate(locale, key string, args ...interface{}) string {
+	locale = strings.ToLower(locale)
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, c.fallback)
+
+	for _, l := range candidates {
+		if msg, ok := c.messages[l][key]; ok {
+			if len(args) > 0 {
+				return fmt.Sprintf(msg, args...)
+			}
+			return msg
+		}
+	}
+	return key
+}
+
+// UseCatalog is middleware that makes the catalog available to T
+func UseCatalog(c *Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		BindContext(Set(r.Context(), catalogCtxKey, c), r)
+	}
+}
+
+// T translates the message key into the request's locale using the catalog bound by UseCatalog.
+// The args are formatted into the message with fmt.Sprintf.
+func T(c context.Context, key string, args ...interface{}) string {
+	catalog, ok := Get(c, catalogCtxKey)
+	if !ok {
+		return key
+	}
+	return catalog.Translate(Locale(c), key, args...)
+}
+
+// Locale retrieves the locale negotiated for the request
+func Locale(c context.Context) string {
+	locale, _ := Get(c, localeCtxKey)
+	return locale
+}
+
+// WithLocale returns a copy of the context containing the locale, used by locale negotiation middleware
+func WithLocale(c context.Context, locale string) context.Context {
+	return Set(c, localeCtxKey, locale)
+}
+
+func parseJSONMessages(data []byte, messages map[string]string) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	flattenMessages("", raw, messages)
+	return nil
+}
+
+func flattenMessages(prefix string, raw map[string]interface{}, messages map[string]string) {
+	for key, val := range raw {
+		switch v := val.(type) {
+		case string:
+			messages[prefix+key] = v
+		case map[string]interface{}:
+			flattenMessages(prefix+key+".", v, messages)
+		}
+	}
+}
+
+// parseTOMLMessages parses the `key = "value"` and `[table]` subset of TOML
+func parseTOMLMessages(data []byte, messages map[string]string) error {
+	var prefix string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			prefix = strings.TrimSpace(text[1:len(text)-1]) + "."
+			continue
+		}
+
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return fmt.Errorf("line %d: expected `key = \"value\"`", line)
+		}
+		key := strings.Trim(strings.TrimSpace(text[:i]), `"`)
+		val, err := strconv.Unquote(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid string value", line)
+		}
+		messages[prefix+key] = val
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en.json": {Data: []byte(`{"hello": "Hello %s", "errors": {"not_found": "Not found"}}`)},
+		"fr.toml": {Data: []byte("# french\nhello = \"Bonjour %s\"\n\n[errors]\nnot_found = \"Introuvable\"\n")},
+		"README":  {Data: []byte("ignored")},
+	}
+	c, err := LoadCatalog(fsys, "en")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		locale   string
+		key      string
+		expected string
+	}{
+		{"en", "errors.not_found", "Not found"},
+		{"fr", "errors.not_found", "Introuvable"},
+		{"fr-CA", "errors.not_found", "Introuvable"},
+		{"de", "errors.not_found", "Not found"},
+		{"fr", "missing.key", "missing.key"},
+	}
+	for _, test := range tests {
+		if msg := c.Translate(test.locale, test.key); msg != test.expected {
+			t.Errorf("%s %s: %q != %q", test.locale, test.key, msg, test.expected)
+		}
+	}
+
+	if msg := c.Translate("fr", "hello", "Marie"); msg != "Bonjour Marie" {
+		t.Errorf("args not formatted: %s", msg)
+	}
+}
+
+func TestLoadCatalogInvalid(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fr.toml": {Data: []byte("hello Bonjour")},
+	}
+	if _, err := LoadCatalog(fsys, "en"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestT(t *testing.T) {
+	c := NewCatalog("en")
+	c.Add("en", map[string]string{"greeting": "Hi"})
+	c.Add("es", map[string]string{"greeting": "Hola"})
+
+	if msg := T(context.Background(), "greeting"); msg != "greeting" {
+		t.Errorf("key should be returned without a catalog: %s", msg)
+	}
+
+	rr := New("/")
+	rr.Before(UseCatalog(c), func(w http.ResponseWriter, r *http.Request) {
+		BindContext(WithLocale(r.Context(), "es"), r)
+	})
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(T(r.Context(), "greeting")))
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Body.String() != "Hola" {
+		t.Errorf("message not translated: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,207 @@
+// Package wrap extends the response writer wrappers of the router and its middleware with the
+// optional interfaces of the writers they wrap, ex. http.Hijacker for websockets.
+package wrap
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Writer returns the wrapper w of the underlying writer, exposing only the optional interfaces the
+// underlying writer implements, so handlers checking for http.Flusher, http.Hijacker or
+// http.Pusher see the same support as they would unwrapped. Each is served by w's own method when
+// it has one, allowing it to flush its buffers or record the status, and by the underlying writer
+// otherwise. io.ReaderFrom is only exposed when w implements it too, as passing it straight through
+// would bypass w's Write. The result unwraps to the underlying writer.
+func Writer(w, underlying http.ResponseWriter) http.ResponseWriter {
+	base := writer{w, underlying}
+	flusher, canFlush := pick[http.Flusher](w, underlying)
+	hijacker, canHijack := pick[http.Hijacker](w, underlying)
+	pusher, canPush := pick[http.Pusher](w, underlying)
+	readerFrom, canReadFrom := w.(io.ReaderFrom)
+	if _, ok := underlying.(io.ReaderFrom); !ok {
+		canReadFrom = false
+	}
+
+	const (
+		flush = 1 << iota
+		hijack
+		push
+		readFrom
+	)
+	var supported int
+	if canFlush {
+		supported |= flush
+	}
+	if canHijack {
+		supported |= hijack
+	}
+	if canPush {
+		supported |= push
+	}
+	if canReadFrom {
+		supported |= readFrom
+	}
+
+	switch supported {
+	case flush:
+		return struct {
+			writer
+			http.Flusher
+		}{base, flusher}
+	case hijack:
+		return struct {
+			writer
+			http.Hijacker
+		}{base, hijacker}
+	case flush | hijack:
+		return struct {
+			writer
+			http.Flusher
+			http.Hijacker
+		}{base, flusher, hijacker}
+	case push:
+		return struct {
+			writer
+			http.Pusher
+		}{base, pusher}
+	case flush | push:
+		return struct {
+			writer
+			http.Flusher
+			http.Pusher
+		}{base, flusher, pusher}
+	case hijack | push:
+		return struct {
+			writer
+			http.Hijacker
+			http.Pusher
+		}{base, hijacker, pusher}
+	case flush | hijack | push:
+		return struct {
+			writer
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{base, flusher, hijacker, pusher}
+	case readFrom:
+		return struct {
+			writer
+			io.ReaderFrom
+		}{base, readerFrom}
+	case flush | readFrom:
+		return struct {
+			writer
+			http.Flusher
+			io.ReaderFrom
+		}{base, flusher, readerFrom}
+	case hijack | readFrom:
+		return struct {
+			writer
+			http.Hijacker
+			io.ReaderFrom
+		}{base, hijacker, readerFrom}
+	case flush | hijack | readFrom:
+		return struct {
+			writer
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{base, flusher, hijacker, readerFrom}
+	case push | readFrom:
+		return struct {
+			writer
+			http.Pusher
+			io.ReaderFrom
+		}{base, pusher, readerFrom}
+	case flush | push | readFrom:
+		return struct {
+			writer
+			http.Flusher
+			http.Pusher
+			io.ReaderFrom
+		}{base, flusher, pusher, readerFrom}
+	case hijack | push | readFrom:
+		return struct {
+			writer
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{base, hijacker, pusher, readerFrom}
+	case flush | hijack | push | readFrom:
+		return struct {
+			writer
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+			io.ReaderFrom
+		}{base, flusher, hijacker, pusher, readerFrom}
+	}
+	return base
+}
+
+// pick returns w's implementation of the interface, falling back to the underlying writer's, as
+// long as the underlying writer implements it
+func pick[T any](w, underlying http.ResponseWriter) (T, bool) {
+	impl, ok := underlying.(T)
+	if !ok {
+		return impl, false
+	}
+	if own, ok := w.(T); ok {
+		return own, true
+	}
+	return impl, true
+}
+
+// writer only promotes the methods of http.ResponseWriter, hiding the wrapper's optional methods
+type writer struct {
+	http.ResponseWriter
+	underlying http.ResponseWriter
+}
+
+// Unwrap returns the underlying writer
+func (w writer) Unwrap() http.ResponseWriter {
+	return w.underlying
+}
+
+// StatusWriter records the status of the response written through it
+type StatusWriter struct {
+	http.ResponseWriter
+	Status int
+}
+
+func (sw *StatusWriter) WriteHeader(status int) {
+	// informational responses, ex. early hints, precede the final status
+	if sw.Status == 0 && status >= 200 {
+		sw.Status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *StatusWriter) Write(b []byte) (int, error) {
+	if sw.Status == 0 {
+		sw.Status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Hijack takes over the underlying connection, recording it as switching protocols. Writer only
+// exposes it when the underlying writer supports it.
+func (sw *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := sw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && sw.Status == 0 {
+		sw.Status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// ReadFrom copies the body using the underlying writer's ReadFrom, allowing files to be sent with
+// sendfile. Writer only exposes it when the underlying writer supports it.
+func (sw *StatusWriter) ReadFrom(src io.Reader) (int64, error) {
+	if sw.Status == 0 {
+		sw.Status = http.StatusOK
+	}
+	return sw.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+}
@@ -0,0 +1,89 @@
+package wrap
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// flushWriter buffers its writes until flushed
+type flushWriter struct {
+	http.ResponseWriter
+	flushed bool
+}
+
+func (fw *flushWriter) Flush() {
+	fw.flushed = true
+}
+
+// hijackRecorder is a recorder that can be hijacked
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hr hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := Writer(&StatusWriter{ResponseWriter: rec}, rec)
+	if _, ok := w.(http.Flusher); !ok {
+		t.Error("the recorder's Flush should be exposed")
+	}
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("the recorder can't be hijacked")
+	}
+	if _, ok := w.(http.Pusher); ok {
+		t.Error("the recorder can't push")
+	}
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Error("the recorder doesn't read from")
+	}
+	if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok || u.Unwrap() != rec {
+		t.Error("the writer should unwrap to the recorder")
+	}
+
+	// the wrapper's own Flush takes precedence over the underlying writer's
+	fw := &flushWriter{ResponseWriter: rec}
+	Writer(fw, rec).(http.Flusher).Flush()
+	if !fw.flushed || rec.Flushed {
+		t.Error("the wrapper's Flush should be called")
+	}
+
+	// the wrapper's ReadFrom isn't exposed over a writer without one
+	hr := hijackRecorder{httptest.NewRecorder()}
+	sw := &StatusWriter{ResponseWriter: hr}
+	w = Writer(sw, hr)
+	if _, ok := w.(io.ReaderFrom); ok {
+		t.Error("ReadFrom should only be exposed when the underlying writer reads from")
+	}
+	if _, _, err := w.(http.Hijacker).Hijack(); err != nil || sw.Status != http.StatusSwitchingProtocols {
+		t.Errorf("hijacking should be recorded as switching protocols, got %d %v", sw.Status, err)
+	}
+}
+
+func TestStatusWriterReadFrom(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &StatusWriter{ResponseWriter: w}
+		Writer(sw, w).(io.ReaderFrom).ReadFrom(strings.NewReader("streamed"))
+		if sw.Status != http.StatusOK {
+			t.Errorf("invalid status %d", sw.Status)
+		}
+	}))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(b) != "streamed" {
+		t.Errorf("invalid body %q", b)
+	}
+}
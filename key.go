@@ -0,0 +1,43 @@
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+// Key is a typed context key. Keys are compared by identity rather than by name, so the keys of
+// different packages never collide, and the values retrieved with Get have the key's type.
+//
+//	var userKey = router.NewKey[*User]("user")
+//
+//	router.BindContext(router.Set(r.Context(), userKey, user), r)
+//	user, ok := router.Get(r.Context(), userKey)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a key for values of type T. The name only describes the key, ex. when printed.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return "router.Key(" + k.name + ")"
+}
+
+// Set returns a copy of the context holding the value under the key
+func Set[T any](c context.Context, key *Key[T], val T) context.Context {
+	return context.WithValue(c, key, val)
+}
+
+// SetRequest returns a copy of the request whose context holds the value under the key, to be
+// passed on to the next handler
+func SetRequest[T any](r *http.Request, key *Key[T], val T) *http.Request {
+	return r.WithContext(Set(r.Context(), key, val))
+}
+
+// Get retrieves the value held under the key, reporting whether the context had one
+func Get[T any](c context.Context, key *Key[T]) (T, bool) {
+	val, ok := c.Value(key).(T)
+	return val, ok
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	userKey := NewKey[string]("user")
+	otherKey := NewKey[string]("user")
+	countKey := NewKey[int]("count")
+
+	c := Set(context.Background(), userKey, "bob")
+	c = Set(c, otherKey, "ann")
+	c = context.WithValue(c, "user", "eve")
+
+	if user, ok := Get(c, userKey); !ok || user != "bob" {
+		t.Errorf("invalid user %q %t", user, ok)
+	}
+	if user, _ := Get(c, otherKey); user != "ann" {
+		t.Errorf("keys of the same name shouldn't collide, got %q", user)
+	}
+	if count, ok := Get(c, countKey); ok || count != 0 {
+		t.Errorf("missing values should be reported, got %d %t", count, ok)
+	}
+	if s := userKey.String(); s != "router.Key(user)" {
+		t.Errorf("invalid name %s", s)
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	derived := SetRequest(req, countKey, 5)
+	if count, _ := Get(derived.Context(), countKey); count != 5 {
+		t.Errorf("invalid count %d", count)
+	}
+	if _, ok := Get(req.Context(), countKey); ok {
+		t.Error("the original request shouldn't be changed")
+	}
+}
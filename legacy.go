@@ -0,0 +1,181 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LegacyRule translates a legacy URL onto the new routes, ex. `/?page=users&id=3` onto `/users/3`
+//
+//	LegacyRule{
+//		Name:  "users",
+//		Path:  "/",
+//		Query: map[string]string{"page": "users", "id": ""},
+//		To:    "/users/:id",
+//	}
+type LegacyRule struct {
+	// Name identifies the rule within the usage counters, defaulting to the legacy path
+	Name string
+
+	// Path is the legacy path, which is matched exactly
+	Path string
+
+	// Query are the query params required by the legacy URL. An empty value only requires the param
+	// to be present.
+	Query map[string]string
+
+	// To is the new path, with each `:name` segment filled with the value of the query param
+	To string
+
+	// Redirect sends the client on to the new URL using the status, rather than serving the new
+	// route directly
+	Redirect int
+}
+
+// LegacyUsage counts the requests still made with a legacy URL
+type LegacyUsage struct {
+	Rule     string    `json:"rule"`
+	Requests uint64    `json:"requests"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Legacy translates the legacy URLs of a previous URL scheme before the routes are matched, counting
+// how often each legacy form is still used
+type Legacy struct {
+	rules []LegacyRule
+
+	mu    sync.Mutex
+	usage map[string]*LegacyUsage
+}
+
+// NewLegacy creates the translation layer for the rules, which are checked in order
+func NewLegacy(rules ...LegacyRule) *Legacy {
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rules[i].Name = rule.Path
+		}
+	}
+	return &Legacy{rules: rules, usage: make(map[string]*LegacyUsage)}
+}
+
+// Legacy enables the translation of legacy URLs for all routes, including those of the subrouters
+func (r *Router) Legacy(l *Legacy) {
+	r.legacy = l
+}
+
+// Usage returns a copy of the counters, ordered by rule name. Rules without any requests are
+// included, identifying those that can be retired.
+func (l *Legacy) Usage() []LegacyUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	usage := make([]LegacyUsage, 0, len(l.rules))
+	seen := make(map[string]bool)
+	for _, rule := range l.rules {
+		if seen[rule.Name] {
+			continue
+		}
+		seen[rule.Name] = true
+		if u := l.usage[rule.Name]; u != nil {
+			usage = append(usage, *u)
+		} else {
+			usage = append(usage, LegacyUsage{Rule: rule.Name})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Rule < usage[j].Rule
+	})
+	return usage
+}
+
+// translate returns a copy of the request with its URL rewritten onto the new route, leaving the
+// caller's URL unchanged, or true if the request was redirected instead
+func (l *Legacy) translate(w http.ResponseWriter, req *http.Request) (*http.Request, bool) {
+	rule, target, ok := l.find(req.URL)
+	if !ok {
+		return req, false
+	}
+	l.record(rule.Name)
+
+	if rule.Redirect != 0 {
+		http.Redirect(w, req, target.String(), rule.Redirect)
+		return req, true
+	}
+	r2 := new(http.Request)
+	*r2 = *req
+	r2.URL = new(url.URL)
+	*r2.URL = *req.URL
+	r2.URL.Path = target.Path
+	r2.URL.RawPath = target.RawPath
+	r2.URL.RawQuery = target.RawQuery
+	r2.RequestURI = target.RequestURI()
+	return r2, false
+}
+
+// find returns the first rule matching the URL, along with the new URL it's translated to
+func (l *Legacy) find(u *url.URL) (LegacyRule, *url.URL, bool) {
+	query := u.Query()
+	for _, rule := range l.rules {
+		if target, ok := rule.translate(u.Path, query); ok {
+			return rule, target, true
+		}
+	}
+	return LegacyRule{}, nil, false
+}
+
+func (l *Legacy) record(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u := l.usage[name]
+	if u == nil {
+		u = &LegacyUsage{Rule: name}
+		l.usage[name] = u
+	}
+	u.Requests++
+	u.LastSeen = now()
+}
+
+// translate builds the new URL if the path and query match the rule. The query params used by the
+// rule are removed, with any others passed on to the new URL.
+func (rule LegacyRule) translate(path string, query url.Values) (*url.URL, bool) {
+	if path != rule.Path {
+		return nil, false
+	}
+	for key, value := range rule.Query {
+		if _, ok := query[key]; !ok || (value != "" && query.Get(key) != value) {
+			return nil, false
+		}
+	}
+
+	rest := url.Values{}
+	for key, values := range query {
+		if _, ok := rule.Query[key]; !ok {
+			rest[key] = values
+		}
+	}
+	segments := strings.Split(rule.To, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		key := segment[1:]
+		value := query.Get(key)
+		if value == "" {
+			return nil, false
+		}
+		segments[i] = url.PathEscape(value)
+		delete(rest, key)
+	}
+
+	target, err := url.Parse(strings.Join(segments, "/"))
+	if err != nil {
+		return nil, false
+	}
+	target.RawQuery = rest.Encode()
+	return target, true
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLegacy(t *testing.T) {
+	legacy := NewLegacy(
+		LegacyRule{Name: "user", Path: "/", Query: map[string]string{"page": "users", "id": ""}, To: "/users/:id"},
+		LegacyRule{Name: "users", Path: "/", Query: map[string]string{"page": "users"}, To: "/users"},
+		LegacyRule{Path: "/index.php", Query: map[string]string{"p": ""}, To: "/posts/:p", Redirect: http.StatusMovedPermanently},
+		LegacyRule{Name: "unused", Path: "/old", To: "/"},
+	)
+	rr := New("/")
+	rr.Legacy(legacy)
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home"))
+	})
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users " + r.URL.RawQuery))
+	})
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user " + Param(r.Context(), "id") + " " + r.URL.RawQuery))
+	})
+
+	tests := []struct {
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{"/?page=users&id=3", 200, "user 3 ", ""},
+		{"/?page=users&id=3&tab=posts", 200, "user 3 tab=posts", ""},
+		{"/?page=users&sort=name", 200, "users sort=name", ""},
+		{"/?page=posts", 200, "home", ""},
+		{"/index.php?p=hello%20world&ref=rss", 301, "", "/posts/hello%20world?ref=rss"},
+		{"/index.php", 404, "", ""},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d != %d", test.path, rec.Code, test.status)
+		}
+		if test.body != "" && rec.Body.String() != test.body {
+			t.Errorf("%s: invalid body %q != %q", test.path, rec.Body.String(), test.body)
+		}
+		if loc := rec.Header().Get("Location"); loc != test.location {
+			t.Errorf("%s: invalid location %q != %q", test.path, loc, test.location)
+		}
+		if req.URL.String() != test.path {
+			t.Errorf("%s: the caller's request should be left untouched, got %s", test.path, req.URL)
+		}
+	}
+
+	expected := map[string]uint64{"/index.php": 1, "unused": 0, "user": 2, "users": 1}
+	usage := legacy.Usage()
+	if len(usage) != len(expected) {
+		t.Fatalf("invalid usage %+v", usage)
+	}
+	for _, u := range usage {
+		if u.Requests != expected[u.Rule] {
+			t.Errorf("%s: invalid requests %d != %d", u.Rule, u.Requests, expected[u.Rule])
+		}
+		if u.Requests > 0 && u.LastSeen.IsZero() {
+			t.Errorf("%s: last seen not set", u.Rule)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package router
+
+import "net/http"
+
+// hooks are the lifecycle callbacks shared by a router and its subrouters
+type hooks struct {
+	registered []func(RouteInfo)
+	removed    []func(RouteInfo)
+	matched    []func(*http.Request, RouteInfo)
+}
+
+// OnRouteRegistered calls fn as each route is added to the router or its subrouters, allowing
+// extensions such as doc generators and metrics registries to follow the route table without the
+// router importing them. It's called straight away for the routes already registered. Routes are
+// described as they're registered, so the options set on the returned Endpoint afterwards, such as
+// its name and policies, are only listed by Routes.
+func (r Router) OnRouteRegistered(fn func(RouteInfo)) {
+	r.hooks.registered = append(r.hooks.registered, fn)
+	for _, route := range r.Routes() {
+		if !route.Inactive {
+			fn(route)
+		}
+	}
+}
+
+// OnRouteRemoved calls fn as each route is removed from the router or its subrouters with Remove
+func (r Router) OnRouteRemoved(fn func(RouteInfo)) {
+	r.hooks.removed = append(r.hooks.removed, fn)
+}
+
+// OnRequestMatched calls fn with each request once its route has been matched, before the
+// middleware runs, ex. for authorization checkers auditing which routes are reached. Hooks are
+// registered before serving, as they aren't synchronized with the requests.
+func (r Router) OnRequestMatched(fn func(req *http.Request, route RouteInfo)) {
+	r.hooks.matched = append(r.hooks.matched, fn)
+}
+
+// Remove removes the routes of the method registered at the path, relative to the router, from the
+// router or its subrouters, along with the endpoints negotiated for them, reporting whether any
+// existed. The method is empty for routes registered with Handle or Mount, and removing a mount
+// removes everything under its prefix. Routes are removed before serving, or within Reload, as the
+// route table isn't synchronized with the requests.
+func (r Router) Remove(method, path string) bool {
+	return r.remove(method, r.fullPath(path))
+}
+
+// remove removes the routes matching the full pattern, calling the OnRouteRemoved hooks for each
+func (r *Router) remove(method, pattern string) bool {
+	removed := make(map[*Endpoint]bool)
+	for route, ep := range r.routes {
+		if route.method == method && (r.fullPath(route.path) == pattern || ep.pattern == pattern) {
+			removed[ep] = true
+		}
+	}
+	// mounts register the same endpoint under two routes, which are removed together
+	for route, ep := range r.routes {
+		if !removed[ep] {
+			continue
+		}
+		delete(r.routes, route)
+		delete(r.endpoints, route)
+		info := r.routeInfo(route, ep, nil)
+		for _, fn := range r.hooks.removed {
+			fn(info)
+		}
+	}
+
+	found := len(removed) > 0
+	for _, sub := range r.subRouters {
+		found = sub.remove(method, pattern) || found
+	}
+	for _, shadow := range r.inactive {
+		found = shadow.remove(method, pattern) || found
+	}
+	return found
+}
+
+func (r Router) routeRegistered(route Route, ep *Endpoint) {
+	if len(r.hooks.registered) == 0 {
+		return
+	}
+	info := r.routeInfo(route, ep, nil)
+	for _, fn := range r.hooks.registered {
+		fn(info)
+	}
+}
+
+func (r Router) requestMatched(rr *Router, route Route, ep *Endpoint, req *http.Request) {
+	if len(r.hooks.matched) == 0 {
+		return
+	}
+	info := rr.routeInfo(route, ep, nil)
+	for _, fn := range r.hooks.matched {
+		fn(req, info)
+	}
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	rr := New("/")
+	rr.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	var registered, removed, matched []string
+	rr.OnRouteRegistered(func(route RouteInfo) {
+		registered = append(registered, method(route.Method)+" "+route.Pattern)
+	})
+	rr.OnRouteRemoved(func(route RouteInfo) {
+		removed = append(removed, method(route.Method)+" "+route.Pattern)
+	})
+	rr.OnRequestMatched(func(r *http.Request, route RouteInfo) {
+		matched = append(matched, route.Pattern+" id="+Param(r.Context(), "id"))
+	})
+
+	api := rr.SubRouter("/api")
+	api.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	api.Post("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	expected := []string{"GET /health", "GET /api/users/:id", "POST /api/users"}
+	if !reflect.DeepEqual(registered, expected) {
+		t.Errorf("invalid registered routes %v", registered)
+	}
+
+	for _, path := range []string{"/api/users/7", "/missing"} {
+		r, _ := http.NewRequest("GET", path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if !reflect.DeepEqual(matched, []string{"/api/users/:id id=7"}) {
+		t.Errorf("invalid matched requests %v", matched)
+	}
+
+	if !api.Remove("POST", "/users") || api.Remove("POST", "/users") {
+		t.Error("route should be removed once")
+	}
+	if !reflect.DeepEqual(removed, []string{"POST /api/users"}) {
+		t.Errorf("invalid removed routes %v", removed)
+	}
+	r, _ := http.NewRequest("POST", "/api/users", nil)
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("removed route served with %d", w.Code)
+	}
+	if len(rr.Routes()) != 2 {
+		t.Errorf("invalid routes %v", rr.Routes())
+	}
+}
+
+func TestRemoveMount(t *testing.T) {
+	rr := New("/")
+	var removed []string
+	rr.OnRouteRemoved(func(route RouteInfo) {
+		removed = append(removed, route.Pattern)
+	})
+	admin := rr.SubRouter("/admin")
+	admin.Mount("/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	if !admin.Remove("", "/files") {
+		t.Error("mount should be removed")
+	}
+	sort.Strings(removed)
+	if !reflect.DeepEqual(removed, []string{"/admin/files", "/admin/files/*"}) {
+		t.Errorf("invalid removed routes %v", removed)
+	}
+	for _, path := range []string{"/admin/files", "/admin/files/a.txt"} {
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: removed mount served with %d", path, w.Code)
+		}
+	}
+
+	// routes removed while reloading are reported too
+	rr.Get("/beta", func(w http.ResponseWriter, r *http.Request) {})
+	removed = nil
+	rr.Reload(func(b *Builder) {
+		b.Remove(http.MethodGet, "/beta")
+	})
+	if !reflect.DeepEqual(removed, []string{"/beta"}) {
+		t.Errorf("invalid removed routes %v", removed)
+	}
+}
@@ -0,0 +1,92 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintRule is an invariant that the selected routes must satisfy, ex. every POST route has a body
+// limit
+type LintRule struct {
+	// Name identifies the rule within the report
+	Name string
+
+	// Routes selects the routes the rule applies to, nil applying it to all routes
+	Routes func(RouteInfo) bool
+
+	// Check returns an error describing why the route violates the rule
+	Check func(RouteInfo) error
+}
+
+// LintViolation is a route that failed a rule
+type LintViolation struct {
+	Rule    string
+	Route   RouteInfo
+	Message string
+}
+
+// LintError reports all the violations found by Lint
+type LintError struct {
+	Violations []LintViolation
+}
+
+func (e *LintError) Error() string {
+	lines := make([]string, 0, len(e.Violations)+1)
+	lines = append(lines, fmt.Sprintf("router: %d lint violations", len(e.Violations)))
+	for _, v := range e.Violations {
+		lines = append(lines, fmt.Sprintf("  %s: %s %s: %s", v.Rule, method(v.Route.Method), v.Route.Pattern, v.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Lint checks the active routes of the router and its subrouters against the rules once they have
+// all been registered, returning a *LintError listing every violation. This catches policy drift
+// within large route tables at startup or within a test.
+//
+//	err := rr.Lint(
+//		router.LintRule{Name: "api-cache", Routes: router.PathPrefix("/api"), Check: policy.RequireCache},
+//		router.LintRule{Name: "post-body", Routes: router.Methods("POST"), Check: policy.RequireBodyLimit},
+//	)
+func (r Router) Lint(rules ...LintRule) error {
+	var violations []LintViolation
+	for _, route := range r.Routes() {
+		if route.Inactive {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Routes != nil && !rule.Routes(route) {
+				continue
+			}
+			if err := rule.Check(route); err != nil {
+				violations = append(violations, LintViolation{Rule: rule.Name, Route: route, Message: err.Error()})
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &LintError{Violations: violations}
+	}
+	return nil
+}
+
+// PathPrefix selects the routes whose pattern is the prefix or is under it
+func PathPrefix(prefix string) func(RouteInfo) bool {
+	prefix = "/" + strings.Trim(prefix, "/")
+	return func(route RouteInfo) bool {
+		return route.Pattern == prefix || strings.HasPrefix(route.Pattern, strings.TrimRight(prefix, "/")+"/")
+	}
+}
+
+// Methods selects the routes handling any of the methods, including those handling all methods
+func Methods(methods ...string) func(RouteInfo) bool {
+	return func(route RouteInfo) bool {
+		if route.Method == "" {
+			return true
+		}
+		for _, m := range methods {
+			if strings.EqualFold(route.Method, m) {
+				return true
+			}
+		}
+		return false
+	}
+}
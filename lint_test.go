@@ -0,0 +1,55 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := New("/")
+	rr.Get("/", fn)
+	rr.Post("/contact", fn).Policy(testPolicy("body"))
+	api := rr.SubRouter("/api")
+	api.Policy(testPolicy("cache"))
+	api.Get("/users", fn)
+	api.Post("/users", fn)
+	admin := rr.SubRouter("/admin")
+	admin.Handle("/files", http.NotFoundHandler())
+
+	has := func(name string) func(RouteInfo) error {
+		return func(route RouteInfo) error {
+			for _, p := range route.Policies {
+				if p == testPolicy(name) {
+					return nil
+				}
+			}
+			return errors.New("no " + name + " policy")
+		}
+	}
+	err := rr.Lint(
+		LintRule{Name: "api-cache", Routes: PathPrefix("/api"), Check: has("cache")},
+		LintRule{Name: "post-body", Routes: Methods("POST"), Check: has("body")},
+		LintRule{Name: "admin-auth", Routes: PathPrefix("/admin/"), Check: has("auth")},
+	)
+	lintErr, ok := err.(*LintError)
+	if !ok {
+		t.Fatalf("invalid error %v", err)
+	}
+
+	expected := `router: 3 lint violations
+  post-body: * /admin/files: no body policy
+  admin-auth: * /admin/files: no auth policy
+  post-body: POST /api/users: no body policy`
+	if lintErr.Error() != expected {
+		t.Errorf("invalid report\n%s", lintErr.Error())
+	}
+	if v := lintErr.Violations[2]; v.Rule != "post-body" || len(v.Route.Policies) != 1 {
+		t.Errorf("invalid violation %+v", v)
+	}
+
+	if err := rr.Lint(LintRule{Name: "api-cache", Routes: PathPrefix("/api"), Check: has("cache")}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package router
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListingOptions configures the directory listings of Static
+type ListingOptions struct {
+	// ShowHidden lists and serves the files and directories whose names start with a dot, ex.
+	// `.env`, which are otherwise not found
+	ShowHidden bool
+
+	// Template renders the HTML listing, given a Listing. It defaults to a plain table whose column
+	// headers sort it.
+	Template *template.Template
+}
+
+// Listing is a directory listed by Static, rendered as HTML or sent as JSON to clients accepting
+// application/json
+type Listing struct {
+	// Path is the url path of the directory, ending with a slash
+	Path    string         `json:"path"`
+	Entries []ListingEntry `json:"entries"`
+
+	// Sort is the column the entries are sorted by, `name`, `size` or `modified`, set with the
+	// `sort` query param. Desc is set by `order=desc`. Directories are listed first.
+	Sort string `json:"sort"`
+	Desc bool   `json:"desc,omitempty"`
+}
+
+// ListingEntry is a file or directory of a Listing
+type ListingEntry struct {
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	Dir      bool      `json:"dir,omitempty"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// WithListing lists the directories of Static, which are otherwise answered with a 404
+//
+//	rr.Static("/share", os.DirFS("/srv/share"), router.WithListing(router.ListingOptions{}))
+func WithListing(opts ListingOptions) StaticOption {
+	return func(o *staticOptions) {
+		o.listing = &opts
+	}
+}
+
+// SortURL is the url of the listing sorted by the column, reversing the order when it's already
+// sorted by it
+func (l Listing) SortURL(column string) string {
+	q := url.Values{"sort": {column}}
+	if l.Sort == column && !l.Desc {
+		q.Set("order", "desc")
+	}
+	return l.Path + "?" + q.Encode()
+}
+
+func serveListing(w http.ResponseWriter, req *http.Request, fsys fs.FS, name string, opts ListingOptions) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		Fail(req, err)
+		return
+	}
+
+	listing := Listing{
+		Path: strings.TrimSuffix(req.URL.Path, "/") + "/",
+		Sort: req.URL.Query().Get("sort"),
+		Desc: req.URL.Query().Get("order") == "desc",
+	}
+	switch listing.Sort {
+	case "name", "size", "modified":
+	default:
+		listing.Sort = "name"
+	}
+	listing.Entries = make([]ListingEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !opts.ShowHidden && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		le := ListingEntry{
+			Name:     entry.Name(),
+			URL:      path.Join(listing.Path, url.PathEscape(entry.Name())),
+			Dir:      entry.IsDir(),
+			Modified: info.ModTime(),
+		}
+		if le.Dir {
+			le.URL += "/"
+		} else {
+			le.Size = info.Size()
+		}
+		listing.Entries = append(listing.Entries, le)
+	}
+	sortListing(listing)
+
+	if negotiate(req.Header.Get("Accept"), []string{"text/html", "application/json"}) == "application/json" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(listing)
+		return
+	}
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = listingHTML
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, listing); err != nil {
+		Fail(req, err)
+	}
+}
+
+// sortListing sorts the entries by the listing's column, directories first
+func sortListing(l Listing) {
+	sort.SliceStable(l.Entries, func(i, j int) bool {
+		a, b := l.Entries[i], l.Entries[j]
+		if a.Dir != b.Dir {
+			return a.Dir
+		}
+		if l.Desc {
+			a, b = b, a
+		}
+		switch l.Sort {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "modified":
+			if !a.Modified.Equal(b.Modified) {
+				return a.Modified.Before(b.Modified)
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+var listingHTML = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Path}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: .3em .8em; border-bottom: 1px solid #ddd; }
+td { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>{{.Path}}</h1>
+<table>
+<tr><th><a href="{{.SortURL "name"}}">Name</a></th><th><a href="{{.SortURL "size"}}">Size</a></th><th><a href="{{.SortURL "modified"}}">Modified</a></th></tr>
+{{- range .Entries}}
+<tr><td><a href="{{.URL}}">{{.Name}}{{if .Dir}}/{{end}}</a></td><td>{{if not .Dir}}{{.Size}}{{end}}</td><td>{{.Modified.Format "2006-01-02 15:04"}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
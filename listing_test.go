@@ -0,0 +1,119 @@
+package router
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func listingFS() fstest.MapFS {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return fstest.MapFS{
+		"b.txt":          {Data: []byte("bb"), ModTime: t0},
+		"a.txt":          {Data: []byte("aaaa"), ModTime: t0.Add(time.Hour)},
+		"c.txt":          {Data: []byte("c"), ModTime: t0.Add(-time.Hour)},
+		".env":           {Data: []byte("secret"), ModTime: t0},
+		"docs/guide.txt": {Data: []byte("guide"), ModTime: t0},
+	}
+}
+
+func getListing(t *testing.T, rr Router, path string) Listing {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	var listing Listing
+	if err := json.Unmarshal(rec.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("%s: %d %v %s", path, rec.Code, err, rec.Body.String())
+	}
+	return listing
+}
+
+func listingNames(l Listing) string {
+	names := make([]string, len(l.Entries))
+	for i, e := range l.Entries {
+		names[i] = e.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestListing(t *testing.T) {
+	rr := New("/")
+	rr.Static("/share", listingFS(), WithListing(ListingOptions{}))
+
+	tests := []struct {
+		path  string
+		names string
+	}{
+		{"/share", "docs,a.txt,b.txt,c.txt"},
+		{"/share?order=desc", "docs,c.txt,b.txt,a.txt"},
+		{"/share?sort=size", "docs,c.txt,b.txt,a.txt"},
+		{"/share?sort=size&order=desc", "docs,a.txt,b.txt,c.txt"},
+		{"/share?sort=modified", "docs,c.txt,b.txt,a.txt"},
+		{"/share/docs", "guide.txt"},
+	}
+	for _, test := range tests {
+		if names := listingNames(getListing(t, rr, test.path)); names != test.names {
+			t.Errorf("%s: invalid listing %s", test.path, names)
+		}
+	}
+
+	listing := getListing(t, rr, "/share/")
+	if listing.Path != "/share/" || listing.Entries[0].URL != "/share/docs/" || listing.Entries[1].URL != "/share/a.txt" || listing.Entries[1].Size != 4 {
+		t.Errorf("invalid listing %+v", listing)
+	}
+
+	// the default is an HTML table whose headers sort it
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/share/docs", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<a href="/share/docs/guide.txt">guide.txt</a>`) || !strings.Contains(body, `href="/share/docs/?sort=size"`) {
+		t.Errorf("invalid html %s", body)
+	}
+}
+
+func TestListingOptions(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`{{range .Entries}}{{.Name}};{{end}}`))
+	rr := New("/")
+	rr.Static("/share", listingFS(), WithListing(ListingOptions{ShowHidden: true, Template: tmpl}))
+	rr.Static("/files", listingFS())
+
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/share", nil))
+	if body := rec.Body.String(); body != "docs;.env;a.txt;b.txt;c.txt;" {
+		t.Errorf("invalid listing %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/files/docs", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("listing should be opt-in, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/share/.env", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "secret" {
+		t.Errorf("shown hidden files should be served, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStaticHidden(t *testing.T) {
+	fsys := listingFS()
+	fsys[".git/config"] = &fstest.MapFile{Data: []byte("[core]")}
+	rr := New("/")
+	rr.Static("/share", fsys, WithListing(ListingOptions{}))
+	rr.Static("/files", fsys)
+
+	for _, path := range []string{"/share/.env", "/files/.env", "/files/.git/config", "/files/docs/../.env"} {
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: hidden files shouldn't be served, got %d", path, rec.Code)
+		}
+	}
+}
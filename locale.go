@@ -0,0 +1,102 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var pathLocaleCtxKey = NewKey[string]("pathlocale")
+
+// LocaleOptions configures the locale prefixes routes are served under
+type LocaleOptions struct {
+	// Locales are the locales whose routes are served under a prefix of their tag, ex. `/fr/users`
+	Locales []string
+
+	// Default is the locale of requests without a prefix, and whose urls are built without one
+	Default string
+}
+
+// LocalePrefixes serves every route both as registered and under a prefix for each of the locales,
+// ex. `/users` as `/fr/users` and `/fr-CA/users`, matching the prefix regardless of its case. The
+// prefix is removed before the routes are matched, leaving its locale to Locale and PathLocale.
+// Requests without a prefix have the default locale. URLForLocale builds the urls of the locales.
+//
+//	rr.LocalePrefixes(router.LocaleOptions{Locales: []string{"fr", "de"}, Default: "en"})
+func (r *Router) LocalePrefixes(opts LocaleOptions) {
+	for _, locale := range opts.Locales {
+		if locale == "" || strings.Contains(locale, "/") {
+			panic(fmt.Sprintf("router: invalid locale %q", locale))
+		}
+	}
+	r.locales = &opts
+}
+
+// PathLocale retrieves the locale given by the request's url prefix, empty when the request had no
+// prefix. Middleware negotiating the locale can use it to prefer the url over the request's headers.
+func PathLocale(c context.Context) string {
+	locale, _ := Get(c, pathLocaleCtxKey)
+	return locale
+}
+
+// URLForLocale builds the path of the named route under the locale's prefix. The default locale's
+// urls have no prefix.
+//
+//	rr.URLForLocale("fr", "user", "id", "123") // => /fr/users/123
+//	rr.URLForLocale(router.Locale(r.Context()), "user", "id", "123")
+func (r Router) URLForLocale(locale, name string, params ...string) (string, error) {
+	path, err := r.URLFor(name, params...)
+	if err != nil {
+		return "", err
+	}
+	opts := r.locales
+	if reloaded := r.reloaded(); reloaded != nil {
+		opts = reloaded.locales
+	}
+	if opts == nil {
+		return "", fmt.Errorf("router: url for %q: locale prefixes aren't enabled", name)
+	}
+	if locale == "" || strings.EqualFold(locale, opts.Default) {
+		return path, nil
+	}
+	for _, l := range opts.Locales {
+		if strings.EqualFold(l, locale) {
+			return "/" + l + path, nil
+		}
+	}
+	return "", fmt.Errorf("router: url for %q: unsupported locale %q", name, locale)
+}
+
+// routeLocale removes the locale prefix from the request's path, returning a copy of the request
+// with its locale
+func (r Router) routeLocale(req *http.Request) *http.Request {
+	opts := r.locales
+	segment := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+	for _, locale := range opts.Locales {
+		if !strings.EqualFold(segment, locale) {
+			continue
+		}
+		c := Set(WithLocale(req.Context(), locale), pathLocaleCtxKey, locale)
+		req = req.WithContext(c)
+		u := *req.URL
+		u.Path = "/" + strings.TrimPrefix(u.Path[len(segment)+1:], "/")
+		if u.RawPath != "" {
+			u.RawPath = "/" + strings.TrimPrefix(u.RawPath[len(segment)+1:], "/")
+		}
+		req.URL = &u
+		return req
+	}
+	if opts.Default != "" {
+		req = req.WithContext(WithLocale(req.Context(), opts.Default))
+	}
+	return req
+}
+
+// localizePath adds the request's locale prefix to the path the router redirects it to
+func localizePath(req *http.Request, path string) string {
+	if locale := PathLocale(req.Context()); locale != "" && strings.HasPrefix(path, "/") {
+		return "/" + locale + path
+	}
+	return path
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocalePrefixes(t *testing.T) {
+	rr := New("/")
+	rr.LocalePrefixes(LocaleOptions{Locales: []string{"fr", "fr-CA"}, Default: "en"})
+	rr.RedirectRoutes(map[string]string{"/about-us": "/about"})
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home " + Locale(r.Context())))
+	})
+	rr.Get("/about", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("about " + Locale(r.Context())))
+	})
+	api := rr.SubRouter("/api")
+	api.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Param(r.Context(), "id") + " " + Locale(r.Context()) + " " + PathLocale(r.Context()) + " " + RoutePattern(r.Context())))
+	}).Name("user")
+
+	tests := []struct {
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{"/", 200, "home en", ""},
+		{"/fr", 200, "home fr", ""},
+		{"/fr/", 200, "home fr", ""},
+		{"/FR-ca/about", 200, "about fr-CA", ""},
+		{"/api/users/1", 200, "1 en  /api/users/:id", ""},
+		{"/fr/api/users/1", 200, "1 fr fr /api/users/:id", ""},
+		{"/de/about", 404, "", ""},
+		{"/fr/about-us?x=1", 301, "", "/fr/about?x=1"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.path, rec.Code)
+			continue
+		}
+		if test.status == 200 && rec.Body.String() != test.body {
+			t.Errorf("%s: invalid body %q", test.path, rec.Body.String())
+		}
+		if loc := rec.Header().Get("Location"); loc != test.location {
+			t.Errorf("%s: invalid location %q", test.path, loc)
+		}
+		if req.URL.Path != strings.Split(test.path, "?")[0] {
+			t.Errorf("%s: the caller's request should be left untouched, got %s", test.path, req.URL.Path)
+		}
+	}
+
+	urls := []struct {
+		locale string
+		url    string
+	}{
+		{"", "/api/users/5"},
+		{"en", "/api/users/5"},
+		{"fr", "/fr/api/users/5"},
+		{"fr-ca", "/fr-CA/api/users/5"},
+	}
+	for _, test := range urls {
+		if url, err := rr.URLForLocale(test.locale, "user", "id", "5"); err != nil || url != test.url {
+			t.Errorf("%q: invalid url %s %v", test.locale, url, err)
+		}
+	}
+	if _, err := rr.URLForLocale("de", "user", "id", "5"); err == nil {
+		t.Error("unsupported locales should fail")
+	}
+	if !strings.HasPrefix(rr.Snapshot(), "config slash=ignore case=sensitive autohead=true locales=fr,fr-CA default=en\n") {
+		t.Errorf("snapshot should include the locales\n%s", rr.Snapshot())
+	}
+}
+
+func TestLocalePrefixesRedirects(t *testing.T) {
+	rr := New("/")
+	rr.LocalePrefixes(LocaleOptions{Locales: []string{"fr"}})
+	rr.TrailingSlash(RedirectTrailingSlash)
+	rr.PathCase(RedirectCase)
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	for path, location := range map[string]string{"/fr/users/": "/fr/users", "/fr/Users": "/fr/users"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != location {
+			t.Errorf("%s: invalid redirect %d %q", path, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	if _, err := New("/").URLForLocale("fr", "users"); err == nil {
+		t.Error("urls for locales should fail without locale prefixes")
+	}
+}
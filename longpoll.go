@@ -0,0 +1,90 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrSourceClosed is returned by an EventSource that will deliver no more events
+var ErrSourceClosed = errors.New("router: event source closed")
+
+// EventSource delivers the next event of a long-poll, blocking until an event is available or the
+// context is done
+type EventSource interface {
+	Next(c context.Context) ([]byte, error)
+}
+
+// ChanSource delivers the events sent on the channel
+type ChanSource <-chan []byte
+
+// Next waits for the next event on the channel
+func (s ChanSource) Next(c context.Context) ([]byte, error) {
+	select {
+	case event, ok := <-s:
+		if !ok {
+			return nil, ErrSourceClosed
+		}
+		return event, nil
+	case <-c.Done():
+		return nil, c.Err()
+	}
+}
+
+// Bus publishes events to the subscribers of a topic. The subscription ends when cancel is called.
+type Bus interface {
+	Subscribe(c context.Context, topic string) (events <-chan []byte, cancel func())
+}
+
+// BusSource delivers the next event published to the topic
+func BusSource(bus Bus, topic string) EventSource {
+	return busSource{bus: bus, topic: topic}
+}
+
+type busSource struct {
+	bus   Bus
+	topic string
+}
+
+func (s busSource) Next(c context.Context) ([]byte, error) {
+	events, cancel := s.bus.Subscribe(c, s.topic)
+	defer cancel()
+	return ChanSource(events).Next(c)
+}
+
+// LongPoll holds the request open until the source delivers an event, writing the event as the
+// response, or until the timeout or a Drainer's shutdown, responding with a 204. Nothing is written if the client
+// disconnects, and the request's context error is returned instead. Source errors are returned
+// without writing a response.
+//
+//	rr.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("Content-Type", "application/json")
+//		if err := router.LongPoll(w, r, router.BusSource(bus, "orders"), 30*time.Second); err != nil {
+//			router.Fail(r, err)
+//		}
+//	})
+func LongPoll(w http.ResponseWriter, r *http.Request, source EventSource, timeout time.Duration) error {
+	c, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// draining ends the poll early, sending the client away to poll another server
+	LongLived(r, cancel)
+
+	event, err := source.Next(c)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return r.Context().Err()
+		}
+		if c.Err() != nil {
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	_, err = w.Write(event)
+	return err
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testBus struct {
+	subscribed chan string
+	events     chan []byte
+	cancelled  chan struct{}
+}
+
+func (b *testBus) Subscribe(c context.Context, topic string) (<-chan []byte, func()) {
+	b.subscribed <- topic
+	return b.events, func() { close(b.cancelled) }
+}
+
+func TestLongPoll(t *testing.T) {
+	errFailed := errors.New("failed")
+	closed := make(chan []byte)
+	close(closed)
+
+	tests := []struct {
+		desc   string
+		source func() EventSource
+		status int
+		body   string
+		err    error
+	}{
+		{"event", func() EventSource {
+			events := make(chan []byte, 1)
+			events <- []byte(`{"id":1}`)
+			return ChanSource(events)
+		}, 200, `{"id":1}`, nil},
+		{"timeout", func() EventSource {
+			return ChanSource(make(chan []byte))
+		}, 204, "", nil},
+		{"closed", func() EventSource {
+			return ChanSource(closed)
+		}, 200, "", ErrSourceClosed},
+		{"failed", func() EventSource {
+			return sourceFunc(func(c context.Context) ([]byte, error) { return nil, errFailed })
+		}, 200, "", errFailed},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/events", nil)
+		rec := httptest.NewRecorder()
+		err := LongPoll(rec, req, test.source(), 10*time.Millisecond)
+
+		if err != test.err {
+			t.Errorf("%s: invalid error %v", test.desc, err)
+		}
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d != %d", test.desc, rec.Code, test.status)
+		}
+		if rec.Body.String() != test.body {
+			t.Errorf("%s: invalid body %q != %q", test.desc, rec.Body.String(), test.body)
+		}
+	}
+}
+
+func TestLongPollDisconnect(t *testing.T) {
+	bus := &testBus{subscribed: make(chan string, 1), events: make(chan []byte), cancelled: make(chan struct{})}
+	c, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "/events", nil)
+	req = req.WithContext(c)
+	rec := httptest.NewRecorder()
+
+	done := make(chan error)
+	go func() {
+		done <- LongPoll(rec, req, BusSource(bus, "orders"), time.Minute)
+	}()
+	if topic := <-bus.subscribed; topic != "orders" {
+		t.Errorf("invalid topic %q", topic)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("invalid error %v", err)
+	}
+	<-bus.cancelled
+	if len(rec.Header()) != 0 || rec.Body.Len() != 0 {
+		t.Error("nothing should be written to a disconnected client")
+	}
+}
+
+type sourceFunc func(c context.Context) ([]byte, error)
+
+func (fn sourceFunc) Next(c context.Context) ([]byte, error) {
+	return fn(c)
+}
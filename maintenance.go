@@ -0,0 +1,103 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// maintenance is the maintenance mode shared by a router and its subrouters
+type maintenance struct {
+	// mode holds the current maintenanceMode, swapped atomically by SetMaintenance
+	mode atomic.Value
+
+	mu      sync.RWMutex
+	allowed []string
+}
+
+type maintenanceMode struct {
+	enabled bool
+	h       http.HandlerFunc
+}
+
+// SetMaintenance switches the router in or out of maintenance mode while it's serving. In
+// maintenance, requests are answered with a 503 by h, or the router's error page if h is nil,
+// except for the health endpoints and the paths allowed by AllowDuringMaintenance.
+//
+//	rr.AllowDuringMaintenance("/admin")
+//	rr.SetMaintenance(true, func(w http.ResponseWriter, r *http.Request) {
+//		w.Write(maintenancePage)
+//	})
+func (r Router) SetMaintenance(enabled bool, h http.HandlerFunc) {
+	r.maintenance.mode.Store(maintenanceMode{enabled: enabled, h: h})
+}
+
+// InMaintenance reports whether the router is in maintenance mode
+func (r Router) InMaintenance() bool {
+	mode, _ := r.maintenance.mode.Load().(maintenanceMode)
+	return mode.enabled
+}
+
+// AllowDuringMaintenance keeps serving the paths, relative to the router's base path, while in
+// maintenance mode. A path also allows the paths below it, so `/admin` allows `/admin/users`.
+func (r Router) AllowDuringMaintenance(paths ...string) {
+	r.maintenance.mu.Lock()
+	defer r.maintenance.mu.Unlock()
+	for _, path := range paths {
+		r.maintenance.allowed = append(r.maintenance.allowed, r.fullPath(path))
+	}
+}
+
+// allows reports whether the path is served while in maintenance mode
+func (m *maintenance) allows(path string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, allowed := range m.allowed {
+		if path == allowed || strings.HasPrefix(path, strings.TrimSuffix(allowed, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// refuseMaintenance answers requests with the maintenance page while in maintenance mode,
+// reporting whether it did
+func (r Router) refuseMaintenance(w http.ResponseWriter, req *http.Request) bool {
+	mode, _ := r.maintenance.mode.Load().(maintenanceMode)
+	if !mode.enabled || r.health.paths[req.URL.Path] || r.maintenance.allows(req.URL.Path) {
+		return false
+	}
+	w.Header().Set("Retry-After", "60")
+	if mode.h == nil {
+		r.renderError(r.findMatchingRouter(req.URL.Path), w, req, http.StatusServiceUnavailable, nil)
+		return true
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	mode.h(wrap.Writer(&maintenanceWriter{ResponseWriter: w}, w), req)
+	return true
+}
+
+// maintenanceWriter sends the maintenance page with a 503, whichever status the handler writes
+type maintenanceWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (mw *maintenanceWriter) WriteHeader(status int) {
+	if status < http.StatusOK {
+		mw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if !mw.wroteHeader {
+		mw.wroteHeader = true
+		mw.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func (mw *maintenanceWriter) Write(b []byte) (int, error) {
+	mw.WriteHeader(http.StatusServiceUnavailable)
+	return mw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMaintenance(t *testing.T) {
+	rr := New("/")
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Health(HealthOptions{})
+	admin := rr.SubRouter("/admin")
+	admin.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	admin.AllowDuringMaintenance("/")
+	rr.Get("/administrators", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("/users"); rec.Code != http.StatusOK || rr.InMaintenance() {
+		t.Errorf("should serve before maintenance, got %d", rec.Code)
+	}
+
+	rr.SetMaintenance(true, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("back soon"))
+	})
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/users", http.StatusServiceUnavailable},
+		{"/administrators", http.StatusServiceUnavailable},
+		{"/admin/users", http.StatusOK},
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusOK},
+	}
+	for _, test := range tests {
+		rec := serve(test.path)
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.path, rec.Code)
+		}
+		if maintenance := rec.Body.String() == "back soon"; maintenance != (test.status == http.StatusServiceUnavailable) {
+			t.Errorf("%s: invalid body %q", test.path, rec.Body.String())
+		}
+	}
+	if !rr.InMaintenance() {
+		t.Error("should be in maintenance")
+	}
+
+	rr.SetMaintenance(true, nil)
+	if rec := serve("/users"); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("default page: invalid status %d", rec.Code)
+	}
+
+	rr.SetMaintenance(false, nil)
+	if rec := serve("/users"); rec.Code != http.StatusOK {
+		t.Errorf("should serve after maintenance, got %d", rec.Code)
+	}
+}
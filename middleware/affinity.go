@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+// AffinityCookie is the name of the cookie set by ReadYourWrites
+const AffinityCookie = "rw_affinity"
+
+var recentWriteCtxKey = router.NewKey[bool]("recentwrite")
+
+// ReadYourWrites marks clients that have recently made a mutating request (POST, PUT, PATCH or
+// DELETE) with a short-lived cookie. Requests made by those clients within the duration report
+// RecentlyWrote, allowing handlers to read from the primary database rather than a replica that
+// may not yet have the write.
+func ReadYourWrites(d time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recent := false
+		if c, err := r.Cookie(AffinityCookie); err == nil {
+			if until, err := strconv.ParseInt(c.Value, 10, 64); err == nil {
+				recent = now().Unix() < until
+			}
+		}
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			MarkWrite(w, r, d)
+			recent = true
+		}
+		router.BindContext(router.Set(r.Context(), recentWriteCtxKey, recent), r)
+	}
+}
+
+// MarkWrite sets the affinity cookie for writes made by requests that ReadYourWrites doesn't treat
+// as mutating, ex. a GET that records a visit. It must be called before the response is written.
+func MarkWrite(w http.ResponseWriter, r *http.Request, d time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     AffinityCookie,
+		Value:    strconv.FormatInt(now().Add(d).Unix(), 10),
+		Path:     "/",
+		MaxAge:   int(d.Seconds() + 0.5),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// RecentlyWrote reports whether the client made a mutating request within the ReadYourWrites
+// duration, including the current request
+func RecentlyWrote(c context.Context) bool {
+	recent, _ := router.Get(c, recentWriteCtxKey)
+	return recent
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	start := time.Unix(1000, 0)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	rr := router.New("/")
+	rr.Before(ReadYourWrites(5 * time.Second))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if RecentlyWrote(r.Context()) {
+			w.Write([]byte("primary"))
+		} else {
+			w.Write([]byte("replica"))
+		}
+	}
+	rr.Get("/users", handler)
+	rr.Post("/users", handler)
+
+	send := func(method string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/users", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("GET", nil); rec.Body.String() != "replica" || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("read without a write should use the replica: %s", rec.Body.String())
+	}
+
+	rec := send("POST", nil)
+	cookies := rec.Result().Cookies()
+	if rec.Body.String() != "primary" || len(cookies) != 1 || cookies[0].MaxAge != 5 {
+		t.Errorf("write should set the affinity cookie: %s %v", rec.Body.String(), cookies)
+		return
+	}
+
+	now = func() time.Time { return start.Add(4 * time.Second) }
+	if rec := send("GET", cookies); rec.Body.String() != "primary" {
+		t.Errorf("read after a write should use the primary: %s", rec.Body.String())
+	}
+
+	now = func() time.Time { return start.Add(5 * time.Second) }
+	if rec := send("GET", cookies); rec.Body.String() != "replica" {
+		t.Errorf("expired affinity should use the replica: %s", rec.Body.String())
+	}
+}
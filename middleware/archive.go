@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// ArchivedResponse is a copy of a response exactly as it was sent, with the request it answered
+type ArchivedResponse struct {
+	Time      time.Time
+	Method    string
+	URL       string
+	Pattern   string
+	ClientIP  string
+	Principal string
+	Status    int
+	Header    http.Header
+	Body      []byte
+}
+
+// ArchiveStore stores archived responses, ex. within an object store
+type ArchiveStore interface {
+	Archive(c context.Context, res ArchivedResponse) error
+}
+
+// Archive is a policy teeing the responses of its routes, such as generated invoices or legal
+// documents, to a store for compliance. Responses are stored asynchronously once sent, so the store
+// doesn't delay them, and failures are logged unless OnError is set. It panics without a store.
+//
+//	rr.Get("/invoices/:id", invoice).Formats("pdf").Policy(middleware.Archive{Store: bucket})
+type Archive struct {
+	Store ArchiveStore
+
+	// Principal identifies the user the response was sent to, ex. their account id
+	Principal func(r *http.Request) string
+
+	// Statuses limits the archived responses, only 2xx responses being archived by default
+	Statuses func(status int) bool
+
+	// OnError is called when a response can't be stored
+	OnError func(err error, res ArchivedResponse)
+}
+
+// Middleware implements router.Policy
+func (a Archive) Middleware() func(http.Handler) http.Handler {
+	if a.Store == nil {
+		panic("middleware: Archive requires a store")
+	}
+	if a.Statuses == nil {
+		a.Statuses = func(status int) bool { return status >= 200 && status < 300 }
+	}
+	if a.OnError == nil {
+		a.OnError = func(err error, res ArchivedResponse) {
+			log.Printf("middleware: archiving %s %s failed: %v", res.Method, res.URL, err)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tw := &teeWriter{ResponseWriter: w}
+			next.ServeHTTP(wrap.Writer(tw, w), r)
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+				tw.header = w.Header().Clone()
+			}
+			if !a.Statuses(tw.status) {
+				return
+			}
+
+			res := ArchivedResponse{
+				Time:      now(),
+				Method:    r.Method,
+				URL:       r.URL.String(),
+				Pattern:   router.RoutePattern(r.Context()),
+				ClientIP:  remoteAddr(r),
+				Principal: principalOf(a.Principal, r),
+				Status:    tw.status,
+				Header:    tw.header,
+				Body:      tw.body.Bytes(),
+			}
+			go func() {
+				if err := a.Store.Archive(context.Background(), res); err != nil {
+					a.OnError(err, res)
+				}
+			}()
+		})
+	}
+}
+
+func (a Archive) String() string {
+	return "archive"
+}
+
+// teeWriter copies the response as it's written
+type teeWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (tw *teeWriter) WriteHeader(status int) {
+	// informational responses, ex. early hints, precede the final status
+	if tw.status == 0 && status >= http.StatusOK {
+		tw.status = status
+		tw.header = tw.Header().Clone()
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *teeWriter) Write(b []byte) (int, error) {
+	if tw.status == 0 {
+		tw.WriteHeader(http.StatusOK)
+	}
+	n, err := tw.ResponseWriter.Write(b)
+	tw.body.Write(b[:n])
+	return n, err
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+type testArchiveStore struct {
+	archived chan ArchivedResponse
+	err      error
+}
+
+func (s *testArchiveStore) Archive(c context.Context, res ArchivedResponse) error {
+	s.archived <- res
+	return s.err
+}
+
+func TestArchive(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	store := &testArchiveStore{archived: make(chan ArchivedResponse, 1)}
+	archive := Archive{
+		Store:     store,
+		Principal: func(r *http.Request) string { return r.Header.Get("X-Account") },
+	}
+	rr := router.New("/")
+	rr.Get("/invoices/:id", func(w http.ResponseWriter, r *http.Request) {
+		if router.Param(r.Context(), "id") == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-"))
+		w.Write([]byte("invoice"))
+	}).Policy(archive)
+
+	r, _ := http.NewRequest("GET", "/invoices/7?download=1", nil)
+	r.RemoteAddr = "1.1.1.1:1000"
+	r.Header.Set("X-Account", "acct_1")
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+
+	if w.Body.String() != "%PDF-invoice" {
+		t.Errorf("invalid response %q", w.Body.String())
+	}
+	select {
+	case res := <-store.archived:
+		if res.Method != "GET" || res.URL != "/invoices/7?download=1" || res.Pattern != "/invoices/:id" {
+			t.Errorf("invalid request metadata %+v", res)
+		}
+		if res.ClientIP != "1.1.1.1" || res.Principal != "acct_1" || !res.Time.Equal(start) {
+			t.Errorf("invalid metadata %+v", res)
+		}
+		if res.Status != 200 || res.Header.Get("Content-Type") != "application/pdf" || string(res.Body) != "%PDF-invoice" {
+			t.Errorf("invalid archived response %d %v %q", res.Status, res.Header, res.Body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("response not archived")
+	}
+
+	// only successful responses are archived by default
+	r, _ = http.NewRequest("GET", "/invoices/missing", nil)
+	rr.ServeHTTP(httptest.NewRecorder(), r)
+	select {
+	case res := <-store.archived:
+		t.Errorf("%d response archived", res.Status)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestArchiveError(t *testing.T) {
+	failed := make(chan error, 1)
+	store := &testArchiveStore{archived: make(chan ArchivedResponse, 1), err: errors.New("bucket unavailable")}
+	h := Archive{Store: store, OnError: func(err error, res ArchivedResponse) {
+		failed <- err
+	}}.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r, _ := http.NewRequest("GET", "/terms", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != 200 {
+		t.Errorf("invalid status %d", w.Code)
+	}
+	select {
+	case err := <-failed:
+		if err != store.err {
+			t.Errorf("invalid error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("error not reported")
+	}
+}
+
+func TestArchiveNoStore(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a missing store should panic when the middleware is built")
+		}
+	}()
+	router.New("/").Get("/invoices/:id", func(w http.ResponseWriter, r *http.Request) {}).Policy(Archive{})
+}
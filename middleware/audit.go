@@ -0,0 +1,322 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// Redacted replaces the values of the redacted fields within audited bodies
+const Redacted = "[REDACTED]"
+
+// AuditEvent is a request and its response, as recorded by Audit. Bodies are only captured for
+// the audited content types, and are cut at the size cap.
+type AuditEvent struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	URL       string        `json:"url"`
+	Pattern   string        `json:"pattern"`
+	ClientIP  string        `json:"client_ip"`
+	Principal string        `json:"principal,omitempty"`
+	Status    int           `json:"status"`
+	Duration  time.Duration `json:"duration"`
+
+	RequestHeader     http.Header `json:"request_header"`
+	RequestBody       string      `json:"request_body,omitempty"`
+	RequestTruncated  bool        `json:"request_truncated,omitempty"`
+	ResponseHeader    http.Header `json:"response_header"`
+	ResponseBody      string      `json:"response_body,omitempty"`
+	ResponseTruncated bool        `json:"response_truncated,omitempty"`
+}
+
+// AuditSink receives the audit events, ex. to write them to an append-only store
+type AuditSink interface {
+	Audit(c context.Context, event AuditEvent) error
+}
+
+// AuditOptions configures Audit
+type AuditOptions struct {
+	Sink AuditSink
+
+	// MaxBodyBytes caps the captured size of each body, 64KB by default
+	MaxBodyBytes int
+
+	// ContentTypes are the media types whose bodies are captured, with those ending in a slash
+	// matching all of their subtypes. JSON, forms and text are captured by default.
+	ContentTypes []string
+
+	// Redact are the fields whose values are replaced within JSON and form bodies, at any depth and
+	// regardless of case. Passwords, tokens and secrets are redacted by default.
+	Redact []string
+
+	// RedactHeaders are the request and response headers whose values are replaced, the
+	// Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key headers by default
+	RedactHeaders []string
+
+	// RedactQuery are the query params of the url whose values are replaced, regardless of case.
+	// Tokens, keys and signatures are redacted by default.
+	RedactQuery []string
+
+	// RedactEvent is called last, before the event is sent to the sink, to redact anything the
+	// other options don't cover
+	RedactEvent func(event *AuditEvent)
+
+	// Principal identifies the user making the request, ex. their account id
+	Principal func(r *http.Request) string
+
+	// OnError is called when an event can't be sent to the sink
+	OnError func(err error, event AuditEvent)
+}
+
+var defaultAuditContentTypes = []string{"application/json", "application/x-www-form-urlencoded", "text/"}
+
+var defaultRedactedFields = []string{"password", "token", "access_token", "refresh_token", "secret", "client_secret"}
+
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+var defaultRedactedQuery = []string{"token", "access_token", "api_key", "key", "signature", "sig"}
+
+// Audit records the requests and their responses, including their bodies, sending them to the sink
+// for compliance. Events are sent asynchronously once the response is complete, so the sink
+// doesn't delay it, and failures are logged unless OnError is set. The options also apply to a
+// single route as a policy. It panics without a sink.
+//
+//	rr.Use(middleware.Audit(middleware.AuditOptions{
+//		Sink:   auditLog,
+//		Redact: []string{"password", "ssn"},
+//	}))
+func Audit(opts AuditOptions) func(http.Handler) http.Handler {
+	return opts.Middleware()
+}
+
+// Middleware implements router.Policy
+func (opts AuditOptions) Middleware() func(http.Handler) http.Handler {
+	if opts.Sink == nil {
+		panic("middleware: Audit requires a sink")
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 64 << 10
+	}
+	if opts.ContentTypes == nil {
+		opts.ContentTypes = defaultAuditContentTypes
+	}
+	if opts.Redact == nil {
+		opts.Redact = defaultRedactedFields
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = defaultRedactedHeaders
+	}
+	if opts.RedactQuery == nil {
+		opts.RedactQuery = defaultRedactedQuery
+	}
+	redact := lowerSet(opts.Redact)
+	redactQuery := lowerSet(opts.RedactQuery)
+	if opts.OnError == nil {
+		opts.OnError = func(err error, event AuditEvent) {
+			log.Printf("middleware: auditing %s %s failed: %v", event.Method, event.URL, err)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := now()
+			var reqBody []byte
+			var reqTruncated bool
+			reqType := r.Header.Get("Content-Type")
+			if r.Body != nil && opts.audits(reqType) {
+				// the captured start of the body is put back ahead of the rest for the handler
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, int64(opts.MaxBodyBytes)+1))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+				if len(reqBody) > opts.MaxBodyBytes {
+					reqBody, reqTruncated = reqBody[:opts.MaxBodyBytes], true
+				}
+			}
+
+			aw := &auditWriter{ResponseWriter: w, max: opts.MaxBodyBytes}
+			next.ServeHTTP(wrap.Writer(aw, w), r)
+			if aw.status == 0 {
+				aw.status = http.StatusOK
+			}
+
+			event := AuditEvent{
+				Time:      start,
+				Method:    r.Method,
+				URL:       redactURL(r.URL, redactQuery),
+				Pattern:   router.RoutePattern(r.Context()),
+				ClientIP:  remoteAddr(r),
+				Principal: principalOf(opts.Principal, r),
+				Status:    aw.status,
+				Duration:  now().Sub(start),
+
+				RequestHeader:    redactHeader(r.Header, opts.RedactHeaders),
+				RequestBody:      redactBody(reqType, reqBody, reqTruncated, redact),
+				RequestTruncated: reqTruncated,
+				ResponseHeader:   redactHeader(w.Header(), opts.RedactHeaders),
+			}
+			if respType := w.Header().Get("Content-Type"); opts.audits(respType) {
+				event.ResponseBody = redactBody(respType, aw.body.Bytes(), aw.truncated, redact)
+				event.ResponseTruncated = aw.truncated
+			}
+			if opts.RedactEvent != nil {
+				opts.RedactEvent(&event)
+			}
+			go func() {
+				if err := opts.Sink.Audit(context.Background(), event); err != nil {
+					opts.OnError(err, event)
+				}
+			}()
+		})
+	}
+}
+
+func (opts AuditOptions) String() string {
+	return "audit"
+}
+
+// audits reports whether bodies of the content type are captured
+func (opts AuditOptions) audits(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range opts.ContentTypes {
+		if mediaType == t || strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// redactHeader copies the header, replacing the values of the redacted headers
+func redactHeader(header http.Header, redact []string) http.Header {
+	h := header.Clone()
+	for _, key := range redact {
+		if _, ok := h[http.CanonicalHeaderKey(key)]; ok {
+			h[http.CanonicalHeaderKey(key)] = []string{Redacted}
+		}
+	}
+	return h
+}
+
+// redactURL replaces the values of the redacted query params of the url
+func redactURL(u *url.URL, redact map[string]bool) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	query := u.Query()
+	redacted := false
+	for key := range query {
+		if redact[strings.ToLower(key)] {
+			query[key] = []string{Redacted}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	copied := *u
+	copied.RawQuery = query.Encode()
+	return copied.String()
+}
+
+// redactBody replaces the values of the redacted fields of JSON and form bodies. JSON bodies that
+// can't be parsed, such as those that were truncated, are left out rather than risk leaking the
+// fields.
+func redactBody(contentType string, body []byte, truncated bool, redact map[string]bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if truncated || json.Unmarshal(body, &v) != nil {
+			return Redacted
+		}
+		b, _ := json.Marshal(redactJSON(v, redact))
+		return string(b)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return Redacted
+		}
+		for key := range values {
+			if redact[strings.ToLower(key)] {
+				values[key] = []string{Redacted}
+			}
+		}
+		return values.Encode()
+	}
+	return string(body)
+}
+
+func redactJSON(v interface{}, redact map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = redactJSON(value, redact)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(value, redact)
+		}
+	}
+	return v
+}
+
+// auditWriter copies the start of the response, up to max bytes, as it's written
+type auditWriter struct {
+	http.ResponseWriter
+	status    int
+	max       int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (aw *auditWriter) WriteHeader(status int) {
+	if aw.status == 0 && status >= 200 {
+		aw.status = status
+	}
+	aw.ResponseWriter.WriteHeader(status)
+}
+
+func (aw *auditWriter) Write(b []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	n, err := aw.ResponseWriter.Write(b)
+	if room := aw.max - aw.body.Len(); room < n {
+		if room > 0 {
+			aw.body.Write(b[:room])
+		}
+		aw.truncated = true
+	} else {
+		aw.body.Write(b[:n])
+	}
+	return n, err
+}
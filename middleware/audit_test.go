@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+type testAuditSink struct {
+	events chan AuditEvent
+	err    error
+}
+
+func (s *testAuditSink) Audit(c context.Context, event AuditEvent) error {
+	s.events <- event
+	return s.err
+}
+
+func TestAudit(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	sink := &testAuditSink{events: make(chan AuditEvent, 1)}
+	rr := router.New("/")
+	rr.Use(Audit(AuditOptions{
+		Sink:         sink,
+		MaxBodyBytes: 100,
+		Redact:       []string{"password", "SSN"},
+		Principal:    func(r *http.Request) string { return r.Header.Get("X-Account") },
+	}))
+	rr.Post("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	rr.Get("/logo.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	})
+	rr.Get("/report", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("x", 60)))
+		w.Write([]byte(strings.Repeat("y", 60)))
+		w.Write([]byte("z"))
+	})
+
+	tests := []struct {
+		desc          string
+		method, path  string
+		contentType   string
+		body          string
+		requestBody   string
+		responseBody  string
+		responseTrunc bool
+		echoed        string
+	}{
+		{
+			"json",
+			"POST", "/users/1", "application/json",
+			`{"name":"bob","password":"hunter2","profile":{"ssn":"123"},"tags":[{"Password":"x"}]}`,
+			`{"name":"bob","password":"[REDACTED]","profile":{"ssn":"[REDACTED]"},"tags":[{"Password":"[REDACTED]"}]}`,
+			`{"name":"bob","password":"[REDACTED]","profile":{"ssn":"[REDACTED]"},"tags":[{"Password":"[REDACTED]"}]}`,
+			false,
+			`{"name":"bob","password":"hunter2","profile":{"ssn":"123"},"tags":[{"Password":"x"}]}`,
+		},
+		{
+			"form",
+			"POST", "/users/1", "application/x-www-form-urlencoded",
+			"name=bob&password=hunter2",
+			"name=bob&password=%5BREDACTED%5D",
+			Redacted, // echoed as invalid json
+			false,
+			"name=bob&password=hunter2",
+		},
+		{
+			"truncated json",
+			"POST", "/users/1", "application/json",
+			`{"password":"` + strings.Repeat("p", 100) + `"}`,
+			Redacted,
+			Redacted,
+			true,
+			`{"password":"` + strings.Repeat("p", 100) + `"}`,
+		},
+		{"binary", "GET", "/logo.png", "", "", "", "", false, "png"},
+		{"capped", "GET", "/report", "", "", "", strings.Repeat("x", 60) + strings.Repeat("y", 40), true, ""},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		req.Header.Set("X-Account", "acct-1")
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if test.echoed != "" && rec.Body.String() != test.echoed {
+			t.Errorf("%s: the handler should receive the whole body, got %q", test.desc, rec.Body.String())
+		}
+		event := <-sink.events
+		if event.RequestBody != test.requestBody {
+			t.Errorf("%s: invalid request body %q", test.desc, event.RequestBody)
+		}
+		if event.ResponseBody != test.responseBody || event.ResponseTruncated != test.responseTrunc {
+			t.Errorf("%s: invalid response body %q %v", test.desc, event.ResponseBody, event.ResponseTruncated)
+		}
+		if event.Method != test.method || event.Principal != "acct-1" || !event.Time.Equal(start) || event.Status != rec.Code {
+			t.Errorf("%s: invalid event %+v", test.desc, event)
+		}
+	}
+}
+
+func TestAuditPolicy(t *testing.T) {
+	sink := &testAuditSink{events: make(chan AuditEvent, 1), err: errors.New("sink down")}
+	failed := make(chan error, 1)
+	rr := router.New("/")
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {}).Policy(AuditOptions{
+		Sink:    sink,
+		OnError: func(err error, event AuditEvent) { failed <- err },
+	})
+
+	for _, path := range []string{"/", "/users/1"} {
+		rr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if event := <-sink.events; event.Pattern != "/users/:id" {
+		t.Errorf("invalid pattern %q", event.Pattern)
+	}
+	if err := <-failed; err == nil || err.Error() != "sink down" {
+		t.Errorf("invalid error %v", err)
+	}
+	select {
+	case event := <-sink.events:
+		t.Errorf("unaudited route was audited %+v", event)
+	default:
+	}
+}
+
+func TestAuditRedactsHeadersAndQuery(t *testing.T) {
+	sink := &testAuditSink{events: make(chan AuditEvent, 1)}
+	rr := router.New("/")
+	rr.Get("/download", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+	})
+	tests := []struct {
+		desc    string
+		opts    AuditOptions
+		url     string
+		header  map[string]string
+		check   map[string]string
+		resp    string
+		request string
+	}{
+		{
+			"defaults",
+			AuditOptions{Sink: sink},
+			"/download?file=a.pdf&Signature=xyz&token=t",
+			map[string]string{"Authorization": "Bearer secret", "Cookie": "session=abc", "Accept": "text/html"},
+			map[string]string{"Authorization": Redacted, "Cookie": Redacted, "Accept": "text/html"},
+			Redacted,
+			"/download?Signature=%5BREDACTED%5D&file=a.pdf&token=%5BREDACTED%5D",
+		},
+		{
+			"custom",
+			AuditOptions{
+				Sink:          sink,
+				RedactHeaders: []string{"x-tenant-key"},
+				RedactQuery:   []string{"file"},
+				RedactEvent:   func(e *AuditEvent) { e.ClientIP = "" },
+			},
+			"/download?file=a.pdf",
+			map[string]string{"X-Tenant-Key": "k", "Authorization": "Bearer secret"},
+			map[string]string{"X-Tenant-Key": Redacted, "Authorization": "Bearer secret"},
+			"session=abc",
+			"/download?file=%5BREDACTED%5D",
+		},
+	}
+	for _, test := range tests {
+		h := test.opts.Middleware()(rr)
+		req := httptest.NewRequest("GET", test.url, nil)
+		for k, v := range test.header {
+			req.Header.Set(k, v)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		event := <-sink.events
+		for k, v := range test.check {
+			if got := event.RequestHeader.Get(k); got != v {
+				t.Errorf("%s: invalid %s %q", test.desc, k, got)
+			}
+		}
+		if got := event.ResponseHeader.Get("Set-Cookie"); !strings.HasPrefix(got, test.resp) {
+			t.Errorf("%s: invalid set-cookie %q", test.desc, got)
+		}
+		if event.URL != test.request {
+			t.Errorf("%s: invalid url %q", test.desc, event.URL)
+		}
+		if test.opts.RedactEvent != nil && event.ClientIP != "" {
+			t.Errorf("%s: the event should be redacted by the hook", test.desc)
+		}
+		if req.Header.Get("Authorization") != test.header["Authorization"] {
+			t.Errorf("%s: the request's headers should not be changed", test.desc)
+		}
+	}
+}
+
+func TestAuditNoSink(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a missing sink should panic when the middleware is built")
+		}
+	}()
+	Audit(AuditOptions{})
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/chrisolsen/router"
 )
 
+var basicAuthCtxKey = router.NewKey[string]("basicauth")
+
 // BasicAuth performs the authentication using the passed in auth function
 func BasicAuth(auth func(c context.Context, name, password string) bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -41,5 +43,12 @@ func BasicAuth(auth func(c context.Context, name, password string) bool) http.Ha
 			router.HaltRequest(r)
 			return
 		}
+		router.BindContext(router.Set(r.Context(), basicAuthCtxKey, parts[0]), r)
 	}
 }
+
+// BasicAuthUser retrieves the name of the user authenticated by the BasicAuth middleware
+func BasicAuthUser(c context.Context) string {
+	name, _ := router.Get(c, basicAuthCtxKey)
+	return name
+}
@@ -99,4 +99,9 @@ func TestValidCredentials(t *testing.T) {
 		t.Error("Invalid response status: ", w.Code)
 		return
 	}
+
+	if BasicAuthUser(r.Context()) != "foo" {
+		t.Error("user not set in context")
+		return
+	}
 }
@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// CircuitState is the state of a circuit
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through while counting their failures
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen fails requests fast with a 503 until the cooldown has passed
+	CircuitOpen
+
+	// CircuitHalfOpen lets a few trial requests through, closing the circuit if they succeed and
+	// opening it again otherwise
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitBreakerOptions configures a CircuitBreaker
+type CircuitBreakerOptions struct {
+	// Key selects the circuit of the request, the matched route pattern by default. A constant key
+	// shares a single circuit between all the routes of the router.
+	Key func(r *http.Request) string
+
+	// Window is the period the failures are counted over, 10 seconds by default
+	Window time.Duration
+
+	// MinRequests is the number of requests within the window below which the circuit stays closed,
+	// 20 by default
+	MinRequests int
+
+	// ErrorRate is the share of the window's requests that must fail to open the circuit, 0.5 by
+	// default
+	ErrorRate float64
+
+	// Cooldown is how long the circuit stays open before letting trial requests through, 30 seconds
+	// by default
+	Cooldown time.Duration
+
+	// HalfOpenRequests is the number of trial requests that must succeed to close the circuit, 1 by
+	// default
+	HalfOpenRequests int
+
+	// IsFailure reports whether the response's status is a failure, a 5xx by default. Panics are
+	// always failures.
+	IsFailure func(status int) bool
+
+	// OnStateChange is called when a circuit changes state, ex. to alert on circuits opening. It's
+	// called outside of the breaker's lock, so it can publish the breaker's Stats.
+	OnStateChange func(key string, from, to CircuitState)
+}
+
+// CircuitStats are the counters of a circuit
+type CircuitStats struct {
+	Key      string `json:"key"`
+	State    string `json:"state"`
+	Requests int    `json:"requests"`
+	Failures int    `json:"failures"`
+
+	// Opened is the number of times the circuit opened, and OpenedAt the last time it did
+	Opened   int       `json:"opened"`
+	OpenedAt time.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker fails requests fast while the error rate of their circuit is too high, giving a failing
+// dependency time to recover rather than piling more requests onto it
+type Breaker struct {
+	opts CircuitBreakerOptions
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+
+	// changes are the state changes made while the lock is held, reported once it's released
+	changes []stateChange
+}
+
+type stateChange struct {
+	key      string
+	from, to CircuitState
+}
+
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	trials      int
+	successes   int
+	opened      int
+	openedAt    time.Time
+}
+
+// CircuitBreaker creates a breaker tracking the error rate of each route, whose middleware is
+// registered with Use so that the route is matched first
+//
+//	cb := middleware.CircuitBreaker(middleware.CircuitBreakerOptions{ErrorRate: 0.25})
+//	rr.Use(cb.Middleware)
+func CircuitBreaker(opts CircuitBreakerOptions) *Breaker {
+	if opts.Key == nil {
+		opts.Key = func(r *http.Request) string { return router.RoutePattern(r.Context()) }
+	}
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 20
+	}
+	if opts.ErrorRate <= 0 {
+		opts.ErrorRate = 0.5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(status int) bool { return status >= 500 }
+	}
+	return &Breaker{opts: opts, circuits: make(map[string]*circuit)}
+}
+
+// Middleware fails the requests of open circuits with a 503, and records the outcome of the others
+func (b *Breaker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := b.opts.Key(r)
+		if wait, ok := b.allow(key); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+0.999)))
+			router.Fail(r, router.NewError(http.StatusServiceUnavailable, "circuit_open", "service unavailable"))
+			return
+		}
+
+		sw := &wrap.StatusWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			// handlers that panic never complete, and count as failures, while the response of failed
+			// requests is only written once the middleware has returned
+			status := sw.Status
+			if err := router.RequestError(r.Context()); err != nil {
+				status = router.ErrorStatus(err)
+			}
+			b.record(key, !completed || b.opts.IsFailure(status))
+		}()
+		next.ServeHTTP(wrap.Writer(sw, w), r)
+		if sw.Status == 0 {
+			sw.Status = http.StatusOK
+		}
+		completed = true
+	})
+}
+
+// allow reports whether the request may go through, or how long until the circuit half-opens
+func (b *Breaker) allow(key string) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	c := b.circuit(key)
+
+	switch c.state {
+	case CircuitOpen:
+		wait := c.openedAt.Add(b.opts.Cooldown).Sub(now())
+		if wait > 0 {
+			return wait, false
+		}
+		b.setState(key, c, CircuitHalfOpen)
+		c.trials, c.successes = 0, 0
+		fallthrough
+	case CircuitHalfOpen:
+		if c.trials >= b.opts.HalfOpenRequests {
+			return time.Second, false
+		}
+		c.trials++
+	}
+	return 0, true
+}
+
+// record counts the outcome of a request, opening or closing its circuit
+func (b *Breaker) record(key string, failed bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	c := b.circuit(key)
+
+	switch c.state {
+	case CircuitHalfOpen:
+		if failed {
+			b.open(key, c)
+			return
+		}
+		c.successes++
+		if c.successes >= b.opts.HalfOpenRequests {
+			b.setState(key, c, CircuitClosed)
+			c.windowStart, c.requests, c.failures = now(), 0, 0
+		}
+	case CircuitClosed:
+		if t := now(); t.Sub(c.windowStart) >= b.opts.Window {
+			c.windowStart, c.requests, c.failures = t, 0, 0
+		}
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= b.opts.MinRequests && float64(c.failures) >= b.opts.ErrorRate*float64(c.requests) {
+			b.open(key, c)
+		}
+	}
+}
+
+func (b *Breaker) open(key string, c *circuit) {
+	b.setState(key, c, CircuitOpen)
+	c.opened++
+	c.openedAt = now()
+}
+
+// setState changes the state of the circuit, which must be done with the lock held
+func (b *Breaker) setState(key string, c *circuit, state CircuitState) {
+	from := c.state
+	c.state = state
+	if b.opts.OnStateChange != nil && from != state {
+		b.changes = append(b.changes, stateChange{key: key, from: from, to: state})
+	}
+}
+
+// unlock releases the lock, then reports the state changes made while it was held
+func (b *Breaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+	for _, change := range changes {
+		b.opts.OnStateChange(change.key, change.from, change.to)
+	}
+}
+
+func (b *Breaker) circuit(key string) *circuit {
+	c := b.circuits[key]
+	if c == nil {
+		c = &circuit{windowStart: now()}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// Stats returns a copy of the counters of the circuits, ordered by key
+func (b *Breaker) Stats() []CircuitStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := make([]CircuitStats, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		state := c.state
+		if state == CircuitOpen && !now().Before(c.openedAt.Add(b.opts.Cooldown)) {
+			state = CircuitHalfOpen
+		}
+		stats = append(stats, CircuitStats{
+			Key:      key,
+			State:    state.String(),
+			Requests: c.requests,
+			Failures: c.failures,
+			Opened:   c.opened,
+			OpenedAt: c.openedAt,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := t0
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	var changes []string
+	var cb *Breaker
+	cb = CircuitBreaker(CircuitBreakerOptions{
+		MinRequests: 4,
+		Cooldown:    time.Minute,
+		OnStateChange: func(key string, from, to CircuitState) {
+			// publishing the stats from the callback doesn't deadlock
+			cb.Stats()
+			changes = append(changes, key+" "+from.String()+">"+to.String())
+		},
+	})
+	failing, calls := false, 0
+	rr := router.New("/")
+	rr.Use(cb.Middleware)
+	rr.Get("/payments/:id", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		router.Fail(r, router.NotFoundErr)
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	for i := 0; i < 4; i++ {
+		failing = i%2 == 1
+		serve("/payments/1")
+	}
+	rec := serve("/payments/2")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "60" || calls != 4 {
+		t.Fatalf("open circuit should fail fast, got %d %q after %d calls", rec.Code, rec.Header().Get("Retry-After"), calls)
+	}
+
+	// client errors aren't failures, and each route has its own circuit
+	for i := 0; i < 5; i++ {
+		if rec := serve("/users/1"); rec.Code != http.StatusNotFound {
+			t.Errorf("invalid status %d", rec.Code)
+		}
+	}
+
+	stats := cb.Stats()
+	expected := []CircuitStats{
+		{Key: "/payments/:id", State: "open", Requests: 4, Failures: 2, Opened: 1, OpenedAt: t0},
+		{Key: "/users/:id", State: "closed", Requests: 5},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("invalid stats %+v", stats)
+	}
+
+	// a failing trial opens the circuit again, and a successful one closes it
+	clock = clock.Add(time.Minute)
+	failing = true
+	if rec := serve("/payments/1"); rec.Code != http.StatusBadGateway {
+		t.Errorf("trial request should be let through, got %d", rec.Code)
+	}
+	if rec := serve("/payments/1"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("failed trial should open the circuit, got %d", rec.Code)
+	}
+	clock = clock.Add(time.Minute)
+	failing = false
+	for i := 0; i < 3; i++ {
+		if rec := serve("/payments/1"); rec.Code != http.StatusOK {
+			t.Errorf("successful trial should close the circuit, got %d", rec.Code)
+		}
+	}
+
+	expectedChanges := []string{
+		"/payments/:id closed>open",
+		"/payments/:id open>half-open",
+		"/payments/:id half-open>open",
+		"/payments/:id open>half-open",
+		"/payments/:id half-open>closed",
+	}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("invalid state changes %q", changes)
+	}
+}
+
+func TestCircuitBreakerPerRouter(t *testing.T) {
+	cb := CircuitBreaker(CircuitBreakerOptions{
+		Key:         func(r *http.Request) string { return "admin" },
+		MinRequests: 1,
+	})
+	rr := router.New("/")
+	admin := rr.SubRouter("/admin")
+	admin.Use(cb.Middleware)
+	admin.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	admin.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, test := range []struct {
+		path   string
+		status int
+	}{
+		{"/admin/panic", http.StatusInternalServerError},
+		{"/admin/users", http.StatusServiceUnavailable},
+		{"/", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, httptest.NewRequest("GET", test.path, nil))
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.path, rec.Code)
+		}
+	}
+}
+
+func TestCircuitBreakerWriter(t *testing.T) {
+	rr := router.New("/")
+	rr.Use(CircuitBreaker(CircuitBreakerOptions{}).Middleware)
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		_, hijack := w.(http.Hijacker)
+		rf, readFrom := w.(io.ReaderFrom)
+		if served := r.Header.Get("X-Served") == "server"; hijack != served || readFrom != served {
+			t.Errorf("the writer should expose the interfaces of the underlying writer, hijack %t read from %t", hijack, readFrom)
+		}
+		if readFrom {
+			rf.ReadFrom(strings.NewReader("streamed"))
+			return
+		}
+		w.Write([]byte("recorded"))
+	})
+
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "recorded" {
+		t.Errorf("invalid response %d %q", rec.Code, rec.Body.String())
+	}
+
+	ts := httptest.NewServer(rr)
+	defer ts.Close()
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("X-Served", "server")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(b) != "streamed" {
+		t.Errorf("invalid response %d %q", res.StatusCode, b)
+	}
+}
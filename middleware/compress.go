@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// content types that are already compressed and gain nothing from being compressed again
+var compressedTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"application/octet-stream",
+}
+
+// Compress wraps the handler, compressing the response body with gzip or deflate depending on the
+// request's Accept-Encoding header. When types are provided only responses with a matching
+// content type (or type prefix, ex. `text/`) are compressed. Routes marked with Streaming aren't
+// compressed, as the compressor holds back their writes.
+func Compress(level int, types ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if router.IsStreaming(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				level:          level,
+				types:          types,
+			}
+			defer cw.Close()
+			next.ServeHTTP(wrap.Writer(cw, w), r)
+		})
+	}
+}
+
+// negotiateEncoding returns the preferred supported encoding, gzip or deflate, or an empty string.
+// Encodings that aren't listed take the quality of `*`, while those listed keep their own, so
+// `*, gzip;q=0` still refuses gzip.
+func negotiateEncoding(header string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, q := parseQuality(part)
+		qualities[name] = q
+	}
+
+	var best string
+	var bestQ float64
+	for _, name := range []string{"gzip", "deflate"} {
+		q, ok := qualities[name]
+		if !ok {
+			q = qualities["*"]
+		}
+		// gzip is preferred on ties, as it's checked first
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// parseQuality splits a header value such as `gzip;q=0.8` into its name and quality
+func parseQuality(part string) (string, float64) {
+	fields := strings.Split(part, ";")
+	name := strings.ToLower(strings.TrimSpace(fields[0]))
+	q := 1.0
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return name, q
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	level    int
+	types    []string
+
+	writer      io.WriteCloser
+	wroteHeader bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	// informational responses, ex. early hints, precede the final status
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	h := cw.Header()
+	if status != http.StatusNoContent && status != http.StatusNotModified &&
+		h.Get("Content-Encoding") == "" && cw.compressible(h.Get("Content-Type")) {
+		var err error
+		switch cw.encoding {
+		case "gzip":
+			cw.writer, err = gzip.NewWriterLevel(cw.ResponseWriter, cw.level)
+		case "deflate":
+			cw.writer, err = flate.NewWriter(cw.ResponseWriter, cw.level)
+		}
+		if err == nil {
+			h.Set("Content-Encoding", cw.encoding)
+			h.Del("Content-Length")
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.writer != nil {
+		return cw.writer.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered compressed data on to the client
+func (cw *compressWriter) Flush() {
+	if f, ok := cw.writer.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close flushes the remaining compressed data
+func (cw *compressWriter) Close() error {
+	if cw.writer != nil {
+		return cw.writer.Close()
+	}
+	return nil
+}
+
+func (cw *compressWriter) compressible(contentType string) bool {
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, t := range compressedTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	if len(cw.types) == 0 {
+		return true
+	}
+	for _, t := range cw.types {
+		if strings.HasPrefix(contentType, strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
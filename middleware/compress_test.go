@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestCompressGzip(t *testing.T) {
+	h := Compress(gzip.BestSpeed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello world"))
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Error("gzip encoding not set")
+		return
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("vary header not set")
+		return
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	body, _ := ioutil.ReadAll(gr)
+	if string(body) != "hello world" {
+		t.Errorf("invalid body: %s", body)
+	}
+}
+
+func TestCompressDeflate(t *testing.T) {
+	h := Compress(flate.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip;q=0, deflate")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Error("deflate encoding not set")
+		return
+	}
+	body, _ := ioutil.ReadAll(flate.NewReader(w.Body))
+	if string(body) != "<html></html>" {
+		t.Errorf("invalid body: %s", body)
+	}
+}
+
+func TestCompressSkipped(t *testing.T) {
+	tests := []struct {
+		desc           string
+		acceptEncoding string
+		contentType    string
+		types          []string
+	}{
+		{desc: "no accept-encoding", contentType: "text/plain"},
+		{desc: "unsupported encoding", acceptEncoding: "br", contentType: "text/plain"},
+		{desc: "refused with wildcard", acceptEncoding: "*, gzip;q=0, deflate;q=0", contentType: "text/plain"},
+		{desc: "wildcard refused", acceptEncoding: "*;q=0", contentType: "text/plain"},
+		{desc: "already compressed", acceptEncoding: "gzip", contentType: "image/png"},
+		{desc: "type not listed", acceptEncoding: "gzip", contentType: "text/plain", types: []string{"application/json"}},
+	}
+
+	for _, test := range tests {
+		h := Compress(gzip.DefaultCompression, test.types...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", test.contentType)
+			w.Write([]byte("raw"))
+		}))
+
+		r, _ := http.NewRequest("GET", "/", nil)
+		if test.acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", test.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: response should not be compressed", test.desc)
+		}
+		if w.Body.String() != "raw" {
+			t.Errorf("%s: invalid body %s", test.desc, w.Body.String())
+		}
+	}
+}
+
+func TestCompressStreaming(t *testing.T) {
+	rr := router.New("/")
+	rr.Use(Compress(gzip.DefaultCompression))
+	rr.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: hi\n\n"))
+	}).Streaming()
+
+	r, _ := http.NewRequest("GET", "/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "data: hi\n\n" {
+		t.Errorf("streaming routes should not be compressed, got %q", w.Body.String())
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"gzip":                 "gzip",
+		"*":                    "gzip",
+		"*, gzip;q=0":          "deflate",
+		"gzip;q=0, *":          "deflate",
+		"deflate, gzip;q=0.5":  "deflate",
+		"*;q=0.5, deflate;q=1": "deflate",
+		"*;q=0, gzip":          "gzip",
+		"identity":             "",
+		"":                     "",
+	}
+	for header, expected := range tests {
+		if encoding := negotiateEncoding(header); encoding != expected {
+			t.Errorf("%q: %q != %q", header, encoding, expected)
+		}
+	}
+}
+
+// hinted sends early hints before creating the resource
+func hinted(w http.ResponseWriter, r *http.Request) {
+	router.EarlyHints(w, []string{"</css/app.css>; rel=preload; as=style"})
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("created"))
+}
+
+// serveHinted sends the request to the handler over a server, returning the informational statuses
+// received ahead of the response, and the response's status and body
+func serveHinted(t *testing.T, h http.Handler, method string, header http.Header) ([]int, int, string) {
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	var informational []int
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			informational = append(informational, code)
+			return nil
+		},
+	}
+	req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), method, srv.URL, nil)
+	for key, vals := range header {
+		req.Header[key] = vals
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return informational, res.StatusCode, string(body)
+}
+
+func TestCompressEarlyHints(t *testing.T) {
+	h := Compress(gzip.BestSpeed)(http.HandlerFunc(hinted))
+	hints, status, body := serveHinted(t, h, "GET", nil)
+	if len(hints) != 1 || hints[0] != http.StatusEarlyHints || status != http.StatusCreated || body != "created" {
+		t.Errorf("early hints should be passed on, got %v %d %q", hints, status, body)
+	}
+}
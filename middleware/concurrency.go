@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConcurrencyLimit is a policy capping the in-flight requests of each principal on expensive
+// routes, such as exports and searches, so one heavy client can't monopolize them. Requests over the
+// limit wait briefly for a slot, then are rejected with a 429. State is shared by the routes the
+// policy is attached to at once.
+//
+//	rr.Get("/exports/:id", export).Policy(middleware.ConcurrencyLimit{
+//		PerPrincipal: 2,
+//		Key:          func(r *http.Request) string { return r.Header.Get("X-API-Key") },
+//	})
+type ConcurrencyLimit struct {
+	// PerPrincipal is the number of requests each principal can have in flight
+	PerPrincipal int
+
+	// Wait is how long a request waits for a slot, 1 second by default
+	Wait time.Duration
+
+	// Key identifies the principal, defaulting to the client's address
+	Key func(r *http.Request) string
+}
+
+// Middleware implements router.Policy
+func (cl ConcurrencyLimit) Middleware() func(http.Handler) http.Handler {
+	if cl.Wait <= 0 {
+		cl.Wait = time.Second
+	}
+	if cl.Key == nil {
+		cl.Key = remoteAddr
+	}
+	s := &slots{byKey: make(map[string]*principalSlots)}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cl.PerPrincipal <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := cl.Key(r)
+			ps := s.acquire(key, cl.PerPrincipal)
+			defer s.release(key, ps)
+
+			timer := time.NewTimer(cl.Wait)
+			defer timer.Stop()
+			select {
+			case ps.sem <- struct{}{}:
+			case <-timer.C:
+				retry := int(cl.Wait.Seconds() + 0.5)
+				if retry < 1 {
+					retry = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			case <-r.Context().Done():
+				return
+			}
+			defer func() { <-ps.sem }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (cl ConcurrencyLimit) String() string {
+	return "concurrency=" + strconv.Itoa(cl.PerPrincipal)
+}
+
+// slots are the semaphores of the principals with requests in flight or waiting
+type slots struct {
+	mu    sync.Mutex
+	byKey map[string]*principalSlots
+}
+
+type principalSlots struct {
+	sem  chan struct{}
+	refs int
+}
+
+// acquire references the principal's semaphore, creating it if they have no other requests
+func (s *slots) acquire(key string, n int) *principalSlots {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.byKey[key]
+	if !ok {
+		ps = &principalSlots{sem: make(chan struct{}, n)}
+		s.byKey[key] = ps
+	}
+	ps.refs++
+	return ps
+}
+
+// release dereferences the semaphore, removing it once the principal has no requests
+func (s *slots) release(key string, ps *principalSlots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ps.refs--; ps.refs == 0 {
+		delete(s.byKey, key)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	rr := router.New("/")
+	rr.Get("/exports", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}).Policy(ConcurrencyLimit{
+		PerPrincipal: 2,
+		Wait:         100 * time.Millisecond,
+		Key:          func(r *http.Request) string { return r.Header.Get("X-API-Key") },
+	})
+
+	request := func(key string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest("GET", "/exports", nil)
+		r.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+		return w
+	}
+
+	// the heavy client fills its slots
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request("heavy")
+		}()
+	}
+	<-started
+	<-started
+
+	if w := request("heavy"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("invalid status over the limit %d", w.Code)
+	}
+
+	// other clients aren't affected
+	done := make(chan int)
+	go func() { done <- request("light").Code }()
+	<-started
+
+	// a queued request is served once a slot frees up
+	queued := make(chan int)
+	go func() {
+		r, _ := http.NewRequest("GET", "/exports", nil)
+		r.Header.Set("X-API-Key", "heavy")
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+		queued <- w.Code
+	}()
+	close(release)
+	if code := <-queued; code != http.StatusOK {
+		t.Errorf("invalid queued status %d", code)
+	}
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("invalid status of other client %d", code)
+	}
+	wg.Wait()
+}
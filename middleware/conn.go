@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/chrisolsen/router"
+)
+
+var (
+	connCtxKey     = router.NewKey[ConnInfo]("conn")
+	listenerCtxKey = router.NewKey[string]("listener")
+)
+
+// ConnInfo describes the connection a request arrived on
+type ConnInfo struct {
+	// Listener is the name given to the listener with ListenerName, if any
+	Listener string
+
+	// Network is the network of the listener, ex. `tcp` or `unix`
+	Network string
+
+	// LocalAddr is the address of the listener that accepted the connection
+	LocalAddr net.Addr
+
+	// Protocol is the ALPN protocol negotiated over TLS, ex. `h2`, or empty for plain connections
+	Protocol string
+}
+
+// Unix reports whether the connection arrived over a unix socket
+func (c ConnInfo) Unix() bool {
+	return c.Network == "unix" || c.Network == "unixpacket"
+}
+
+// ListenerName names the connections of a server, allowing requests to be told apart when multiple
+// servers share a router. It is set as the server's ConnContext.
+//
+//	srv := &http.Server{Handler: rr, ConnContext: middleware.ListenerName("internal")}
+func ListenerName(name string) func(c context.Context, conn net.Conn) context.Context {
+	return func(c context.Context, conn net.Conn) context.Context {
+		return router.Set(c, listenerCtxKey, name)
+	}
+}
+
+// ConnMetadata binds the metadata of the request's connection, available via Conn
+func ConnMetadata(w http.ResponseWriter, r *http.Request) {
+	info := ConnInfo{}
+	info.Listener, _ = router.Get(r.Context(), listenerCtxKey)
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		info.LocalAddr = addr
+		info.Network = addr.Network()
+	}
+	if r.TLS != nil {
+		info.Protocol = r.TLS.NegotiatedProtocol
+	}
+	router.BindContext(router.Set(r.Context(), connCtxKey, info), r)
+}
+
+// Conn retrieves the connection metadata bound by the ConnMetadata middleware
+func Conn(c context.Context) ConnInfo {
+	info, _ := router.Get(c, connCtxKey)
+	return info
+}
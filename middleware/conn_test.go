@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func connRouter() router.Router {
+	rr := router.New("/")
+	rr.Before(ConnMetadata)
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		info := Conn(r.Context())
+		io.WriteString(w, info.Listener+" "+info.Network+" "+info.Protocol)
+	})
+	return rr
+}
+
+func TestConnMetadataTLS(t *testing.T) {
+	ts := httptest.NewUnstartedServer(connRouter())
+	ts.EnableHTTP2 = true
+	ts.Config.ConnContext = ListenerName("public")
+	ts.StartTLS()
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "public tcp h2" {
+		t.Errorf("invalid connection metadata %q", body)
+	}
+}
+
+func TestConnMetadataUnix(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "router.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip("unix sockets unavailable:", err)
+	}
+	srv := &http.Server{Handler: connRouter(), ConnContext: ListenerName("internal")}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(c context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(c, "unix", sock)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "internal unix " {
+		t.Errorf("invalid connection metadata %q", body)
+	}
+	if !(ConnInfo{Network: "unix"}).Unix() {
+		t.Error("unix connection not reported")
+	}
+}
@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// ContentTypeOptions configures the ContentType middleware
+type ContentTypeOptions struct {
+	// Dev checks the declared content type of each response against its sniffed content, logging
+	// mismatches unless OnMismatch is set
+	Dev bool
+
+	// OnMismatch is called when the declared and sniffed content types disagree, ex. an HTML error
+	// page sent as application/json. Setting it enables the check outside of dev mode.
+	OnMismatch func(r *http.Request, declared, sniffed string)
+}
+
+// ContentType wraps the handler, normalizing text responses to UTF-8. Text responses without a
+// charset are declared as UTF-8, and those declared as ISO-8859-1, Windows-1252 or US-ASCII are
+// transcoded to UTF-8. Responses without a content type are given their sniffed type.
+func ContentType(opts ContentTypeOptions) func(http.Handler) http.Handler {
+	if opts.Dev && opts.OnMismatch == nil {
+		opts.OnMismatch = func(r *http.Request, declared, sniffed string) {
+			log.Printf("middleware: %s %s declared %s but sniffed %s", r.Method, r.URL.Path, declared, sniffed)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := &contentWriter{ResponseWriter: w, r: r, onMismatch: opts.OnMismatch}
+			next.ServeHTTP(wrap.Writer(cw, w), r)
+			cw.sendHeader(nil)
+		})
+	}
+}
+
+// contentWriter holds back the header until the first write, allowing the body to be sniffed
+type contentWriter struct {
+	http.ResponseWriter
+	r          *http.Request
+	onMismatch func(r *http.Request, declared, sniffed string)
+
+	status    int
+	sent      bool
+	transcode func([]byte) []byte
+}
+
+func (cw *contentWriter) WriteHeader(status int) {
+	// informational responses, ex. early hints, aren't held back
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *contentWriter) Write(b []byte) (int, error) {
+	cw.sendHeader(b)
+	if cw.transcode != nil {
+		if _, err := cw.ResponseWriter.Write(cw.transcode(b)); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends the header, if it hasn't been sent, and flushes the underlying writer
+func (cw *contentWriter) Flush() {
+	cw.sendHeader(nil)
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sendHeader normalizes the content type using the start of the body, then sends the header
+func (cw *contentWriter) sendHeader(b []byte) {
+	if cw.sent {
+		return
+	}
+	if cw.status == 0 && b == nil {
+		// nothing has been written, leaving the response to the server's defaults
+		return
+	}
+	cw.sent = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	h := cw.Header()
+	declared := h.Get("Content-Type")
+	if len(b) > 0 && h.Get("Content-Encoding") == "" {
+		sniffed := http.DetectContentType(b)
+		if declared == "" {
+			declared = sniffed
+			h.Set("Content-Type", declared)
+		} else if cw.onMismatch != nil && contentMismatch(mediaType(declared), mediaType(sniffed)) {
+			cw.onMismatch(cw.r, declared, sniffed)
+		}
+	}
+	if declared != "" {
+		cw.normalizeCharset(declared)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// normalizeCharset declares text responses as UTF-8, transcoding them from the charsets that map
+// directly onto unicode
+func (cw *contentWriter) normalizeCharset(contentType string) {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mt, "text/") {
+		return
+	}
+	switch strings.ToLower(params["charset"]) {
+	case "utf-8", "utf8":
+		return
+	case "", "us-ascii", "ascii":
+	case "iso-8859-1", "latin1", "l1":
+		cw.transcode = latin1ToUTF8
+	case "windows-1252", "cp1252":
+		cw.transcode = windows1252ToUTF8
+	default:
+		// charsets requiring tables, such as shift_jis, are left as declared
+		return
+	}
+	params["charset"] = "utf-8"
+	cw.Header().Set("Content-Type", mime.FormatMediaType(mt, params))
+	if cw.transcode != nil {
+		cw.Header().Del("Content-Length")
+	}
+}
+
+// mediaType returns the media type without its parameters
+func mediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// contentMismatch reports whether the sniffed type contradicts the declared type. Sniffing can't
+// identify formats such as JSON or CSV, which are sniffed as plain text.
+func contentMismatch(declared, sniffed string) bool {
+	if declared == sniffed {
+		return false
+	}
+	switch {
+	case sniffed == "application/octet-stream":
+		return false
+	case sniffed == "text/plain":
+		return binaryType(declared)
+	case (sniffed == "text/xml" || sniffed == "text/html") && strings.Contains(declared, "xml"):
+		return false
+	}
+	return true
+}
+
+func binaryType(t string) bool {
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/", "application/pdf", "application/zip", "application/gzip"} {
+		if strings.HasPrefix(t, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func latin1ToUTF8(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		out = appendRune(out, rune(c))
+	}
+	return out
+}
+
+// windows1252 maps the bytes 0x80-0x9F, where Windows-1252 differs from ISO-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func windows1252ToUTF8(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		r := rune(c)
+		if c >= 0x80 && c <= 0x9F {
+			r = windows1252[c-0x80]
+		}
+		out = appendRune(out, r)
+	}
+	return out
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		desc        string
+		contentType string
+		status      int
+		body        string
+		expectedCT  string
+		expected    string
+		mismatch    string
+	}{
+		{"missing charset", "text/html", 200, "<p>hi</p>", "text/html; charset=utf-8", "<p>hi</p>", ""},
+		{"utf-8", "text/plain; charset=UTF-8", 200, "héllo", "text/plain; charset=UTF-8", "héllo", ""},
+		{"latin1", "text/plain; charset=iso-8859-1", 201, "caf\xe9", "text/plain; charset=utf-8", "café", ""},
+		{"windows-1252", "text/csv; charset=windows-1252", 200, "\x93quoted\x94 \x80", "text/csv; charset=utf-8", "“quoted” €", ""},
+		{"unknown charset", "text/plain; charset=shift_jis", 200, "abc", "text/plain; charset=shift_jis", "abc", ""},
+		{"undeclared", "", 200, "<!DOCTYPE html><html></html>", "text/html; charset=utf-8", "<!DOCTYPE html><html></html>", ""},
+		{"json", "application/json", 200, `{"ok":true}`, "application/json", `{"ok":true}`, ""},
+		{"html as json", "application/json", 500, "<html><body>error</body></html>", "application/json", "<html><body>error</body></html>", "text/html; charset=utf-8"},
+		{"text as image", "image/png", 200, "not an image", "image/png", "not an image", "text/plain; charset=utf-8"},
+		{"atom", "application/atom+xml", 200, `<?xml version="1.0"?><feed/>`, "application/atom+xml", `<?xml version="1.0"?><feed/>`, ""},
+	}
+	for _, test := range tests {
+		var mismatch string
+		mw := ContentType(ContentTypeOptions{OnMismatch: func(r *http.Request, declared, sniffed string) {
+			mismatch = sniffed
+		}})
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if test.contentType != "" {
+				w.Header().Set("Content-Type", test.contentType)
+			}
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		r, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.desc, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != test.expectedCT {
+			t.Errorf("%s: invalid content type %q != %q", test.desc, ct, test.expectedCT)
+		}
+		if w.Body.String() != test.expected {
+			t.Errorf("%s: invalid body %q != %q", test.desc, w.Body.String(), test.expected)
+		}
+		if mismatch != test.mismatch {
+			t.Errorf("%s: invalid mismatch %q != %q", test.desc, mismatch, test.mismatch)
+		}
+	}
+}
+
+func TestContentTypeNoBody(t *testing.T) {
+	h := ContentType(ContentTypeOptions{Dev: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent || w.Header().Get("Content-Type") != "" {
+		t.Errorf("invalid response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestContentTypeEarlyHints(t *testing.T) {
+	h := ContentType(ContentTypeOptions{})(http.HandlerFunc(hinted))
+	hints, status, body := serveHinted(t, h, "GET", nil)
+	if len(hints) != 1 || status != http.StatusCreated || body != "created" {
+		t.Errorf("early hints should be passed on, got %v %d %q", hints, status, body)
+	}
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CORSOptions configures the cross-origin requests that are allowed
+type CORSOptions struct {
+	// Origins are the allowed origins, ex. `https://example.com`. A `*` subdomain wildcard, ex.
+	// `https://*.example.com`, allows any subdomain and a lone `*` allows every origin, which can't be
+	// combined with Credentials.
+	Origins []string
+
+	// AllowOrigin is consulted for origins not in Origins, ex. to allow the domains customers have
+	// registered. Its decisions are cached for CacheTTL, and errors deny the origin uncached.
+	AllowOrigin func(c context.Context, origin string) (bool, error)
+	CacheTTL    time.Duration
+
+	// Methods default to GET, HEAD and POST
+	Methods []string
+
+	// Headers are the request headers allowed, defaulting to those requested by the preflight
+	Headers        []string
+	ExposedHeaders []string
+	Credentials    bool
+
+	// MaxAge is how long browsers can cache preflight responses
+	MaxAge time.Duration
+}
+
+// CORS wraps the handler, answering preflight requests and reflecting allowed origins in the
+// Access-Control-Allow-Origin header. Preflight requests from disallowed origins are refused with
+// a 403, while other requests pass through without the CORS headers, leaving the browser to block
+// the response. Applied to a single route it allows origins per route:
+//
+//	rr.Handle("/widget/*", middleware.CORS(opts)(widgetHandler))
+//
+// It panics if every origin is allowed along with credentials, which would let any site make
+// credentialed requests.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	if opts.Credentials {
+		for _, origin := range opts.Origins {
+			if origin == "*" {
+				panic("middleware: CORS can't allow credentials from every origin")
+			}
+		}
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	cache := &originCache{entries: make(map[string]originEntry)}
+	methods := strings.Join(opts.Methods, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the response varies by origin even for requests without one, preventing caches
+			// from serving it to cross-origin requests
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !opts.allowed(r.Context(), origin, cache) {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", origin)
+			if opts.Credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				if len(opts.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(opts.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			if len(opts.Headers) > 0 {
+				h.Set("Access-Control-Allow-Headers", strings.Join(opts.Headers, ", "))
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+			if opts.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// allowed checks the origin against the static origins, then the cached or fresh decision of the
+// AllowOrigin callback
+func (opts CORSOptions) allowed(c context.Context, origin string, cache *originCache) bool {
+	for _, allowed := range opts.Origins {
+		if matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	if opts.AllowOrigin == nil {
+		return false
+	}
+
+	t := now()
+	if ok, cached := cache.get(origin, t); cached {
+		return ok
+	}
+	ok, err := opts.AllowOrigin(c, origin)
+	if err != nil {
+		return false
+	}
+	if opts.CacheTTL > 0 {
+		cache.set(origin, ok, t, opts.CacheTTL)
+	}
+	return ok
+}
+
+// matchOrigin compares the origins without case, allowing a `*` wildcard in place of subdomains
+func matchOrigin(pattern, origin string) bool {
+	if pattern == "*" {
+		return true
+	}
+	pattern, origin = strings.ToLower(pattern), strings.ToLower(origin)
+	i := strings.Index(pattern, "*.")
+	if i < 0 {
+		return pattern == origin
+	}
+	prefix, suffix := pattern[:i], pattern[i+1:]
+	return len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
+
+// originCache holds the AllowOrigin decisions
+type originCache struct {
+	mu        sync.Mutex
+	entries   map[string]originEntry
+	lastSweep time.Time
+}
+
+type originEntry struct {
+	allowed bool
+	expires time.Time
+}
+
+func (c *originCache) get(origin string, t time.Time) (allowed, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[origin]
+	if !ok || !t.Before(entry.expires) {
+		return false, false
+	}
+	return entry.allowed, true
+}
+
+func (c *originCache) set(origin string, allowed bool, t time.Time, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// expired entries are removed at most once per ttl, preventing unbounded growth from
+	// requests with made up origins
+	if t.Sub(c.lastSweep) >= ttl {
+		c.lastSweep = t
+		for key, entry := range c.entries {
+			if !t.Before(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	c.entries[origin] = originEntry{allowed: allowed, expires: t.Add(ttl)}
+}
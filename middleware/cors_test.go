@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		pattern, origin string
+		expected        bool
+	}{
+		{"*", "https://any.com", true},
+		{"https://example.com", "https://EXAMPLE.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.com", false},
+	}
+	for _, test := range tests {
+		if ok := matchOrigin(test.pattern, test.origin); ok != test.expected {
+			t.Errorf("%s %s: %v != %v", test.pattern, test.origin, ok, test.expected)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	start := time.Unix(1000, 0)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	lookups := 0
+	registered := map[string]bool{"https://shop.customer.com": true}
+	h := CORS(CORSOptions{
+		Origins: []string{"https://*.example.com"},
+		AllowOrigin: func(c context.Context, origin string) (bool, error) {
+			lookups++
+			if origin == "https://down.com" {
+				return false, errors.New("db down")
+			}
+			return registered[origin], nil
+		},
+		CacheTTL:       time.Minute,
+		Methods:        []string{"GET", "PUT"},
+		ExposedHeaders: []string{"X-Total"},
+		Credentials:    true,
+		MaxAge:         time.Hour,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	send := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/widget", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("GET", "https://app.example.com", false)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || rec.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+		t.Errorf("static origin not allowed %v", rec.Header())
+	}
+	if lookups != 0 {
+		t.Error("static origins should not be looked up")
+	}
+
+	rec = send("OPTIONS", "https://shop.customer.com", true)
+	if rec.Code != 204 || rec.Header().Get("Access-Control-Allow-Origin") != "https://shop.customer.com" {
+		t.Errorf("dynamic origin not allowed %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Methods") != "GET, PUT" || rec.Header().Get("Access-Control-Allow-Headers") != "Content-Type" ||
+		rec.Header().Get("Access-Control-Max-Age") != "3600" || rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("invalid preflight headers %v", rec.Header())
+	}
+	if rec.Body.Len() != 0 {
+		t.Error("preflight should not reach the handler")
+	}
+
+	send("GET", "https://shop.customer.com", false)
+	if lookups != 1 {
+		t.Errorf("decision not cached, %d lookups", lookups)
+	}
+	now = func() time.Time { return start.Add(time.Minute) }
+	send("GET", "https://shop.customer.com", false)
+	if lookups != 2 {
+		t.Errorf("expired decision not looked up again, %d lookups", lookups)
+	}
+
+	rec = send("OPTIONS", "https://evil.com", true)
+	if rec.Code != 403 || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight %d %v", rec.Code, rec.Header())
+	}
+	rec = send("GET", "https://evil.com", false)
+	if rec.Body.String() != "ok" || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed origin should pass through without headers %v", rec.Header())
+	}
+
+	send("GET", "https://down.com", false)
+	send("GET", "https://down.com", false)
+	if lookups != 5 {
+		t.Errorf("errors should not be cached, %d lookups", lookups)
+	}
+
+	rec = send("GET", "", false)
+	if rec.Header().Get("Vary") != "Origin" || rec.Body.String() != "ok" {
+		t.Errorf("same origin request %v", rec.Header())
+	}
+}
+
+func TestCORSWildcardCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("allowing credentials from every origin should panic")
+		}
+	}()
+	CORS(CORSOptions{Origins: []string{"https://example.com", "*"}, Credentials: true})
+}
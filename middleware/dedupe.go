@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// DedupeStore records the deliveries that have been seen, shared between instances in order to
+// dedupe redeliveries reaching any of them
+type DedupeStore interface {
+	// Add records the key for the ttl, reporting false if it was already recorded and unexpired
+	Add(c context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Delete removes the key, allowing the delivery to be retried
+	Delete(c context.Context, key string) error
+}
+
+// Dedupe is a policy for webhook routes that handles each delivery once, identified by the
+// provider's delivery ID header. Redeliveries of a delivery already handled, or being handled,
+// by the route are answered with a 200 without calling the handler. Deliveries that fail with a
+// 5xx, a panic or router.Fail are forgotten so the provider's retry is handled. Requests without
+// the header, or whose ID can't be recorded, are always handled.
+//
+//	rr.Post("/hooks/github", githubHook).Policy(middleware.Dedupe{Header: "X-GitHub-Delivery"})
+type Dedupe struct {
+	// Header holds the delivery ID, ex. X-GitHub-Delivery
+	Header string
+
+	// TTL is how long deliveries are remembered, 24 hours by default
+	TTL time.Duration
+
+	// Store defaults to an in-memory store local to the policy
+	Store DedupeStore
+}
+
+// Middleware implements router.Policy
+func (d Dedupe) Middleware() func(http.Handler) http.Handler {
+	if d.TTL <= 0 {
+		d.TTL = 24 * time.Hour
+	}
+	if d.Store == nil {
+		d.Store = NewMemoryDedupeStore()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(d.Header)
+			if id == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// deliveries are deduped per route, as a provider may deliver the same event to several
+			key := router.RoutePattern(r.Context()) + " " + id
+			added, err := d.Store.Add(r.Context(), key, d.TTL)
+			if err == nil && !added {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sw := &wrap.StatusWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if !completed || sw.Status >= 500 || router.RequestError(r.Context()) != nil {
+					d.Store.Delete(context.Background(), key)
+				}
+			}()
+			next.ServeHTTP(wrap.Writer(sw, w), r)
+			completed = true
+		})
+	}
+}
+
+func (d Dedupe) String() string {
+	return "dedupe=" + d.Header
+}
+
+// memoryDedupeStore is a DedupeStore local to the process
+type memoryDedupeStore struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryDedupeStore creates a DedupeStore held in memory, suitable for a single instance
+func NewMemoryDedupeStore() DedupeStore {
+	return &memoryDedupeStore{expires: make(map[string]time.Time)}
+}
+
+func (s *memoryDedupeStore) Add(c context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	s.sweep(t, ttl)
+	if exp, ok := s.expires[key]; ok && t.Before(exp) {
+		return false, nil
+	}
+	s.expires[key] = t.Add(ttl)
+	return true, nil
+}
+
+func (s *memoryDedupeStore) Delete(c context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expires, key)
+	return nil
+}
+
+// sweep removes the expired keys at most once per ttl
+func (s *memoryDedupeStore) sweep(t time.Time, ttl time.Duration) {
+	if t.Sub(s.lastSweep) < ttl {
+		return
+	}
+	s.lastSweep = t
+	for key, exp := range s.expires {
+		if !t.Before(exp) {
+			delete(s.expires, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestDedupe(t *testing.T) {
+	start := time.Now()
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	calls := map[string]int{}
+	status := http.StatusAccepted
+	dedupe := Dedupe{Header: "X-GitHub-Delivery", TTL: time.Hour}
+
+	rr := router.New("/")
+	rr.Post("/hooks/:provider", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		w.WriteHeader(status)
+	}).Policy(dedupe)
+	rr.Post("/audit", func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		if status == http.StatusTeapot {
+			router.Fail(r, errors.New("failed"))
+		}
+	}).Policy(dedupe)
+
+	deliver := func(path, id string) int {
+		r, _ := http.NewRequest("POST", path, nil)
+		if id != "" {
+			r.Header.Set("X-GitHub-Delivery", id)
+		}
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := deliver("/hooks/github", "1"); code != http.StatusAccepted {
+		t.Errorf("invalid status %d", code)
+	}
+	if code := deliver("/hooks/github", "1"); code != http.StatusOK {
+		t.Errorf("invalid duplicate status %d", code)
+	}
+	// the same delivery to another route, and deliveries without an ID, are handled, while
+	// deliveries are shared by the paths of a route
+	deliver("/hooks/gitlab", "1")
+	deliver("/audit", "1")
+	deliver("/hooks/github", "")
+	deliver("/hooks/github", "")
+	if calls["/hooks/github"] != 3 || calls["/hooks/gitlab"] != 0 || calls["/audit"] != 1 {
+		t.Errorf("invalid calls %v", calls)
+	}
+
+	// failed deliveries are retried
+	status = http.StatusServiceUnavailable
+	deliver("/hooks/github", "2")
+	status = http.StatusAccepted
+	if code := deliver("/hooks/github", "2"); code != http.StatusAccepted {
+		t.Errorf("failed delivery not retried, status %d", code)
+	}
+	status = http.StatusTeapot
+	deliver("/audit", "2")
+	deliver("/audit", "2")
+	if calls["/audit"] != 3 {
+		t.Errorf("delivery failed with Fail not retried, %d calls", calls["/audit"])
+	}
+
+	// deliveries are forgotten after the ttl
+	now = func() time.Time { return start.Add(time.Hour) }
+	status = http.StatusAccepted
+	if code := deliver("/hooks/github", "1"); code != http.StatusAccepted {
+		t.Errorf("expired delivery not handled, status %d", code)
+	}
+}
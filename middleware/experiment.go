@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+var experimentsCtxKey = router.NewKey[map[string]string]("experiments")
+
+// Variant is an arm of an experiment. Requests are split between the variants in proportion to
+// their weights, with a zero weight counting as 1.
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// ExperimentOptions configures an experiment
+type ExperimentOptions struct {
+	Name     string
+	Variants []Variant
+
+	// Principal identifies the user, ex. their account id, so they receive the same variant on every
+	// device. Requests without a principal are kept on their variant with a cookie.
+	Principal func(r *http.Request) string
+
+	// CookieMaxAge is how long a cookie assignment lasts, 90 days by default
+	CookieMaxAge time.Duration
+
+	// Exposure reports that the request was served the variant, ex. to the analytics pipeline
+	Exposure func(r *http.Request, experiment, variant string)
+}
+
+// Experiment assigns requests to a variant of the experiment, available to handlers with
+// ExperimentVariant and sent to the client in the X-Experiment header.
+//
+//	rr.Before(middleware.Experiment(middleware.ExperimentOptions{
+//		Name:     "checkout",
+//		Variants: []middleware.Variant{{Name: "control"}, {Name: "one-page"}},
+//	}))
+func Experiment(opts ExperimentOptions) http.HandlerFunc {
+	if opts.CookieMaxAge <= 0 {
+		opts.CookieMaxAge = 90 * 24 * time.Hour
+	}
+	total := 0
+	for _, v := range opts.Variants {
+		total += weight(v)
+	}
+	cookie := "exp_" + opts.Name
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if total == 0 {
+			return
+		}
+
+		var variant string
+		if principal := principalOf(opts.Principal, r); principal != "" {
+			h := fnv.New32a()
+			h.Write([]byte(opts.Name + ":" + principal))
+			variant = opts.pick(int(h.Sum32() % uint32(total)))
+		} else {
+			if c, err := r.Cookie(cookie); err == nil && opts.has(c.Value) {
+				variant = c.Value
+			} else {
+				variant = opts.pick(randIntn(total))
+				http.SetCookie(w, &http.Cookie{
+					Name:     cookie,
+					Value:    variant,
+					Path:     "/",
+					MaxAge:   int(opts.CookieMaxAge.Seconds()),
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+		}
+
+		assigned := map[string]string{opts.Name: variant}
+		for name, v := range experiments(r.Context()) {
+			if name != opts.Name {
+				assigned[name] = v
+			}
+		}
+		router.BindContext(router.Set(r.Context(), experimentsCtxKey, assigned), r)
+		w.Header().Add("X-Experiment", opts.Name+"="+variant)
+		if opts.Exposure != nil {
+			opts.Exposure(r, opts.Name, variant)
+		}
+	}
+}
+
+// ExperimentVariant retrieves the variant of the experiment the request was assigned to, empty if
+// the experiment didn't run
+func ExperimentVariant(c context.Context, experiment string) string {
+	return experiments(c)[experiment]
+}
+
+func experiments(c context.Context) map[string]string {
+	assigned, _ := router.Get(c, experimentsCtxKey)
+	return assigned
+}
+
+// randIntn is swapped out within the tests
+var randIntn = rand.Intn
+
+func weight(v Variant) int {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// pick returns the variant at the point within the total weight
+func (opts ExperimentOptions) pick(n int) string {
+	for _, v := range opts.Variants {
+		if n < weight(v) {
+			return v.Name
+		}
+		n -= weight(v)
+	}
+	return opts.Variants[len(opts.Variants)-1].Name
+}
+
+func (opts ExperimentOptions) has(name string) bool {
+	for _, v := range opts.Variants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func principalOf(fn func(r *http.Request) string, r *http.Request) string {
+	if fn == nil {
+		return ""
+	}
+	return fn(r)
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestExperiment(t *testing.T) {
+	randIntn = func(n int) int { return n - 1 }
+	defer func() { randIntn = rand.Intn }()
+
+	var exposures []string
+	rr := router.New("/")
+	rr.Before(Experiment(ExperimentOptions{
+		Name:     "checkout",
+		Variants: []Variant{{Name: "control", Weight: 3}, {Name: "one-page"}},
+		Principal: func(r *http.Request) string {
+			return r.Header.Get("X-User")
+		},
+		Exposure: func(r *http.Request, experiment, variant string) {
+			exposures = append(exposures, experiment+"="+variant)
+		},
+	}), Experiment(ExperimentOptions{
+		Name:     "banner",
+		Variants: []Variant{{Name: "on"}},
+	}))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ExperimentVariant(r.Context(), "checkout") + " " + ExperimentVariant(r.Context(), "banner")))
+	})
+
+	send := func(user string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("", nil)
+	if rec.Body.String() != "one-page on" || len(rec.Header()["X-Experiment"]) != 2 {
+		t.Errorf("invalid assignment %q %v", rec.Body.String(), rec.Header())
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 2 || cookies[0].Name != "exp_checkout" || cookies[0].Value != "one-page" {
+		t.Errorf("invalid cookies %v", cookies)
+		return
+	}
+
+	// the cookie keeps the assignment even though a new random pick would differ
+	randIntn = func(n int) int { return 0 }
+	rec = send("", cookies)
+	if rec.Body.String() != "one-page on" || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("assignment not sticky %q", rec.Body.String())
+	}
+
+	// tampered cookies are reassigned
+	rec = send("", []*http.Cookie{{Name: "exp_checkout", Value: "hacked"}})
+	if rec.Body.String() != "control on" {
+		t.Errorf("invalid cookie should be reassigned %q", rec.Body.String())
+	}
+
+	// principals always receive the same variant without cookies
+	first := send("user-1", nil).Body.String()
+	for i := 0; i < 5; i++ {
+		if body := send("user-1", nil).Body.String(); body != first {
+			t.Errorf("principal assignment changed %q != %q", body, first)
+		}
+	}
+
+	if len(exposures) != 9 || exposures[0] != "checkout=one-page" {
+		t.Errorf("invalid exposures %v", exposures)
+	}
+}
+
+func TestExperimentWeights(t *testing.T) {
+	opts := ExperimentOptions{Variants: []Variant{{Name: "a", Weight: 2}, {Name: "b"}, {Name: "c", Weight: 3}}}
+	expected := []string{"a", "a", "b", "c", "c", "c"}
+	for n, name := range expected {
+		if v := opts.pick(n); v != name {
+			t.Errorf("%d: %s != %s", n, v, name)
+		}
+	}
+}
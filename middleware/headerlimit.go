@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/chrisolsen/router"
+)
+
+// HeaderLimitOptions are the header size budgets of sensitive routes, such as login flows targeted
+// by cookie bombing. Sizes are the total bytes of all of a header's values.
+type HeaderLimitOptions struct {
+	// MaxCookieBytes limits the total size of the request's cookies
+	MaxCookieBytes int
+
+	// MaxHeaderBytes limits the size of specific headers, keyed by name, ex. Authorization
+	MaxHeaderBytes map[string]int
+}
+
+// HeaderLimit rejects requests whose cookies or headers exceed their budgets with a 431, logging
+// the names of the offending headers. Applied to a router it covers all its routes, while a single
+// route attaches the options as a policy.
+//
+//	auth := rr.SubRouter("/auth")
+//	auth.Before(middleware.HeaderLimit(middleware.HeaderLimitOptions{MaxCookieBytes: 4096}))
+//
+//	rr.Post("/login", login).Policy(middleware.HeaderLimitOptions{MaxCookieBytes: 4096})
+func HeaderLimit(opts HeaderLimitOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts.limit(w, r)
+	}
+}
+
+// Middleware implements router.Policy
+func (opts HeaderLimitOptions) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.limit(w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// limit responds with a 431 and halts the request when it's over budget, reporting whether the
+// request is allowed
+func (opts HeaderLimitOptions) limit(w http.ResponseWriter, r *http.Request) bool {
+	var over []string
+	if opts.MaxCookieBytes > 0 && headerSize(r.Header, "Cookie") > opts.MaxCookieBytes {
+		over = append(over, "Cookie")
+	}
+	for name, max := range opts.MaxHeaderBytes {
+		if max > 0 && headerSize(r.Header, name) > max {
+			over = append(over, http.CanonicalHeaderKey(name))
+		}
+	}
+	if len(over) == 0 {
+		return true
+	}
+	sort.Strings(over)
+	log.Printf("middleware: %s %s rejected, headers over budget: %s", r.Method, r.URL.Path, strings.Join(over, ", "))
+	http.Error(w, http.StatusText(http.StatusRequestHeaderFieldsTooLarge), http.StatusRequestHeaderFieldsTooLarge)
+	router.HaltRequest(r)
+	return false
+}
+
+func headerSize(h http.Header, name string) int {
+	size := 0
+	for _, v := range h.Values(name) {
+		size += len(v)
+	}
+	return size
+}
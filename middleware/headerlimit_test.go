@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestHeaderLimit(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	mw := HeaderLimit(HeaderLimitOptions{
+		MaxCookieBytes: 20,
+		MaxHeaderBytes: map[string]int{"authorization": 10},
+	})
+	tests := []struct {
+		desc    string
+		headers map[string][]string
+		status  int
+		logged  string
+	}{
+		{"within budget", map[string][]string{"Cookie": {"a=1; b=2"}, "Authorization": {"Bearer x"}}, 200, ""},
+		{"cookie bomb", map[string][]string{"Cookie": {"a=1111111111", "b=2222222222"}}, 431, "headers over budget: Cookie"},
+		{"large header", map[string][]string{"Authorization": {"Bearer xxxxxxxx"}}, 431, "headers over budget: Authorization"},
+		{"both", map[string][]string{"Cookie": {strings.Repeat("c", 21)}, "Authorization": {strings.Repeat("a", 11)}}, 431, "headers over budget: Authorization, Cookie"},
+		{"other headers", map[string][]string{"X-Large": {strings.Repeat("x", 100)}}, 200, ""},
+	}
+	for _, test := range tests {
+		logs.Reset()
+		r, _ := http.NewRequest("POST", "/login", nil)
+		for k, vals := range test.headers {
+			for _, v := range vals {
+				r.Header.Add(k, v)
+			}
+		}
+		w := httptest.NewRecorder()
+		mw(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.desc, w.Code)
+		}
+		if halted := r.Context().Err() != nil; halted != (test.status == 431) {
+			t.Errorf("%s: halted %v", test.desc, halted)
+		}
+		if test.logged == "" && logs.Len() > 0 || !strings.Contains(logs.String(), test.logged) {
+			t.Errorf("%s: invalid log %q", test.desc, logs.String())
+		}
+	}
+}
+
+func TestHeaderLimitPolicy(t *testing.T) {
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(os.Stderr)
+
+	called := false
+	rr := router.New("/")
+	rr.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}).Policy(HeaderLimitOptions{MaxCookieBytes: 10})
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/login", "/"} {
+		method := "GET"
+		if path == "/login" {
+			method = "POST"
+		}
+		r, _ := http.NewRequest(method, path, nil)
+		r.Header.Set("Cookie", strings.Repeat("c", 11))
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+
+		expected := 200
+		if path == "/login" {
+			expected = 431
+		}
+		if w.Code != expected {
+			t.Errorf("%s: invalid status %d", path, w.Code)
+		}
+	}
+	if called {
+		t.Error("handler called over budget")
+	}
+}
@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// IdempotentResponse is a response cached by Idempotency, replayed for retries of its request
+type IdempotentResponse struct {
+	// Fingerprint identifies the request the response answered, so that a key reused for another
+	// request is rejected
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore holds the responses of idempotent requests, shared between instances so that
+// retries reaching any of them are replayed, ex. in Redis
+type IdempotencyStore interface {
+	// Get returns the response stored for the key, nil if there's none
+	Get(c context.Context, key string) (*IdempotentResponse, error)
+
+	// Lock reserves the key while its first request is being handled, for at most the timeout,
+	// reporting false if it's already reserved or has a response
+	Lock(c context.Context, key string, timeout time.Duration) (bool, error)
+
+	// Save stores the response of the key for the ttl, releasing its reservation
+	Save(c context.Context, key string, res IdempotentResponse, ttl time.Duration) error
+
+	// Unlock releases the reservation of the key, allowing its request to be retried
+	Unlock(c context.Context, key string) error
+}
+
+// IdempotencyOptions configures the Idempotency middleware
+type IdempotencyOptions struct {
+	// Store defaults to one held in memory
+	Store IdempotencyStore
+
+	// TTL is how long responses are replayed for, 24 hours by default
+	TTL time.Duration
+
+	// LockTimeout is how long a key is reserved for while its first request is handled, one minute
+	// by default, so that keys held by an instance that died mid-request are released
+	LockTimeout time.Duration
+
+	// Principal identifies the client, scoping its keys so that clients sending the same key never
+	// see each other's responses. It defaults to a hash of the Authorization and Cookie headers.
+	Principal func(r *http.Request) string
+
+	// MaxBytes limits the size of the bodies held in memory to fingerprint the requests, 1MB by
+	// default. Larger requests sent with a key fail with a 413.
+	MaxBytes int64
+}
+
+// Idempotency makes POST and PATCH requests sent with an Idempotency-Key header safe to retry. The
+// first response of each key is cached, without its cookies, and replayed for the retries of the
+// request with an Idempotent-Replayed header. Keys are scoped to the route and the principal.
+// Retries sent while the first request is still being handled are rejected with a 409, and keys
+// reused for a different request with a 422. Responses with a 5xx, panics and requests failed with
+// router.Fail aren't cached, so the request can be retried.
+//
+//	rr.Use(middleware.Idempotency(middleware.IdempotencyOptions{Store: redisStore}))
+func Idempotency(opts IdempotencyOptions) func(http.Handler) http.Handler {
+	store, ttl, lockTimeout := opts.Store, opts.TTL, opts.LockTimeout
+	if store == nil {
+		store = NewMemoryIdempotencyStore()
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	if lockTimeout <= 0 {
+		lockTimeout = time.Minute
+	}
+	principal := opts.Principal
+	if principal == nil {
+		principal = credentials
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 1 << 20
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get("Idempotency-Key")
+			if id == "" || r.Method != http.MethodPost && r.Method != http.MethodPatch {
+				next.ServeHTTP(w, r)
+				return
+			}
+			fingerprint, err := requestFingerprint(r, maxBytes)
+			if err != nil {
+				router.Fail(r, err)
+				return
+			}
+
+			// keys are scoped to the route, as clients generate them per request, and to the client
+			key := router.RoutePattern(r.Context()) + " " + principal(r) + " " + id
+			res, err := store.Get(r.Context(), key)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if res != nil {
+				replay(w, r, res, fingerprint)
+				return
+			}
+			locked, err := store.Lock(r.Context(), key, lockTimeout)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !locked {
+				router.Fail(r, router.NewError(http.StatusConflict, "idempotency_key_in_use", "a request with this idempotency key is being handled"))
+				return
+			}
+
+			tw := &teeWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				if !completed || tw.status >= 500 || router.RequestError(r.Context()) != nil {
+					store.Unlock(context.Background(), key)
+					return
+				}
+				store.Save(context.Background(), key, IdempotentResponse{
+					Fingerprint: fingerprint,
+					Status:      tw.status,
+					Header:      withoutCookies(tw.header),
+					Body:        tw.body.Bytes(),
+				}, ttl)
+			}()
+			next.ServeHTTP(wrap.Writer(tw, w), r)
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+				tw.header = w.Header().Clone()
+			}
+			completed = true
+		})
+	}
+}
+
+// replay writes the cached response, unless it answered a different request
+func replay(w http.ResponseWriter, r *http.Request, res *IdempotentResponse, fingerprint string) {
+	if res.Fingerprint != fingerprint {
+		router.Fail(r, router.NewError(http.StatusUnprocessableEntity, "idempotency_key_reused", "the idempotency key was used for a different request"))
+		return
+	}
+	for key, values := range withoutCookies(res.Header) {
+		w.Header()[key] = append([]string(nil), values...)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(res.Status)
+	w.Write(res.Body)
+}
+
+// credentials hashes the request's Authorization and Cookie headers, identifying the client that
+// sent it
+func credentials(r *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, r.Header.Get("Authorization")+"\n"+strings.Join(r.Header.Values("Cookie"), "; "))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// withoutCookies copies the header without the cookies it sets, which belong to a single client
+func withoutCookies(h http.Header) http.Header {
+	h = h.Clone()
+	h.Del("Set-Cookie")
+	return h
+}
+
+// requestFingerprint hashes the method, url and body of the request, of at most maxBytes, putting
+// the body back for the handler
+func requestFingerprint(r *http.Request, maxBytes int64) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		if err != nil {
+			return "", router.BadRequest(err)
+		}
+		if int64(len(body)) > maxBytes {
+			return "", router.NewError(http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("body must not be larger than %d bytes", maxBytes))
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// memoryIdempotencyStore is an IdempotencyStore local to the process
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	res     *IdempotentResponse
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates an IdempotencyStore held in memory, suitable for a single
+// instance
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{entries: make(map[string]*idempotencyEntry)}
+}
+
+func (s *memoryIdempotencyStore) Get(c context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entry(key); e != nil {
+		return e.res, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Lock(c context.Context, key string, timeout time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(timeout)
+	if s.entry(key) != nil {
+		return false, nil
+	}
+	s.entries[key] = &idempotencyEntry{expires: now().Add(timeout)}
+	return true, nil
+}
+
+func (s *memoryIdempotencyStore) Save(c context.Context, key string, res IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = &idempotencyEntry{res: &res, expires: now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Unlock(c context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entries[key]; e != nil && e.res == nil {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// entry returns the unexpired entry of the key, removing it once it has expired
+func (s *memoryIdempotencyStore) entry(key string) *idempotencyEntry {
+	e := s.entries[key]
+	if e != nil && !now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+// sweep removes the expired entries at most once per ttl
+func (s *memoryIdempotencyStore) sweep(ttl time.Duration) {
+	t := now()
+	if t.Sub(s.lastSweep) < ttl {
+		return
+	}
+	s.lastSweep = t
+	for key, e := range s.entries {
+		if !t.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestIdempotency(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	calls := 0
+	status := http.StatusCreated
+	rr := router.New("/")
+	rr.Use(Idempotency(IdempotencyOptions{TTL: time.Hour}))
+	rr.Post("/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", fmt.Sprintf("/payments/%d", calls))
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "payment %d", calls)
+	})
+
+	pay := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := pay("k1", `{"amount":10}`)
+	retry := pay("k1", `{"amount":10}`)
+	if calls != 1 || retry.Code != http.StatusCreated || retry.Body.String() != "payment 1" || retry.Header().Get("Location") != "/payments/1" {
+		t.Errorf("retry should be replayed, got %d %q after %d calls", retry.Code, retry.Body.String(), calls)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("only replays should be marked")
+	}
+
+	if rec := pay("k1", `{"amount":20}`); rec.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("reused key should be rejected, got %d", rec.Code)
+	}
+	if rec := pay("", `{"amount":10}`); rec.Body.String() != "payment 2" {
+		t.Errorf("requests without a key should always be handled, got %q", rec.Body.String())
+	}
+
+	// server errors aren't cached, so the request can be retried
+	status = http.StatusServiceUnavailable
+	pay("k2", "{}")
+	status = http.StatusCreated
+	if rec := pay("k2", "{}"); rec.Code != http.StatusCreated || rec.Body.String() != "payment 4" {
+		t.Errorf("failed request should be retried, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// keys expire after the ttl
+	clock = clock.Add(2 * time.Hour)
+	if rec := pay("k1", `{"amount":10}`); rec.Body.String() != "payment 5" {
+		t.Errorf("expired key should be handled again, got %q", rec.Body.String())
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	rr := router.New("/")
+	rr.Use(Idempotency(IdempotencyOptions{TTL: time.Hour}))
+	rr.Patch("/orders/:id", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	rr.Post("/orders/:id/refund", func(w http.ResponseWriter, r *http.Request) {})
+
+	done := make(chan struct{})
+	go func() {
+		req := httptest.NewRequest("PATCH", "/orders/1", nil)
+		req.Header.Set("Idempotency-Key", "k")
+		rr.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	<-started
+
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"PATCH", "/orders/1", http.StatusConflict},
+		// keys are scoped to the route
+		{"POST", "/orders/1/refund", http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%s %s: invalid status %d", test.method, test.path, rec.Code)
+		}
+	}
+	close(release)
+	<-done
+}
+
+func TestIdempotencyScopedToClient(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	calls := 0
+	rr := router.New("/")
+	rr.Use(Idempotency(IdempotencyOptions{}))
+	rr.Post("/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: r.Header.Get("Authorization")})
+		fmt.Fprintf(w, "payment %d for %s", calls, r.Header.Get("Authorization"))
+	})
+	pay := func(auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/payments", strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "k")
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	pay("alice")
+	if rec := pay("bob"); rec.Body.String() != "payment 2 for bob" {
+		t.Errorf("clients sending the same key should be handled separately, got %q", rec.Body.String())
+	}
+	retry := pay("alice")
+	if retry.Body.String() != "payment 1 for alice" || retry.Header().Get("Set-Cookie") != "" {
+		t.Errorf("replays should not set cookies, got %q %v", retry.Body.String(), retry.Header())
+	}
+}
+
+func TestIdempotencyLockTimeout(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	store := NewMemoryIdempotencyStore()
+	// an instance that died mid-request leaves its key locked
+	if ok, _ := store.Lock(context.Background(), "/payments "+credentials(httptest.NewRequest("POST", "/", nil))+" k", time.Minute); !ok {
+		t.Fatal("key should be locked")
+	}
+	rr := router.New("/")
+	rr.Use(Idempotency(IdempotencyOptions{Store: store}))
+	rr.Post("/payments", func(w http.ResponseWriter, r *http.Request) {})
+
+	pay := func() int {
+		req := httptest.NewRequest("POST", "/payments", nil)
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if status := pay(); status != http.StatusConflict {
+		t.Errorf("locked key should be rejected, got %d", status)
+	}
+	clock = clock.Add(2 * time.Minute)
+	if status := pay(); status != http.StatusOK {
+		t.Errorf("lock should time out well before the ttl, got %d", status)
+	}
+}
+
+func TestIdempotencyEarlyHints(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	h := Idempotency(IdempotencyOptions{Store: store})(http.HandlerFunc(hinted))
+	header := http.Header{"Idempotency-Key": {"k1"}}
+	for i := 0; i < 2; i++ {
+		if _, status, body := serveHinted(t, h, "POST", header); status != http.StatusCreated || body != "created" {
+			t.Errorf("%d: the final status should be saved, got %d %q", i, status, body)
+		}
+	}
+}
+
+func TestIdempotencyMaxBytes(t *testing.T) {
+	calls := 0
+	rr := router.New("/")
+	rr.Use(Idempotency(IdempotencyOptions{MaxBytes: 8}))
+	rr.Post("/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	req := httptest.NewRequest("POST", "/payments", strings.NewReader(`{"amount":10}`))
+	req.Header.Set("Idempotency-Key", "k1")
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Errorf("bodies over the limit should be refused, got %d after %d calls", rec.Code, calls)
+	}
+}
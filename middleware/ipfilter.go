@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/chrisolsen/router"
+)
+
+// IPFilterOptions are the client addresses allowed to reach the routes, given as IPs or CIDRs.
+// Clients are identified by the address resolved by RealIP, or the immediate peer without it.
+type IPFilterOptions struct {
+	// Allow, when set, only lets the listed addresses through
+	Allow []string
+
+	// Deny rejects the listed addresses, even those that are allowed
+	Deny []string
+
+	// OnReject responds to rejected requests, a 403 by default
+	OnReject http.HandlerFunc
+}
+
+// IPFilter rejects requests from clients that aren't allowed by the options. Applied to a router it
+// covers all its routes, while a single route attaches the options as a policy, further restricting
+// who can reach it. It panics if an address can't be parsed.
+//
+//	rr.Before(middleware.RealIP(proxies), middleware.IPFilter(middleware.IPFilterOptions{
+//		Deny: abusers,
+//	}))
+//	rr.Get("/admin", admin).Policy(middleware.IPFilterOptions{Allow: []string{"10.0.0.0/8"}})
+func IPFilter(opts IPFilterOptions) http.HandlerFunc {
+	f := newIPFilter(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !f.allows(r) {
+			f.reject(w, r)
+			router.HaltRequest(r)
+		}
+	}
+}
+
+// Middleware implements router.Policy
+func (opts IPFilterOptions) Middleware() func(http.Handler) http.Handler {
+	f := newIPFilter(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.allows(r) {
+				f.reject(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ipFilter holds the parsed networks of the options
+type ipFilter struct {
+	allow, deny []*net.IPNet
+	onReject    http.HandlerFunc
+}
+
+func newIPFilter(opts IPFilterOptions) *ipFilter {
+	return &ipFilter{
+		allow:    parseNetworks(opts.Allow, "allowed address"),
+		deny:     parseNetworks(opts.Deny, "denied address"),
+		onReject: opts.OnReject,
+	}
+}
+
+// allows reports whether the client's address passes the filter. Requests whose address can't be
+// parsed are only let through when there's no allow list.
+func (f *ipFilter) allows(r *http.Request) bool {
+	ip := net.ParseIP(remoteAddr(r))
+	if ip == nil {
+		return len(f.allow) == 0
+	}
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, ip)
+}
+
+func (f *ipFilter) reject(w http.ResponseWriter, r *http.Request) {
+	if f.onReject != nil {
+		f.onReject(w, r)
+		return
+	}
+	router.Fail(r, router.NewError(http.StatusForbidden, "ip_not_allowed", "ip address not allowed"))
+}
+
+// parseNetworks parses the IPs and CIDRs, panicking if one is invalid
+func parseNetworks(addrs []string, what string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(addrs))
+	for _, addr := range addrs {
+		cidr := addr
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("middleware: invalid " + what + " " + addr)
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestIPFilter(t *testing.T) {
+	rr := router.New("/")
+	rr.Before(RealIP([]string{"10.0.0.1"}), IPFilter(IPFilterOptions{
+		Allow: []string{"192.168.0.0/16", "2001:db8::/32"},
+		Deny:  []string{"192.168.1.0/24", "192.168.2.5"},
+	}))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		desc      string
+		peer      string
+		forwarded string
+		status    int
+	}{
+		{"allowed", "192.168.0.1:1234", "", 200},
+		{"allowed ipv6", "[2001:db8::1]:1234", "", 200},
+		{"not allowed", "172.16.0.1:1234", "", 403},
+		{"denied network", "192.168.1.20:1234", "", 403},
+		{"denied ip", "192.168.2.5:1234", "", 403},
+		{"allowed behind proxy", "10.0.0.1:1234", "192.168.0.1", 200},
+		{"denied behind proxy", "10.0.0.1:1234", "192.168.1.20", 403},
+		{"spoofed", "172.16.0.1:1234", "192.168.0.1", 403},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.peer
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.desc, w.Code)
+		}
+	}
+}
+
+func TestIPFilterPolicy(t *testing.T) {
+	called := false
+	rr := router.New("/")
+	rr.Before(IPFilter(IPFilterOptions{Deny: []string{"172.16.0.0/12"}}))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/admin", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}).Policy(IPFilterOptions{
+		Allow: []string{"10.0.0.0/8"},
+		OnReject: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "admins only", http.StatusNotFound)
+		},
+	})
+
+	tests := []struct {
+		path   string
+		peer   string
+		status int
+	}{
+		{"/", "192.168.0.1:1234", 200},
+		{"/", "172.16.0.1:1234", 403},
+		{"/admin", "10.0.0.1:1234", 200},
+		{"/admin", "192.168.0.1:1234", 404},
+		{"/admin", "172.16.0.1:1234", 403},
+	}
+	for _, test := range tests {
+		called = false
+		r, _ := http.NewRequest("GET", test.path, nil)
+		r.RemoteAddr = test.peer
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s %s: invalid status %d", test.path, test.peer, w.Code)
+		}
+		if called != (test.status == 200 && test.path == "/admin") {
+			t.Errorf("%s %s: handler called %v", test.path, test.peer, called)
+		}
+	}
+}
+
+func TestIPFilterInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid addresses should panic")
+		}
+	}()
+	IPFilter(IPFilterOptions{Allow: []string{"10.0.0.0/33"}})
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+var claimsCtxKey = router.NewKey[JWTClaims]("claims")
+
+var (
+	errMissingToken = errors.New("jwt: missing token")
+	errInvalidToken = errors.New("jwt: invalid token")
+	errExpiredToken = errors.New("jwt: token expired")
+)
+
+// JWTOptions configures how tokens are found and validated
+type JWTOptions struct {
+	// Key is the []byte secret used for HS256/384/512 tokens or the *rsa.PublicKey
+	// used for RS256/384/512 tokens
+	Key interface{}
+
+	// Cookie is the name of the cookie to read the token from when the Authorization header is missing
+	Cookie string
+
+	// Query is the name of the query param to read the token from when the Authorization header is missing
+	Query string
+
+	// Leeway allows for clock skew when validating the exp and nbf claims
+	Leeway time.Duration
+}
+
+// JWTClaims are the decoded claims of a validated token
+type JWTClaims map[string]interface{}
+
+// JWT validates the bearer token's signature and expiry, binding the token's claims to the
+// request's context. Requests without a valid token are halted with a 401.
+func JWT(opts JWTOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := parseJWT(jwtToken(r, opts), opts.Key, opts.Leeway)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			router.HaltRequest(r)
+			return
+		}
+		router.BindContext(router.Set(r.Context(), claimsCtxKey, claims), r)
+	}
+}
+
+// Claims retrieves the claims of the token validated by the JWT middleware
+func Claims(c context.Context) JWTClaims {
+	claims, _ := router.Get(c, claimsCtxKey)
+	return claims
+}
+
+// jwtToken finds the token within the header, cookie or query param
+func jwtToken(r *http.Request, opts JWTOptions) string {
+	if header := r.Header.Get("Authorization"); len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return header[len("Bearer "):]
+	}
+	if opts.Cookie != "" {
+		if cookie, err := r.Cookie(opts.Cookie); err == nil {
+			return cookie.Value
+		}
+	}
+	if opts.Query != "" {
+		return r.URL.Query().Get(opts.Query)
+	}
+	return ""
+}
+
+func parseJWT(token string, key interface{}, leeway time.Duration) (JWTClaims, error) {
+	if token == "" {
+		return nil, errMissingToken
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	if err := verifySignature(header.Alg, parts[0]+"."+parts[1], signature, key); err != nil {
+		return nil, err
+	}
+
+	var claims JWTClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errInvalidToken
+	}
+	now := time.Now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return nil, errExpiredToken
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
+func decodeSegment(seg string, dst interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func verifySignature(alg, signed string, signature []byte, key interface{}) error {
+	if len(alg) != 5 {
+		return errInvalidToken
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return errInvalidToken
+	}
+
+	switch {
+	case strings.HasPrefix(alg, "HS"):
+		secret, ok := key.([]byte)
+		if !ok {
+			return errInvalidToken
+		}
+		var mac = hmac.New(sha256.New, secret)
+		switch hash {
+		case crypto.SHA384:
+			mac = hmac.New(sha512.New384, secret)
+		case crypto.SHA512:
+			mac = hmac.New(sha512.New, secret)
+		}
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errInvalidToken
+		}
+		return nil
+	case strings.HasPrefix(alg, "RS"):
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errInvalidToken
+		}
+		h := hash.New()
+		h.Write([]byte(signed))
+		if rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), signature) != nil {
+			return errInvalidToken
+		}
+		return nil
+	}
+	return errInvalidToken
+}
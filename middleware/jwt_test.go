@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var jwtSecret = []byte("secret")
+
+func signHS256(payload string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	body := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte(header + "." + body))
+	return header + "." + body + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTValidToken(t *testing.T) {
+	token := signHS256(`{"sub":"1234","exp":9999999999}`)
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+
+	JWT(JWTOptions{Key: jwtSecret})(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Error("Invalid response status: ", w.Code)
+		return
+	}
+	if r.Context().Err() != nil {
+		t.Error("request should not be halted")
+		return
+	}
+	if Claims(r.Context())["sub"] != "1234" {
+		t.Error("claims not set in context")
+	}
+}
+
+func TestJWTTokenSources(t *testing.T) {
+	token := signHS256(`{"sub":"1234"}`)
+
+	r, _ := http.NewRequest("GET", "/?token="+token, nil)
+	w := httptest.NewRecorder()
+	JWT(JWTOptions{Key: jwtSecret, Query: "token"})(w, r)
+	if Claims(r.Context()) == nil {
+		t.Error("query token not found")
+	}
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "jwt", Value: token})
+	w = httptest.NewRecorder()
+	JWT(JWTOptions{Key: jwtSecret, Cookie: "jwt"})(w, r)
+	if Claims(r.Context()) == nil {
+		t.Error("cookie token not found")
+	}
+}
+
+func TestJWTInvalidTokens(t *testing.T) {
+	expired := time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		desc  string
+		token string
+	}{
+		{desc: "missing token"},
+		{desc: "malformed token", token: "abc.def"},
+		{desc: "bad signature", token: signHS256(`{"sub":"1234"}`) + "x"},
+		{desc: "expired", token: signHS256(fmt.Sprintf(`{"exp":%d}`, expired))},
+		{desc: "not yet valid", token: signHS256(`{"nbf":9999999999}`)},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		if test.token != "" {
+			r.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		w := httptest.NewRecorder()
+
+		JWT(JWTOptions{Key: jwtSecret})(w, r)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: invalid response status %d", test.desc, w.Code)
+		}
+		if r.Context().Err() == nil {
+			t.Errorf("%s: request should be halted", test.desc)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/chrisolsen/router"
+)
+
+// Language negotiates the request's language from the supported language tags, passing it on
+// within the request's context for Lang and router.T. The locale of the url's prefix, with
+// router.LocalePrefixes, is preferred, then a supported tag given by the `lang` query param or
+// cookie, and then the Accept-Language header, whose ranges are tried in order of their quality,
+// ex. `fr-CA` matches a supported `fr` and `fr` a supported `fr-CA`. The first supported tag is used
+// when nothing matches. The tag is sent as the response's Content-Language. It's applied with Use,
+// so it runs once the router has removed the locale prefix. It panics without supported tags.
+//
+//	rr.Use(middleware.Language([]string{"en", "fr", "fr-CA"}))
+func Language(supported []string) func(http.Handler) http.Handler {
+	if len(supported) == 0 {
+		panic("middleware: no supported languages")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+			lang := negotiateRequestLanguage(r, supported)
+			w.Header().Set("Content-Language", lang)
+			next.ServeHTTP(w, r.WithContext(router.WithLocale(r.Context(), lang)))
+		})
+	}
+}
+
+// negotiateRequestLanguage picks the supported tag of the url's prefix, the `lang` query param or
+// cookie, or the Accept-Language header, in that order
+func negotiateRequestLanguage(r *http.Request, supported []string) string {
+	lang := matchLanguage(router.PathLocale(r.Context()), supported)
+	if lang == "" {
+		lang = matchLanguage(r.URL.Query().Get("lang"), supported)
+	}
+	if lang == "" {
+		if cookie, err := r.Cookie("lang"); err == nil {
+			lang = matchLanguage(cookie.Value, supported)
+		}
+	}
+	if lang == "" {
+		lang = negotiateLanguage(r.Header.Get("Accept-Language"), supported)
+	}
+	return lang
+}
+
+// Lang retrieves the language tag negotiated by the Language middleware, empty if it wasn't used
+func Lang(c context.Context) string {
+	return router.Locale(c)
+}
+
+// negotiateLanguage picks the supported tag best matching the Accept-Language header's ranges,
+// falling back on the first supported tag
+func negotiateLanguage(header string, supported []string) string {
+	type langRange struct {
+		tag string
+		q   float64
+	}
+	var ranges []langRange
+	for _, part := range strings.Split(header, ",") {
+		tag, q := parseQuality(part)
+		if tag != "" && q > 0 {
+			ranges = append(ranges, langRange{tag, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, rg := range ranges {
+		if rg.tag == "*" {
+			break
+		}
+		if lang := matchLanguage(rg.tag, supported); lang != "" {
+			return lang
+		}
+	}
+	return supported[0]
+}
+
+// matchLanguage finds the supported tag matching the language range, trying an exact match, then a
+// tag within the range (`fr-CA` for `fr`), then the range's base language (`fr` for `fr-CA`)
+func matchLanguage(tag string, supported []string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if tag == "" {
+		return ""
+	}
+	for _, s := range supported {
+		if strings.EqualFold(s, tag) {
+			return s
+		}
+	}
+	for _, s := range supported {
+		if strings.HasPrefix(strings.ToLower(s), tag+"-") {
+			return s
+		}
+	}
+	if i := strings.Index(tag, "-"); i > 0 {
+		for _, s := range supported {
+			if strings.EqualFold(s, tag[:i]) {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestLanguage(t *testing.T) {
+	catalog := router.NewCatalog("en")
+	catalog.Add("en", map[string]string{"hello": "Hello"})
+	catalog.Add("fr", map[string]string{"hello": "Bonjour"})
+
+	rr := router.New("/")
+	rr.Use(Language([]string{"en", "fr", "pt-BR"}))
+	rr.Before(router.UseCatalog(catalog))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Lang(r.Context()) + " " + router.T(r.Context(), "hello")))
+	})
+
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		cookie string
+		lang   string
+	}{
+		{"no header", "/", "", "", "en"},
+		{"exact", "/", "fr", "", "fr"},
+		{"quality", "/", "en;q=0.5, fr;q=0.8", "", "fr"},
+		{"base language", "/", "fr-CA", "", "fr"},
+		{"region", "/", "pt", "", "pt-BR"},
+		{"case", "/", "PT-br", "", "pt-BR"},
+		{"unsupported", "/", "de, ja;q=0.9", "", "en"},
+		{"unsupported fallback range", "/", "de, fr;q=0.1", "", "fr"},
+		{"excluded", "/", "fr;q=0, en;q=0.1", "", "en"},
+		{"wildcard", "/", "de, *;q=0.5, fr;q=0.1", "", "en"},
+		{"cookie", "/", "en", "fr", "fr"},
+		{"query", "/?lang=pt_BR", "en", "fr", "pt-BR"},
+		{"unsupported override", "/?lang=de", "fr", "ja", "fr"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept-Language", test.accept)
+		}
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: test.cookie})
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		hello := map[string]string{"en": "Hello", "fr": "Bonjour", "pt-BR": "Hello"}[test.lang]
+		if body := rec.Body.String(); body != test.lang+" "+hello {
+			t.Errorf("%s: invalid response %q", test.name, body)
+		}
+		if rec.Header().Get("Content-Language") != test.lang || rec.Header().Get("Vary") != "Accept-Language" {
+			t.Errorf("%s: invalid headers %v", test.name, rec.Header())
+		}
+	}
+}
+
+func TestLanguagePathLocale(t *testing.T) {
+	rr := router.New("/")
+	rr.LocalePrefixes(router.LocaleOptions{Locales: []string{"fr"}, Default: "en"})
+	rr.Use(Language([]string{"en", "fr"}))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Lang(r.Context())))
+	})
+
+	for path, lang := range map[string]string{"/fr/?lang=en": "fr", "/?lang=fr": "fr", "/": "en"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Body.String() != lang {
+			t.Errorf("%s: invalid language %q", path, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideOptions configures the methods that POST requests can be overridden to
+type MethodOverrideOptions struct {
+	// Header holds the method, X-HTTP-Method-Override by default
+	Header string
+
+	// Field holds the method within POSTed forms, `_method` by default. Forms are only parsed when
+	// the header isn't set.
+	Field string
+
+	// Methods are the methods requests can be overridden to, PUT, PATCH and DELETE by default.
+	// Overrides to other methods are ignored.
+	Methods []string
+}
+
+// MethodOverride wraps the router, allowing clients limited to GET and POST, such as HTML forms,
+// to make requests with other methods. Only POST requests are overridden, and the caller's request
+// is left unchanged.
+//
+//	h := middleware.MethodOverride(middleware.MethodOverrideOptions{Methods: []string{"PATCH", "DELETE"}})(rr)
+func MethodOverride(opts MethodOverrideOptions) func(http.Handler) http.Handler {
+	if opts.Header == "" {
+		opts.Header = "X-HTTP-Method-Override"
+	}
+	if opts.Field == "" {
+		opts.Field = "_method"
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	allowed := make(map[string]bool, len(opts.Methods))
+	for _, m := range opts.Methods {
+		allowed[strings.ToUpper(m)] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				method := r.Header.Get(opts.Header)
+				if method == "" && isForm(r) {
+					method = r.PostFormValue(opts.Field)
+				}
+				if method = strings.ToUpper(strings.TrimSpace(method)); allowed[method] {
+					overridden := *r
+					overridden.Method = method
+					r = &overridden
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isForm reports whether the request's body is a url encoded or multipart form
+func isForm(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mt == "application/x-www-form-urlencoded" || mt == "multipart/form-data")
+}
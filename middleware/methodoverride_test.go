@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestMethodOverride(t *testing.T) {
+	rr := router.New("/")
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		method := method
+		rr.HandleFunc(method, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(method))
+		})
+	}
+	h := MethodOverride(MethodOverrideOptions{Methods: []string{"patch", "DELETE"}})(rr)
+
+	form := func(contentType, field string) (string, string) {
+		if contentType == "multipart/form-data" {
+			return "multipart/form-data; boundary=xx", "--xx\r\nContent-Disposition: form-data; name=\"_method\"\r\n\r\n" + field + "\r\n--xx--\r\n"
+		}
+		return contentType, url.Values{"_method": {field}}.Encode()
+	}
+
+	tests := []struct {
+		desc        string
+		method      string
+		header      string
+		contentType string
+		field       string
+		expected    string
+	}{
+		{"header", "POST", "PATCH", "", "", "PATCH"},
+		{"lower case header", "POST", "delete", "", "", "DELETE"},
+		{"form field", "POST", "", "application/x-www-form-urlencoded", "DELETE", "DELETE"},
+		{"multipart field", "POST", "", "multipart/form-data", "patch", "PATCH"},
+		{"header before field", "POST", "PATCH", "application/x-www-form-urlencoded", "DELETE", "PATCH"},
+		{"not allowed", "POST", "PUT", "", "", "POST"},
+		{"not a post", "GET", "DELETE", "", "", "GET"},
+		{"json body ignored", "POST", "", "application/json", "DELETE", "POST"},
+		{"no override", "POST", "", "application/x-www-form-urlencoded", "", "POST"},
+	}
+	for _, test := range tests {
+		var body string
+		contentType := test.contentType
+		if contentType != "" {
+			contentType, body = form(contentType, test.field)
+		}
+		r, _ := http.NewRequest(test.method, "/users/1", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		if test.header != "" {
+			r.Header.Set("X-HTTP-Method-Override", test.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Body.String() != test.expected {
+			t.Errorf("%s: served by %s, expected %s", test.desc, w.Body.String(), test.expected)
+		}
+		if r.Method != test.method {
+			t.Errorf("%s: the caller's request should be left unchanged, got %s", test.desc, r.Method)
+		}
+	}
+}
+
+func TestRouterIgnoresMethodField(t *testing.T) {
+	rr := router.New("/")
+	rr.Post("/users/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Form != nil {
+			t.Error("the router should not parse the body")
+		}
+		w.Write([]byte("POST"))
+	})
+	r, _ := http.NewRequest("POST", "/users/1", strings.NewReader("_method=DELETE"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+	if w.Body.String() != "POST" {
+		t.Errorf("the router should not override methods, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+// now is swapped out within the tests
+var now = time.Now
+
+// Limit allows a number of requests within each window. A limit of zero or fewer requests is unlimited.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// RateLimit limits the requests made by each key, halting requests over the limit with a 429.
+// Requests are keyed by the client's address when key is nil, as resolved by RealIP if it runs first.
+func RateLimit(limit Limit, key func(r *http.Request) string) http.HandlerFunc {
+	if key == nil {
+		key = remoteAddr
+	}
+	l := newLimiter()
+	return func(w http.ResponseWriter, r *http.Request) {
+		l.limit(w, r, key(r), limit)
+	}
+}
+
+// RateLimitConfig limits requests like RateLimit, loading the limit from the config on each
+// request so it can be changed with UpdateConfig while serving. Requests are unlimited until the
+// config holds a Limit.
+func RateLimitConfig(cfg *router.Config, key func(r *http.Request) string) http.HandlerFunc {
+	if key == nil {
+		key = remoteAddr
+	}
+	l := newLimiter()
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := cfg.Load().(Limit)
+		l.limit(w, r, key(r), limit)
+	}
+}
+
+// TierOptions configures the limits applied to each tier of principal
+type TierOptions struct {
+	// Tier resolves the tier of the request's principal, ex. free, pro or enterprise
+	Tier func(r *http.Request) string
+
+	// Limits are the limits of each tier
+	Limits map[string]Limit
+
+	// Default is the tier used when the resolved tier has no limit
+	Default string
+
+	// Key identifies the principal, defaulting to the client's address
+	Key func(r *http.Request) string
+}
+
+// TieredRateLimit limits the requests of each principal according to the limit of their tier. The
+// active tier is sent in the X-RateLimit-Tier header.
+func TieredRateLimit(opts TierOptions) http.HandlerFunc {
+	if opts.Key == nil {
+		opts.Key = remoteAddr
+	}
+	l := newLimiter()
+	return func(w http.ResponseWriter, r *http.Request) {
+		tier := opts.Tier(r)
+		limit, ok := opts.Limits[tier]
+		if !ok {
+			tier = opts.Default
+			limit = opts.Limits[tier]
+		}
+		w.Header().Set("X-RateLimit-Tier", tier)
+
+		// the tier is part of the key so a principal changing tiers starts with a fresh window
+		l.limit(w, r, tier+":"+opts.Key(r), limit)
+	}
+}
+
+// remoteAddr returns the client's address resolved by RealIP, or the address of the immediate peer
+func remoteAddr(r *http.Request) string {
+	if ip := ClientIP(r.Context()); ip != "" {
+		return ip
+	}
+	return peerAddr(r)
+}
+
+// peerAddr returns the address of the immediate peer, which may be a proxy
+func peerAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// limiter counts requests within fixed windows
+type limiter struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+type window struct {
+	start time.Time
+	count int
+	size  time.Duration
+}
+
+func newLimiter() *limiter {
+	return &limiter{windows: make(map[string]*window)}
+}
+
+// limit counts the request, setting the rate limit headers and halting the request if over the limit
+func (l *limiter) limit(w http.ResponseWriter, r *http.Request, key string, limit Limit) {
+	if limit.Requests <= 0 {
+		return
+	}
+	remaining, reset, ok := l.allow(key, limit, now())
+
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	if !ok {
+		retry := int(reset.Sub(now()).Seconds() + 0.5)
+		if retry < 1 {
+			retry = 1
+		}
+		h.Set("Retry-After", strconv.Itoa(retry))
+		w.WriteHeader(http.StatusTooManyRequests)
+		router.HaltRequest(r)
+	}
+}
+
+func (l *limiter) allow(key string, limit Limit, t time.Time) (remaining int, reset time.Time, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(t, limit.Window)
+	win := l.windows[key]
+	if win == nil || !t.Before(win.start.Add(win.size)) {
+		win = &window{start: t, size: limit.Window}
+		l.windows[key] = win
+	}
+	reset = win.start.Add(win.size)
+	if win.count >= limit.Requests {
+		return 0, reset, false
+	}
+	win.count++
+	return limit.Requests - win.count, reset, true
+}
+
+// sweep removes the expired windows at most once per window, preventing unbounded growth from
+// one-off clients
+func (l *limiter) sweep(t time.Time, size time.Duration) {
+	if t.Sub(l.lastSweep) < size {
+		return
+	}
+	l.lastSweep = t
+	for key, win := range l.windows {
+		if !t.Before(win.start.Add(win.size)) {
+			delete(l.windows, key)
+		}
+	}
+}
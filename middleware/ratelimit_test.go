@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestRateLimit(t *testing.T) {
+	defer func() { now = time.Now }()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+
+	mw := RateLimit(Limit{Requests: 2, Window: time.Minute}, nil)
+	tests := []struct {
+		addr           string
+		elapsed        time.Duration
+		expectedStatus int
+		remaining      string
+	}{
+		{"1.1.1.1:1000", 0, 200, "1"},
+		{"1.1.1.1:1001", time.Second, 200, "0"},
+		{"1.1.1.1:1002", 2 * time.Second, 429, "0"},
+		{"2.2.2.2:1000", 2 * time.Second, 200, "1"},
+		{"1.1.1.1:1003", time.Minute, 200, "1"},
+	}
+	for _, test := range tests {
+		now = func() time.Time { return start.Add(test.elapsed) }
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.addr
+		w := httptest.NewRecorder()
+		mw(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("%s +%s: invalid status %d != %d", test.addr, test.elapsed, w.Code, test.expectedStatus)
+		}
+		if rem := w.Header().Get("X-RateLimit-Remaining"); rem != test.remaining {
+			t.Errorf("%s +%s: invalid remaining %s != %s", test.addr, test.elapsed, rem, test.remaining)
+		}
+		if test.expectedStatus == 429 {
+			if r.Context().Err() == nil {
+				t.Error("request should be halted")
+			}
+			if w.Header().Get("Retry-After") != "58" {
+				t.Errorf("invalid Retry-After %s", w.Header().Get("Retry-After"))
+			}
+		}
+	}
+}
+
+func TestTieredRateLimit(t *testing.T) {
+	mw := TieredRateLimit(TierOptions{
+		Tier: func(r *http.Request) string { return r.Header.Get("X-Tier") },
+		Limits: map[string]Limit{
+			"free":       {Requests: 1, Window: time.Hour},
+			"pro":        {Requests: 3, Window: time.Hour},
+			"enterprise": {},
+		},
+		Default: "free",
+		Key:     func(r *http.Request) string { return r.Header.Get("X-User") },
+	})
+
+	tests := []struct {
+		user           string
+		tier           string
+		expectedTier   string
+		expectedStatus int
+	}{
+		{"a", "free", "free", 200},
+		{"a", "free", "free", 429},
+		{"b", "unknown", "free", 200},
+		{"b", "", "free", 429},
+		{"a", "pro", "pro", 200},
+		{"a", "pro", "pro", 200},
+		{"c", "enterprise", "enterprise", 200},
+		{"c", "enterprise", "enterprise", 200},
+	}
+	for i, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.Header.Set("X-User", test.user)
+		r.Header.Set("X-Tier", test.tier)
+		w := httptest.NewRecorder()
+		mw(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("%d: invalid status %d != %d", i, w.Code, test.expectedStatus)
+		}
+		if tier := w.Header().Get("X-RateLimit-Tier"); tier != test.expectedTier {
+			t.Errorf("%d: invalid tier %s != %s", i, tier, test.expectedTier)
+		}
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	defer func() { now = time.Now }()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+
+	rr := router.New("/")
+	mw := RateLimitConfig(rr.Config("ratelimit"), nil)
+	request := func() int {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "1.1.1.1:1000"
+		w := httptest.NewRecorder()
+		mw(w, r)
+		return w.Code
+	}
+
+	if code := request(); code != 200 {
+		t.Errorf("unconfigured limit: invalid status %d", code)
+	}
+	rr.UpdateConfig("ratelimit", Limit{Requests: 1, Window: time.Minute})
+	request()
+	if code := request(); code != 429 {
+		t.Errorf("updated limit: invalid status %d", code)
+	}
+	rr.UpdateConfig("ratelimit", Limit{Requests: 5, Window: time.Minute})
+	if code := request(); code != 200 {
+		t.Errorf("raised limit: invalid status %d", code)
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/chrisolsen/router"
+)
+
+var clientIPCtxKey = router.NewKey[string]("clientip")
+
+// RealIP resolves the client's address from the Forwarded, X-Forwarded-For and X-Real-IP headers,
+// in that order of preference, binding it to the context for ClientIP. The headers are only trusted
+// when the immediate peer is one of the trusted proxies, given as IPs or CIDRs, otherwise the peer's
+// address is used. Chains of proxies are walked from the nearest, the client being the first
+// address that isn't a trusted proxy. It panics if a trusted proxy can't be parsed.
+//
+//	rr.Before(middleware.RealIP([]string{"10.0.0.0/8", "127.0.0.1"}))
+func RealIP(trustedProxies []string) http.HandlerFunc {
+	trusted := parseNetworks(trustedProxies, "trusted proxy")
+	isTrusted := func(ip net.IP) bool {
+		return containsIP(trusted, ip)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		peer := net.ParseIP(peerAddr(r))
+		ip := peer
+		if peer != nil && isTrusted(peer) {
+			if client := walkChain(forwardedChain(r.Header), isTrusted); client != nil {
+				ip = client
+			}
+		}
+		if ip == nil {
+			return
+		}
+		router.BindContext(router.Set(r.Context(), clientIPCtxKey, ip.String()), r)
+	}
+}
+
+// ClientIP retrieves the client's address resolved by the RealIP middleware, empty if it wasn't used
+func ClientIP(c context.Context) string {
+	ip, _ := router.Get(c, clientIPCtxKey)
+	return ip
+}
+
+// forwardedChain returns the addresses of the proxy chain, client first, from the most preferred
+// header present. A nil address marks an obfuscated or unparseable hop.
+func forwardedChain(h http.Header) []net.IP {
+	if values := h.Values("Forwarded"); len(values) > 0 {
+		var chain []net.IP
+		for _, element := range strings.Split(strings.Join(values, ","), ",") {
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					chain = append(chain, parseNode(kv[1]))
+				}
+			}
+		}
+		return chain
+	}
+	if values := h.Values("X-Forwarded-For"); len(values) > 0 {
+		var chain []net.IP
+		for _, addr := range strings.Split(strings.Join(values, ","), ",") {
+			chain = append(chain, parseNode(addr))
+		}
+		return chain
+	}
+	if v := h.Get("X-Real-IP"); v != "" {
+		return []net.IP{parseNode(v)}
+	}
+	return nil
+}
+
+// parseNode parses an address, which may be quoted, bracketed or include a port
+func parseNode(node string) net.IP {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	return net.ParseIP(strings.Trim(node, "[]"))
+}
+
+// walkChain returns the nearest address that isn't a trusted proxy, or the furthest address when
+// they are all trusted. Walking stops at a hop that can't be parsed, as nothing before it can be
+// trusted.
+func walkChain(chain []net.IP, isTrusted func(net.IP) bool) net.IP {
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := chain[i]
+		if ip == nil {
+			if i == len(chain)-1 {
+				return nil
+			}
+			return chain[i+1]
+		}
+		if !isTrusted(ip) || i == 0 {
+			return ip
+		}
+	}
+	return nil
+}
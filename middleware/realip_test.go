@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRealIP(t *testing.T) {
+	mw := RealIP([]string{"10.0.0.0/8", "127.0.0.1", "::1"})
+	tests := []struct {
+		desc     string
+		peer     string
+		headers  map[string]string
+		expected string
+	}{
+		{"untrusted peer", "1.1.1.1:1000", map[string]string{"X-Forwarded-For": "2.2.2.2"}, "1.1.1.1"},
+		{"no headers", "10.0.0.1:1000", nil, "10.0.0.1"},
+		{"x-forwarded-for", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "2.2.2.2"}, "2.2.2.2"},
+		{"spoofed chain", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "6.6.6.6, 2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		{"all trusted", "127.0.0.1:1000", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"x-real-ip", "[::1]:1000", map[string]string{"X-Real-IP": "2.2.2.2"}, "2.2.2.2"},
+		{"forwarded", "10.0.0.1:1000", map[string]string{
+			"Forwarded":       `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`,
+			"X-Forwarded-For": "3.3.3.3",
+		}, "2001:db8:cafe::17"},
+		{"obfuscated hop", "10.0.0.1:1000", map[string]string{"Forwarded": "for=_hidden, for=10.0.0.2"}, "10.0.0.2"},
+		{"malformed nearest hop", "10.0.0.1:1000", map[string]string{"X-Forwarded-For": "2.2.2.2, junk"}, "10.0.0.1"},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.peer
+		for k, v := range test.headers {
+			r.Header.Set(k, v)
+		}
+		mw(httptest.NewRecorder(), r)
+
+		if ip := ClientIP(r.Context()); ip != test.expected {
+			t.Errorf("%s: %q != %q", test.desc, ip, test.expected)
+		}
+	}
+}
+
+func TestRealIPRateLimit(t *testing.T) {
+	defer func() { now = time.Now }()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+
+	realIP := RealIP([]string{"10.0.0.1"})
+	limit := RateLimit(Limit{Requests: 1, Window: time.Minute}, nil)
+	for _, client := range []string{"2.2.2.2", "3.3.3.3"} {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1000"
+		r.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		realIP(w, r)
+		limit(w, r)
+		if w.Code != 200 {
+			t.Errorf("%s: clients behind the proxy should be limited separately", client)
+		}
+	}
+}
+
+func TestRealIPInvalidProxy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("invalid proxies should panic")
+		}
+	}()
+	RealIP([]string{"not-an-ip"})
+}
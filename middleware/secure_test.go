@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestSecure(t *testing.T) {
+	tests := []struct {
+		desc     string
+		opts     SecureOptions
+		https    bool
+		expected map[string]string
+	}{
+		{"defaults over https", DefaultSecureOptions, true, map[string]string{
+			"Strict-Transport-Security":           "max-age=31536000; includeSubDomains",
+			"X-Content-Type-Options":              "nosniff",
+			"X-Frame-Options":                     "DENY",
+			"Referrer-Policy":                     "strict-origin-when-cross-origin",
+			"Content-Security-Policy":             "default-src 'self'",
+			"Content-Security-Policy-Report-Only": "",
+		}},
+		{"no hsts over http", DefaultSecureOptions, false, map[string]string{
+			"Strict-Transport-Security": "",
+			"X-Frame-Options":           "DENY",
+		}},
+		{"preload", SecureOptions{HSTSMaxAge: time.Hour, HSTSPreload: true}, true, map[string]string{
+			"Strict-Transport-Security": "max-age=3600; preload",
+			"X-Content-Type-Options":    "",
+		}},
+		{"report only", SecureOptions{CSP: "script-src 'self'; report-uri /csp", CSPReportOnly: true}, false, map[string]string{
+			"Content-Security-Policy":             "",
+			"Content-Security-Policy-Report-Only": "script-src 'self'; report-uri /csp",
+		}},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "/", nil)
+		if test.https {
+			r.TLS = &tls.ConnectionState{}
+		}
+		w := httptest.NewRecorder()
+		Secure(test.opts)(w, r)
+
+		for k, v := range test.expected {
+			if actual := w.Header().Get(k); actual != v {
+				t.Errorf("%s: invalid %s %q != %q", test.desc, k, actual, v)
+			}
+		}
+	}
+}
+
+func TestSecureForwardedProto(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	Secure(SecureOptions{HSTSMaxAge: time.Hour})(w, r)
+
+	if hsts := w.Header().Get("Strict-Transport-Security"); hsts != "max-age=3600" {
+		t.Errorf("invalid hsts %q", hsts)
+	}
+}
+
+func TestSecureRouteOverride(t *testing.T) {
+	rr := router.New("/")
+	rr.Before(Secure(DefaultSecureOptions))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/embed", func(w http.ResponseWriter, r *http.Request) {}).Policy(SecureOptions{
+		FrameOptions:  "SAMEORIGIN",
+		CSP:           "frame-ancestors https://partner.example.com",
+		CSPReportOnly: true,
+	})
+
+	tests := []struct {
+		path      string
+		frame     string
+		csp       string
+		cspReport string
+		referrer  string
+	}{
+		{"/", "DENY", "default-src 'self'", "", "strict-origin-when-cross-origin"},
+		{"/embed", "SAMEORIGIN", "", "frame-ancestors https://partner.example.com", "strict-origin-when-cross-origin"},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+
+		h := w.Header()
+		if v := h.Get("X-Frame-Options"); v != test.frame {
+			t.Errorf("%s: invalid frame options %q", test.path, v)
+		}
+		if v := h.Get("Content-Security-Policy"); v != test.csp {
+			t.Errorf("%s: invalid csp %q", test.path, v)
+		}
+		if v := h.Get("Content-Security-Policy-Report-Only"); v != test.cspReport {
+			t.Errorf("%s: invalid report only csp %q", test.path, v)
+		}
+		if v := h.Get("Referrer-Policy"); v != test.referrer {
+			t.Errorf("%s: invalid referrer policy %q", test.path, v)
+		}
+	}
+}
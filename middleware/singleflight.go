@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// Singleflight coalesces concurrent GET requests with the same key, so that only the first runs the
+// handler and the others receive a copy of its response, without its cookies, once it completes,
+// ex. to protect an expensive report from a burst of identical requests. The key defaults to the
+// request's url and a hash of its Authorization and Cookie headers, so that clients never receive
+// each other's responses, and requests with an empty key aren't coalesced. The response is held in memory, so streamed and
+// large responses shouldn't be coalesced, and routes marked with Streaming aren't. Requests waiting
+// on a handler that panics, or whose client goes away before it completes, run it themselves.
+//
+//	rr.Get("/reports/:id", report)
+//	// the reports are public, so requests are coalesced across clients
+//	rr.Use(middleware.Singleflight(func(r *http.Request) string {
+//		return r.URL.RequestURI() + " " + r.Header.Get("Accept")
+//	}))
+func Singleflight(key func(r *http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = func(r *http.Request) string { return r.URL.RequestURI() + " " + credentials(r) }
+	}
+	var mu sync.Mutex
+	flights := make(map[string]*flight)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if r.Method != http.MethodGet || k == "" || router.IsStreaming(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mu.Lock()
+			if f, ok := flights[k]; ok {
+				mu.Unlock()
+				select {
+				case <-f.done:
+				case <-r.Context().Done():
+					return
+				}
+				f.replay(w, r, next)
+				return
+			}
+			f := &flight{done: make(chan struct{})}
+			flights[k] = f
+			mu.Unlock()
+
+			tw := &teeWriter{ResponseWriter: w}
+			defer func() {
+				mu.Lock()
+				delete(flights, k)
+				mu.Unlock()
+				close(f.done)
+			}()
+			c := r.Context()
+			next.ServeHTTP(wrap.Writer(tw, w), r)
+			if c.Err() != nil {
+				// the handler may have given up on the request without writing its response, while
+				// halting the request with router.Fail only cancels the context bound to it
+				return
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+				tw.header = w.Header().Clone()
+			}
+			f.status, f.header, f.body = tw.status, tw.header, tw.body.Bytes()
+			f.err = router.RequestError(r.Context())
+			f.completed = true
+		})
+	}
+}
+
+// flight is a request being handled on behalf of the requests waiting on it
+type flight struct {
+	done chan struct{}
+
+	completed bool
+	status    int
+	header    http.Header
+	body      []byte
+	err       error
+}
+
+// replay writes the response of the flight, failing the request with the flight's error
+func (f *flight) replay(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	if !f.completed {
+		next.ServeHTTP(w, r)
+		return
+	}
+	if f.err != nil {
+		router.Fail(r, f.err)
+		return
+	}
+	for key, values := range withoutCookies(f.header) {
+		w.Header()[key] = append([]string(nil), values...)
+	}
+	w.WriteHeader(f.status)
+	w.Write(f.body)
+}
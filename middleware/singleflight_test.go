@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestSingleflight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	rr := router.New("/")
+	rr.Use(Singleflight(nil))
+	rr.Get("/reports/:id", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		<-release
+		if router.Param(r.Context(), "id") == "broken" {
+			router.Fail(r, router.Conflict(errors.New("report is locked")))
+			return
+		}
+		w.Header().Set("X-Report", router.Param(r.Context(), "id"))
+		fmt.Fprintf(w, "report %d", n)
+	})
+
+	results := make([]*httptest.ResponseRecorder, 10)
+	var wg sync.WaitGroup
+	for i := range results {
+		path := "/reports/1"
+		if i%2 == 1 {
+			path = "/reports/broken"
+		}
+		results[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rec *httptest.ResponseRecorder, path string) {
+			defer wg.Done()
+			rr.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		}(results[i], path)
+	}
+	// give the requests time to join the flights
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("handler should run once per key, ran %d times", n)
+	}
+	for i, rec := range results {
+		if i%2 == 1 {
+			if rec.Code != http.StatusConflict {
+				t.Errorf("%d: waiters should share the error, got %d", i, rec.Code)
+			}
+			continue
+		}
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Report") != "1" || (rec.Body.String() != "report 1" && rec.Body.String() != "report 2") {
+			t.Errorf("%d: invalid response %d %q", i, rec.Code, rec.Body.String())
+		}
+		if rec.Body.String() != results[0].Body.String() {
+			t.Errorf("%d: waiters should receive the same response", i)
+		}
+	}
+}
+
+func TestSingleflightPanic(t *testing.T) {
+	var calls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	rr := router.New("/")
+	rr.Use(Singleflight(nil))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+			panic("boom")
+		}
+		w.Write([]byte("ok"))
+	})
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		rr.ServeHTTP(first, httptest.NewRequest("GET", "/", nil))
+		close(done)
+	}()
+	<-started
+
+	waiter := httptest.NewRecorder()
+	waited := make(chan struct{})
+	go func() {
+		rr.ServeHTTP(waiter, httptest.NewRequest("GET", "/", nil))
+		close(waited)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-done
+	<-waited
+
+	if first.Code != http.StatusInternalServerError || waiter.Body.String() != "ok" {
+		t.Errorf("waiter should run the handler itself, got %d %q", waiter.Code, waiter.Body.String())
+	}
+}
+
+func TestSingleflightClients(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	rr := router.New("/")
+	rr.Use(Singleflight(nil))
+	rr.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		http.SetCookie(w, &http.Cookie{Name: "seen", Value: r.Header.Get("Authorization")})
+		w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	auths := []string{"alice", "bob", "alice"}
+	results := make([]*httptest.ResponseRecorder, len(auths))
+	var wg sync.WaitGroup
+	for i, auth := range auths {
+		results[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(rec *httptest.ResponseRecorder, auth string) {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/me", nil)
+			req.Header.Set("Authorization", auth)
+			rr.ServeHTTP(rec, req)
+		}(results[i], auth)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("handler should run once per client, ran %d times", n)
+	}
+	cookies := 0
+	for i, rec := range results {
+		if rec.Body.String() != auths[i] {
+			t.Errorf("%d: clients should never receive each other's responses, got %q", i, rec.Body.String())
+		}
+		if rec.Header().Get("Set-Cookie") != "" {
+			cookies++
+		}
+	}
+	if cookies != 2 {
+		t.Errorf("cookies should only be set by the requests running the handler, got %d", cookies)
+	}
+}
+
+func TestSingleflightCanceled(t *testing.T) {
+	var calls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	rr := router.New("/")
+	rr.Use(Singleflight(nil))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-r.Context().Done()
+			<-release
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	c, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		rr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(c))
+		close(done)
+	}()
+	<-started
+
+	waiter := httptest.NewRecorder()
+	waited := make(chan struct{})
+	go func() {
+		rr.ServeHTTP(waiter, httptest.NewRequest("GET", "/", nil))
+		close(waited)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(release)
+	<-done
+	<-waited
+
+	if waiter.Code != http.StatusOK || waiter.Body.String() != "ok" {
+		t.Errorf("waiter should run the handler itself, got %d %q", waiter.Code, waiter.Body.String())
+	}
+}
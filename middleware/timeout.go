@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+// Timeout wraps the handler with a context deadline, responding with a 503 if the handler doesn't
+// complete within the duration. The handler's response is buffered until it completes, and any
+// writes made after the timeout response has been sent fail with http.ErrHandlerTimeout. Routes
+// marked with Streaming aren't timed out.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if router.IsStreaming(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			if pattern := router.RoutePattern(ctx); pattern != "" {
+				// the handler may outlive the request, so it's given a copy of the router's pooled params
+				ctx = router.WithParams(ctx, router.Params(ctx))
+			}
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				dst := w.Header()
+				for key, vals := range tw.header {
+					dst[key] = vals
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response until the handler completes, other than informational
+// responses, ex. early hints, which are sent on as they're written
+type timeoutWriter struct {
+	mu       sync.Mutex
+	w        http.ResponseWriter
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		// the response isn't written to until the handler completes or times out, while the lock
+		// is held
+		dst := tw.w.Header()
+		for key, vals := range tw.header {
+			dst[key] = append([]string(nil), vals...)
+		}
+		tw.w.WriteHeader(status)
+		return
+	}
+	tw.status = status
+}
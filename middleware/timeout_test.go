@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestTimeoutCompleted(t *testing.T) {
+	h := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("context deadline not set")
+		}
+		w.Header().Set("foo", "bar")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Error("Invalid response status: ", w.Code)
+	}
+	if w.Header().Get("foo") != "bar" {
+		t.Error("Header not copied")
+	}
+	if w.Body.String() != "done" {
+		t.Error("Invalid body: ", w.Body.String())
+	}
+}
+
+func TestTimeoutExceeded(t *testing.T) {
+	writeErr := make(chan error, 1)
+	h := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("too late"))
+		writeErr <- err
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Error("Invalid response status: ", w.Code)
+	}
+	if err := <-writeErr; err != http.ErrHandlerTimeout {
+		t.Error("write after timeout should fail: ", err)
+	}
+	if w.Body.String() != http.StatusText(http.StatusServiceUnavailable) {
+		t.Error("Invalid body: ", w.Body.String())
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	h := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if recover() != "boom" {
+			t.Error("panic not propagated")
+		}
+	}()
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestTimeoutOutlivesParams(t *testing.T) {
+	release := make(chan struct{})
+	id := make(chan string, 1)
+	rr := router.New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		id <- router.Param(r.Context(), "id")
+	}).Policy(timeoutPolicy(10 * time.Millisecond))
+	rr.Get("/posts/:slug", func(w http.ResponseWriter, r *http.Request) {})
+
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	rr.ServeHTTP(httptest.NewRecorder(), r)
+
+	// the router reuses its params for the next request, while the timed out handler still runs
+	r, _ = http.NewRequest("GET", "/posts/hello", nil)
+	rr.ServeHTTP(httptest.NewRecorder(), r)
+	close(release)
+	if v := <-id; v != "1" {
+		t.Errorf("invalid param %q", v)
+	}
+}
+
+type timeoutPolicy time.Duration
+
+func (p timeoutPolicy) Middleware() func(http.Handler) http.Handler {
+	return Timeout(time.Duration(p))
+}
+
+func TestTimeoutStreaming(t *testing.T) {
+	rr := router.New("/")
+	rr.Use(Timeout(10 * time.Millisecond))
+	rr.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("streaming routes should have no deadline")
+		}
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("streaming routes should write to the response directly")
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("data: done\n\n"))
+	}).Streaming()
+
+	req, _ := http.NewRequest("GET", "/events", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "data: done\n\n" {
+		t.Errorf("invalid response %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTimeoutEarlyHints(t *testing.T) {
+	h := Timeout(time.Second)(http.HandlerFunc(hinted))
+	hints, status, body := serveHinted(t, h, "GET", nil)
+	if len(hints) != 1 || status != http.StatusCreated || body != "created" {
+		t.Errorf("early hints should be sent ahead of the buffered response, got %v %d %q", hints, status, body)
+	}
+}
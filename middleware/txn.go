@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+var txCtxKey = router.NewKey[Tx]("tx")
+
+// Tx is a database transaction, such as a *sql.Tx
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Beginner begins the transactions of the Txn policy
+type Beginner interface {
+	Begin(c context.Context) (Tx, error)
+}
+
+// BeginFunc adapts a function to a Beginner
+//
+//	middleware.BeginFunc(func(c context.Context) (middleware.Tx, error) {
+//		return db.BeginTx(c, nil)
+//	})
+type BeginFunc func(c context.Context) (Tx, error)
+
+// Begin calls the function
+func (fn BeginFunc) Begin(c context.Context) (Tx, error) {
+	return fn(c)
+}
+
+// Txn is a policy running each request of its routes within a transaction, available to the
+// handler via Transaction. The transaction is committed when a 2xx or 3xx status is written, or
+// the handler returns without writing, and is rolled back on a 4xx or 5xx status, a panic, or
+// when the request fails with router.Fail. Failing to begin or commit fails the request, which is
+// rendered by the router's InternalError handler in place of the handler's response.
+//
+//	rr.Post("/orders", createOrder).Policy(middleware.Txn{DB: beginner})
+type Txn struct {
+	DB Beginner
+}
+
+// Middleware implements router.Policy
+func (t Txn) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tx, err := t.DB.Begin(r.Context())
+			if err != nil {
+				router.Fail(r, err)
+				return
+			}
+			r = r.WithContext(router.Set(r.Context(), txCtxKey, tx))
+
+			tw := &txWriter{ResponseWriter: w, r: r, tx: tx}
+			defer func() {
+				if rec := recover(); rec != nil {
+					if !tw.done {
+						tw.done = true
+						tx.Rollback()
+					}
+					panic(rec)
+				}
+			}()
+			next.ServeHTTP(wrap.Writer(tw, w), r)
+			tw.finish(http.StatusOK)
+		})
+	}
+}
+
+func (t Txn) String() string {
+	return "txn"
+}
+
+// Transaction retrieves the transaction of the Txn policy, nil if the route doesn't have one
+func Transaction(c context.Context) Tx {
+	tx, _ := router.Get(c, txCtxKey)
+	return tx
+}
+
+// txWriter completes the transaction before the response status is sent, allowing a failed commit
+// to replace the response
+type txWriter struct {
+	http.ResponseWriter
+	r  *http.Request
+	tx Tx
+
+	done   bool
+	failed bool
+}
+
+func (tw *txWriter) WriteHeader(status int) {
+	// informational responses, ex. early hints, are sent before the handler completes
+	if status < http.StatusOK {
+		tw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if tw.done {
+		if !tw.failed {
+			tw.ResponseWriter.WriteHeader(status)
+		}
+		return
+	}
+	tw.finish(status)
+	if !tw.failed {
+		tw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (tw *txWriter) Write(b []byte) (int, error) {
+	if !tw.done {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.failed {
+		return len(b), nil
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush completes the transaction, if it hasn't been, and flushes the underlying writer
+func (tw *txWriter) Flush() {
+	if !tw.done {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok && !tw.failed {
+		f.Flush()
+	}
+}
+
+// finish commits or rolls back the transaction for the response status, failing the request if
+// the commit fails
+func (tw *txWriter) finish(status int) {
+	if tw.done {
+		return
+	}
+	tw.done = true
+	if status >= 400 || router.RequestError(tw.r.Context()) != nil {
+		tw.tx.Rollback()
+		return
+	}
+	if err := tw.tx.Commit(); err != nil {
+		tw.failed = true
+		router.Fail(tw.r, err)
+	}
+}
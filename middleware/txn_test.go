@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+type testTx struct {
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (tx *testTx) Commit() error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *testTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func TestTxn(t *testing.T) {
+	var tx *testTx
+	var commitErr error
+	txn := Txn{DB: BeginFunc(func(c context.Context) (Tx, error) {
+		tx = &testTx{commitErr: commitErr}
+		return tx, nil
+	})}
+
+	rr := router.New("/")
+	rr.Policy(txn)
+	rr.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if Transaction(r.Context()) != tx {
+			t.Error("transaction not bound to the context")
+		}
+		w.Write([]byte("ok"))
+	})
+	rr.Get("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusSeeOther)
+	})
+	rr.Get("/conflict", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	rr.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		router.Fail(r, errors.New("failed"))
+	})
+	rr.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	})
+
+	tests := []struct {
+		path       string
+		commitErr  error
+		status     int
+		committed  bool
+		rolledBack bool
+	}{
+		{"/ok", nil, 200, true, false},
+		{"/empty", nil, 200, true, false},
+		{"/redirect", nil, 303, true, false},
+		{"/conflict", nil, 409, false, true},
+		{"/fail", nil, 500, false, true},
+		{"/panic", nil, 500, false, true},
+		{"/ok", errors.New("serialization failure"), 500, true, false},
+		{"/empty", errors.New("serialization failure"), 500, true, false},
+	}
+	for _, test := range tests {
+		commitErr = test.commitErr
+		r, _ := http.NewRequest("GET", test.path, nil)
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s: invalid status %d != %d", test.path, w.Code, test.status)
+		}
+		if tx.committed != test.committed || tx.rolledBack != test.rolledBack {
+			t.Errorf("%s: committed %v, rolled back %v", test.path, tx.committed, tx.rolledBack)
+		}
+		if test.commitErr != nil && w.Body.String() == "ok" {
+			t.Errorf("%s: handler response sent after the commit failed", test.path)
+		}
+	}
+}
+
+func TestTxnRequestUnchanged(t *testing.T) {
+	tx := &testTx{}
+	h := Txn{DB: BeginFunc(func(c context.Context) (Tx, error) { return tx, nil })}.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Transaction(r.Context()) != tx {
+			t.Error("the handler should have the transaction")
+		}
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if Transaction(r.Context()) != nil || !tx.committed {
+		t.Error("the transaction should be passed on in a copy of the request")
+	}
+}
+
+func TestTxnBeginError(t *testing.T) {
+	called := false
+	rr := router.New("/")
+	rr.InternalError(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(router.RequestError(r.Context()).Error()))
+	})
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}).Policy(Txn{DB: BeginFunc(func(c context.Context) (Tx, error) {
+		return nil, errors.New("no connections")
+	})})
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+
+	if called {
+		t.Error("handler called without a transaction")
+	}
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "no connections" {
+		t.Errorf("invalid response %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTxnEarlyHints(t *testing.T) {
+	tx := &testTx{}
+	rr := router.New("/")
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		router.EarlyHints(w, nil)
+		if tx.committed {
+			t.Error("the transaction shouldn't be committed by early hints")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}).Policy(Txn{DB: BeginFunc(func(c context.Context) (Tx, error) { return tx, nil })})
+
+	hints, status, _ := serveHinted(t, rr, "GET", nil)
+	if len(hints) != 1 || status != http.StatusCreated || !tx.committed {
+		t.Errorf("invalid response %v %d, committed %v", hints, status, tx.committed)
+	}
+}
@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/chrisolsen/router"
+)
+
+// UploadStore stores uploaded files, ex. within an object store, returning their location. The
+// files already stored are deleted when a later part of the form fails the request.
+type UploadStore interface {
+	Store(c context.Context, file router.UploadedFile, content io.Reader) (location string, err error)
+	Delete(c context.Context, location string) error
+}
+
+// UploadOptions are the limits of the uploads accepted
+type UploadOptions struct {
+	// MaxFileBytes limits the size of each file, 10MB by default
+	MaxFileBytes int64
+
+	// MaxFiles limits the number of files, 10 by default
+	MaxFiles int
+
+	// MaxFieldBytes limits the total size of the form's other fields, 1MB by default
+	MaxFieldBytes int64
+
+	// ContentTypes are the content types allowed, sniffed from each file's content. A type ending
+	// in `/` allows the types within it, ex. `image/`. All types are allowed by default.
+	ContentTypes []string
+
+	// Store stores the files. Without one the files are written to temp files within Dir, or the
+	// system's temp dir, which are removed once the handler completes.
+	Store UploadStore
+	Dir   string
+}
+
+// Upload wraps the handler, streaming the files of multipart requests to storage rather than
+// holding them in memory. The files are retrieved with router.Files, and the other fields with the
+// request's FormValue. Uploads over the limits fail the request with a *router.Error: a 413 for
+// sizes and counts and a 415 for content types. Attached to a single route the options are a
+// policy.
+//
+//	rr.Post("/avatars", setAvatar).Policy(middleware.UploadOptions{
+//		MaxFileBytes: 2 << 20,
+//		MaxFiles:     1,
+//		ContentTypes: []string{"image/png", "image/jpeg"},
+//	})
+func Upload(opts UploadOptions) func(http.Handler) http.Handler {
+	return opts.Middleware()
+}
+
+// Middleware implements router.Policy
+func (opts UploadOptions) Middleware() func(http.Handler) http.Handler {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = 10 << 20
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = 10
+	}
+	if opts.MaxFieldBytes <= 0 {
+		opts.MaxFieldBytes = 1 << 20
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mt != "multipart/form-data" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var temps []string
+			defer func() {
+				for _, path := range temps {
+					os.Remove(path)
+				}
+			}()
+			files, form, err := opts.receive(r, func(path string) { temps = append(temps, path) })
+			if err != nil {
+				router.Fail(r, err)
+				return
+			}
+			r = r.WithContext(router.WithFiles(r.Context(), files))
+			setForm(r, form)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (opts UploadOptions) String() string {
+	return fmt.Sprintf("upload(%d files of %d bytes)", opts.MaxFiles, opts.MaxFileBytes)
+}
+
+var errFileTooLarge = errors.New("file too large")
+
+// receive streams the parts of the form, storing the files and returning them along with the
+// form's fields. The paths of temp files are passed to created as they're written, so they're
+// removed on failure, while the files already in the store are deleted.
+func (opts UploadOptions) receive(r *http.Request, created func(path string)) (_ []router.UploadedFile, _ url.Values, err error) {
+	var files []router.UploadedFile
+	defer func() {
+		if err != nil && opts.Store != nil {
+			// the request may have failed by being canceled, so the deletes aren't bound to it
+			for _, file := range files {
+				opts.Store.Delete(context.Background(), file.Location)
+			}
+		}
+	}()
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, router.BadRequest(err)
+	}
+	form := url.Values{}
+	fieldBytes := opts.MaxFieldBytes
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, router.BadRequest(err)
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, fieldBytes+1))
+			if err != nil {
+				return nil, nil, router.BadRequest(err)
+			}
+			if fieldBytes -= int64(len(value)); fieldBytes < 0 {
+				return nil, nil, router.NewError(http.StatusRequestEntityTooLarge, "fields_too_large", "form fields too large")
+			}
+			form.Add(part.FormName(), string(value))
+			continue
+		}
+
+		if len(files) == opts.MaxFiles {
+			return nil, nil, router.NewError(http.StatusRequestEntityTooLarge, "too_many_files", fmt.Sprintf("at most %d files can be uploaded", opts.MaxFiles))
+		}
+		content := bufio.NewReaderSize(part, 512)
+		head, _ := content.Peek(512)
+		file := router.UploadedFile{
+			Field:       part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: http.DetectContentType(head),
+		}
+		if !opts.allowed(file.ContentType) {
+			return nil, nil, router.NewError(http.StatusUnsupportedMediaType, "unsupported_file_type", fmt.Sprintf("%s files are not allowed", mediaType(file.ContentType)))
+		}
+
+		limited := &limitedReader{r: content, max: opts.MaxFileBytes}
+		if file.Location, err = opts.store(r.Context(), file, limited, created); err != nil {
+			if errors.Is(err, errFileTooLarge) || limited.read > limited.max {
+				return nil, nil, router.NewError(http.StatusRequestEntityTooLarge, "file_too_large", fmt.Sprintf("%s is larger than %d bytes", file.Filename, opts.MaxFileBytes))
+			}
+			return nil, nil, err
+		}
+		file.Size = limited.read
+		files = append(files, file)
+	}
+
+	return files, form, nil
+}
+
+// setForm sets the request's form to the fields received, along with the url's query params. The
+// parsed form stands in for the body that's been read, ex. for router.BindForm.
+func setForm(r *http.Request, form url.Values) {
+	r.MultipartForm = &multipart.Form{Value: form}
+	r.PostForm = form
+	r.Form = make(url.Values, len(form))
+	for k, vals := range form {
+		r.Form[k] = vals
+	}
+	for k, vals := range r.URL.Query() {
+		if _, ok := form[k]; !ok {
+			r.Form[k] = vals
+		}
+	}
+}
+
+// store writes the file to the store, or a temp file
+func (opts UploadOptions) store(c context.Context, file router.UploadedFile, content io.Reader, created func(path string)) (string, error) {
+	if opts.Store != nil {
+		return opts.Store.Store(c, file, content)
+	}
+	f, err := os.CreateTemp(opts.Dir, "upload-*")
+	if err != nil {
+		return "", err
+	}
+	created(f.Name())
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+func (opts UploadOptions) allowed(contentType string) bool {
+	if len(opts.ContentTypes) == 0 {
+		return true
+	}
+	mt := mediaType(contentType)
+	for _, t := range opts.ContentTypes {
+		if t == mt || strings.HasSuffix(t, "/") && strings.HasPrefix(mt, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedReader fails with errFileTooLarge once more than the max bytes are read, unlike
+// io.LimitReader which ends the file early
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (lr *limitedReader) Read(b []byte) (int, error) {
+	n, err := lr.r.Read(b)
+	lr.read += int64(n)
+	if lr.read > lr.max {
+		return n, errFileTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+var pngHeader = "\x89PNG\r\n\x1a\n"
+
+type uploadPart struct {
+	field, filename, content string
+}
+
+func multipartRequest(parts ...uploadPart) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		if p.filename == "" {
+			mw.WriteField(p.field, p.content)
+			continue
+		}
+		fw, _ := mw.CreateFormFile(p.field, p.filename)
+		fw.Write([]byte(p.content))
+	}
+	mw.Close()
+	r, _ := http.NewRequest("POST", "/avatars?source=web", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestUpload(t *testing.T) {
+	dir, _ := os.MkdirTemp("", "uploads")
+	defer os.RemoveAll(dir)
+
+	var files []router.UploadedFile
+	var contents []string
+	var form string
+	rr := router.New("/")
+	rr.Post("/avatars", func(w http.ResponseWriter, r *http.Request) {
+		files = router.Files(r, "avatar")
+		contents = nil
+		for _, f := range files {
+			b, _ := os.ReadFile(f.Location)
+			contents = append(contents, string(b))
+		}
+		form = r.FormValue("caption") + " " + r.FormValue("source")
+	}).Policy(UploadOptions{
+		MaxFileBytes:  16,
+		MaxFiles:      2,
+		MaxFieldBytes: 8,
+		ContentTypes:  []string{"image/", "text/plain"},
+		Dir:           dir,
+	})
+
+	tests := []struct {
+		desc   string
+		parts  []uploadPart
+		status int
+		code   string
+	}{
+		{"ok", []uploadPart{{"caption", "", "me"}, {"avatar", "me.png", pngHeader + "pixels"}, {"avatar", "me.txt", "hello"}}, 200, ""},
+		{"file too large", []uploadPart{{"avatar", "big.png", pngHeader + strings.Repeat("x", 9)}}, 413, "file_too_large"},
+		{"too many files", []uploadPart{{"avatar", "a.txt", "a"}, {"avatar", "b.txt", "b"}, {"avatar", "c.txt", "c"}}, 413, "too_many_files"},
+		{"fields too large", []uploadPart{{"caption", "", "a long caption"}}, 413, "fields_too_large"},
+		{"unsupported type", []uploadPart{{"avatar", "me.png", "<html><script></script></html>"}}, 415, "unsupported_file_type"},
+	}
+	for _, test := range tests {
+		files = nil
+		r := multipartRequest(test.parts...)
+		r.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s: invalid status %d %s", test.desc, w.Code, w.Body.String())
+			continue
+		}
+		if test.code != "" {
+			var problem struct{ Code string }
+			json.Unmarshal(w.Body.Bytes(), &problem)
+			if problem.Code != test.code {
+				t.Errorf("%s: invalid error %s", test.desc, w.Body.String())
+			}
+			if files != nil {
+				t.Errorf("%s: handler called", test.desc)
+			}
+		}
+		if leftover, _ := os.ReadDir(dir); len(leftover) > 0 {
+			t.Errorf("%s: temp files not removed %d", test.desc, len(leftover))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, multipartRequest(uploadPart{"caption", "", "me"}, uploadPart{"avatar", "me.png", pngHeader + "pixels"}, uploadPart{"avatar", "me.txt", "hello"}))
+	if len(files) != 2 || files[0].Filename != "me.png" || files[0].ContentType != "image/png" || files[0].Size != 14 {
+		t.Errorf("invalid files %+v", files)
+	}
+	if len(contents) != 2 || contents[0] != pngHeader+"pixels" || contents[1] != "hello" {
+		t.Errorf("invalid contents %q", contents)
+	}
+	if form != "me web" {
+		t.Errorf("invalid form values %q", form)
+	}
+}
+
+type testUploadStore struct {
+	stored map[string]string
+}
+
+func (s *testUploadStore) Store(c context.Context, file router.UploadedFile, content io.Reader) (string, error) {
+	b, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+	key := "uploads/" + file.Filename
+	s.stored[key] = string(b)
+	return key, nil
+}
+
+func (s *testUploadStore) Delete(c context.Context, location string) error {
+	delete(s.stored, location)
+	return nil
+}
+
+func TestUploadStore(t *testing.T) {
+	store := &testUploadStore{stored: map[string]string{}}
+	var location string
+	h := Upload(UploadOptions{Store: store, MaxFileBytes: 8})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location = router.Files(r, "doc")[0].Location
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, multipartRequest(uploadPart{"doc", "a.txt", "contents"}))
+	if location != "uploads/a.txt" || store.stored[location] != "contents" {
+		t.Errorf("invalid location %q %v", location, store.stored)
+	}
+
+	// the files stored before a part fails the request are deleted
+	location = ""
+	h.ServeHTTP(httptest.NewRecorder(), multipartRequest(uploadPart{"doc", "b.txt", "b"}, uploadPart{"doc", "c.txt", "too large"}))
+	if location != "" {
+		t.Error("handler called")
+	}
+	if _, ok := store.stored["uploads/b.txt"]; ok || len(store.stored) != 1 {
+		t.Errorf("the stored files should be deleted, got %v", store.stored)
+	}
+
+	// requests that aren't multipart pass through
+	called := false
+	h = Upload(UploadOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	r, _ := http.NewRequest("POST", "/", strings.NewReader("{}"))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if !called {
+		t.Error("handler not called")
+	}
+}
+
+func TestUploadBindForm(t *testing.T) {
+	var in struct {
+		Caption string `form:"caption"`
+	}
+	var bindErr error
+	rr := router.New("/")
+	rr.Post("/avatars", func(w http.ResponseWriter, r *http.Request) {
+		bindErr = router.BindForm(r, &in)
+	}).Policy(UploadOptions{Dir: t.TempDir()})
+
+	rr.ServeHTTP(httptest.NewRecorder(), multipartRequest(uploadPart{"caption", "", "me"}, uploadPart{"avatar", "me.txt", "hello"}))
+	if bindErr != nil || in.Caption != "me" {
+		t.Errorf("the uploaded form should be bound, got %q %v", in.Caption, bindErr)
+	}
+}
+
+func TestUploadRequestUnchanged(t *testing.T) {
+	var files []router.UploadedFile
+	h := Upload(UploadOptions{Dir: t.TempDir()})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		files = router.Files(r, "doc")
+	}))
+	r := multipartRequest(uploadPart{"caption", "", "me"}, uploadPart{"doc", "a.txt", "contents"})
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if len(files) != 1 {
+		t.Fatalf("invalid files %+v", files)
+	}
+	if len(router.Files(r, "doc")) != 0 || r.PostForm != nil {
+		t.Error("the files and form should be passed on in a copy of the request")
+	}
+}
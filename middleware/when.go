@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Predicate reports whether middleware should be run for the request
+type Predicate func(r *http.Request) bool
+
+// When only runs the middleware when the predicate matches the request
+//
+//	rr.Before(middleware.When(middleware.HostIs("admin.example.com"), middleware.BasicAuth(auth)))
+func When(pred Predicate, mw http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if pred(r) {
+			mw(w, r)
+		}
+	}
+}
+
+// Not inverts the predicate
+func Not(pred Predicate) Predicate {
+	return func(r *http.Request) bool {
+		return !pred(r)
+	}
+}
+
+// IsJSON matches requests with a JSON body, including `+json` suffixed types
+func IsJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsAuthenticated matches requests that have been authenticated by the JWT or BasicAuth middleware
+func IsAuthenticated(r *http.Request) bool {
+	return Claims(r.Context()) != nil || BasicAuthUser(r.Context()) != ""
+}
+
+// HostIs matches requests made to any of the hosts, ignoring the port
+func HostIs(hosts ...string) Predicate {
+	return func(r *http.Request) bool {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		for _, h := range hosts {
+			if strings.EqualFold(host, h) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MethodIn matches requests using any of the methods
+func MethodIn(methods ...string) Predicate {
+	return func(r *http.Request) bool {
+		for _, m := range methods {
+			if strings.EqualFold(r.Method, m) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestWhen(t *testing.T) {
+	mw := When(MethodIn("POST", "put"), SetHeader("foo", "bar"))
+
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{"GET", ""},
+		{"POST", "bar"},
+		{"PUT", "bar"},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, "/", nil)
+		w := httptest.NewRecorder()
+		mw(w, r)
+
+		if w.Header().Get("foo") != test.expected {
+			t.Errorf("%s: invalid header %q", test.method, w.Header().Get("foo"))
+		}
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	newRequest := func(fn func(r *http.Request)) *http.Request {
+		r, _ := http.NewRequest("GET", "http://example.com:8080/", nil)
+		fn(r)
+		return r
+	}
+
+	tests := []struct {
+		desc     string
+		pred     Predicate
+		r        *http.Request
+		expected bool
+	}{
+		{"json", IsJSON, newRequest(func(r *http.Request) { r.Header.Set("Content-Type", "application/json; charset=utf-8") }), true},
+		{"json suffix", IsJSON, newRequest(func(r *http.Request) { r.Header.Set("Content-Type", "application/problem+json") }), true},
+		{"not json", IsJSON, newRequest(func(r *http.Request) { r.Header.Set("Content-Type", "text/html") }), false},
+		{"host", HostIs("Example.com"), newRequest(func(r *http.Request) {}), true},
+		{"other host", HostIs("admin.example.com"), newRequest(func(r *http.Request) {}), false},
+		{"not", Not(HostIs("admin.example.com")), newRequest(func(r *http.Request) {}), true},
+		{"anonymous", IsAuthenticated, newRequest(func(r *http.Request) {}), false},
+		{"jwt", IsAuthenticated, newRequest(func(r *http.Request) {
+			*r = *r.WithContext(router.Set(r.Context(), claimsCtxKey, JWTClaims{}))
+		}), true},
+		{"basic auth", IsAuthenticated, newRequest(func(r *http.Request) {
+			*r = *r.WithContext(router.Set(r.Context(), basicAuthCtxKey, "foo"))
+		}), true},
+	}
+	for _, test := range tests {
+		if result := test.pred(test.r); result != test.expected {
+			t.Errorf("%s: %v != %v", test.desc, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Mount delegates all methods and all paths under the prefix to the handler, with the prefix stripped
+// from the request's path. This allows pprof, another router, or any other http.Handler to be
+// served within the router.
+//
+//	rr.Mount("/debug/pprof", http.DefaultServeMux)
+func (r Router) Mount(prefix string, h http.Handler) *Endpoint {
+	prefix = "/" + strings.Trim(prefix, "/")
+	strip := r.fullPath(prefix)
+	ep := &Endpoint{handler: stripPrefix(strip, h)}
+
+	// the prefix itself and everything under it share the same endpoint
+	r.bindRoute("", prefix, ep)
+	r.bindRoute("", strings.TrimRight(prefix, "/")+"/*", ep)
+	return ep
+}
+
+// stripPrefix removes the prefix from the path, leaving `/` rather than an empty path
+func stripPrefix(prefix string, h http.Handler) http.Handler {
+	if prefix == "/" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = "/" + strings.TrimLeft(strings.TrimPrefix(r.URL.RawPath, prefix), "/")
+		}
+		h.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.URL.RawQuery))
+	})
+
+	rr := New("/")
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("users")) })
+	rr.Mount("/ext", mux)
+	rr.SubRouter("/admin").Mount("/debug/", mux)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"GET", "/ext", "GET / "},
+		{"GET", "/ext/", "GET / "},
+		{"POST", "/ext/a/b?c=d", "POST /a/b c=d"},
+		{"DELETE", "/admin/debug/pprof/heap", "DELETE /pprof/heap "},
+		{"GET", "/users", "users"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Body.String() != test.expected {
+			t.Errorf("%s %s: %q != %q", test.method, test.path, rec.Body.String(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a single media range of an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses the header into its media ranges, ordered by quality. Ranges of equal
+// quality keep the order they were given in.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// negotiate returns the offered media type best matching the Accept header, preferring the order of
+// the offers when the client has no preference, or an empty string if none are acceptable
+func negotiate(header string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		q, specificity := acceptQuality(parseAccept(header), offer)
+		if q > bestQ || (q == bestQ && q > 0 && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality of the most specific range matching the media type, along with
+// how specific that range was: 0 for `*/*`, 1 for `type/*` and 2 for an exact match
+func acceptQuality(ranges []acceptRange, mediaType string) (float64, int) {
+	mediaType = strings.ToLower(mediaType)
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.mediaType == mediaType:
+			s = 2
+		case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, r.mediaType[:len(r.mediaType)-1]):
+			s = 1
+		case r.mediaType == "*/*" || r.mediaType == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q, specificity
+}
+
+// Accept sets the media types the endpoint produces, allowing other endpoints of the same route to
+// handle requests preferring other types. Requests that accept none of the route's types are
+// handled by its endpoint without types, or receive a 406.
+//
+//	rr.Get("/users/:id", showUserJSON).Accept("application/json")
+//	rr.Get("/users/:id", showUserHTML).Accept("text/html")
+func (e *Endpoint) Accept(mediaTypes ...string) *Endpoint {
+	e.accept = append(e.accept, mediaTypes...)
+	return e
+}
+
+// negotiateEndpoint selects the endpoint of the route producing the media type the request
+// prefers, reporting false if none are acceptable. Routes without types use the active endpoint.
+func (r Router) negotiateEndpoint(route Route, w http.ResponseWriter, req *http.Request) (*Endpoint, bool) {
+	eps := r.queryEndpoints(route, req)
+	if len(eps) == 0 {
+		return nil, false
+	}
+
+	var offers []string
+	var fallback *Endpoint
+	producers := make(map[string]*Endpoint)
+	for _, e := range eps {
+		if len(e.accept) == 0 {
+			fallback = e
+			continue
+		}
+		for _, mediaType := range e.accept {
+			if producers[mediaType] == nil {
+				offers = append(offers, mediaType)
+			}
+			producers[mediaType] = e
+		}
+	}
+	if len(offers) == 0 {
+		return eps[len(eps)-1], true
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if mediaType := negotiate(req.Header.Get("Accept"), offers); mediaType != "" {
+		return producers[mediaType], true
+	}
+	return fallback, fallback != nil
+}
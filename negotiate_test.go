@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"text/plain", "application/json", "text/html"}
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "text/plain"},
+		{"*/*", "text/plain"},
+		{"application/json", "application/json"},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", "text/html"},
+		{"text/*;q=0.5, application/json;q=0.4", "text/plain"},
+		{"text/*, text/plain;q=0.1", "text/html"},
+		{"*/*, text/html", "text/html"},
+		{"image/png", ""},
+		{"application/json;q=0", ""},
+	}
+	for _, test := range tests {
+		if result := negotiate(test.accept, offers); result != test.expected {
+			t.Errorf("%q: %q != %q", test.accept, result, test.expected)
+		}
+	}
+}
+
+func TestAcceptRouting(t *testing.T) {
+	rr := New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("json"))
+	}).Accept("application/json")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("html"))
+	}).Accept("text/html", "application/xhtml+xml")
+	rr.Get("/reports/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("csv"))
+	}).Accept("text/csv")
+	rr.Get("/reports/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("default"))
+	})
+
+	tests := []struct {
+		path   string
+		accept string
+		status int
+		body   string
+	}{
+		{"/users/5", "", 200, "json"},
+		{"/users/5", "application/json", 200, "json"},
+		{"/users/5", "text/html,application/xhtml+xml,*/*;q=0.8", 200, "html"},
+		{"/users/5", "application/json;q=0.5, text/*", 200, "html"},
+		{"/users/5", "image/png", 406, "Not Acceptable\n"},
+		{"/reports/5", "text/csv", 200, "csv"},
+		{"/reports/5", "application/pdf", 200, "default"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != test.status || rec.Body.String() != test.body {
+			t.Errorf("%s %q: invalid response %d %q", test.path, test.accept, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("%s %q: missing Vary header", test.path, test.accept)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotFoundInheritance(t *testing.T) {
+	notFound := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(body))
+		}
+	}
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+
+	rr := New("/")
+	rr.NotFound(notFound("root"))
+	rr.Get("/", fn)
+	api := rr.SubRouter("/api")
+	api.NotFound(notFound("api"))
+	api.Get("/users", fn)
+	v1 := api.SubRouter("/v1")
+	v1.Get("/users", fn)
+	v1.Get("/pages/:slug", func(w http.ResponseWriter, r *http.Request) {
+		NotFoundFromHandler(w, r)
+	})
+	blog := rr.SubRouter("/blog")
+	blog.Get("/", fn)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/missing", "root"},
+		{"/api/missing", "api"},
+		{"/api/v1/missing", "api"},
+		{"/api/v1/pages/about", "api"},
+		{"/blog/missing", "root"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound || rec.Body.String() != test.expected {
+			t.Errorf("%s: invalid response %d %s", test.path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestNotFoundOwnsResponse(t *testing.T) {
+	rr := New("/")
+	rr.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old-about" {
+			http.Redirect(w, r, "/about", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusGone)
+	})
+
+	req, _ := http.NewRequest("GET", "/old-about", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/about" {
+		t.Errorf("invalid redirect %d %v", rec.Code, rec.Header())
+	}
+
+	req, _ = http.NewRequest("GET", "/removed", nil)
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusGone {
+		t.Errorf("invalid status %d", rec.Code)
+	}
+}
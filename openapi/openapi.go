@@ -0,0 +1,273 @@
+// Package openapi generates OpenAPI 3 documents from a router's route table
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.0.3"
+
+// Info describes the API within the document
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Generate creates an OpenAPI 3 document describing the router's active routes, using the docs set
+// with the routes' Doc. Routes handling all methods, such as mounted handlers, are left out.
+func Generate(r router.Router) ([]byte, error) {
+	return GenerateInfo(r, Info{Title: "API", Version: "1.0.0"})
+}
+
+// GenerateInfo creates an OpenAPI 3 document like Generate, described by the info
+func GenerateInfo(r router.Router, info Info) ([]byte, error) {
+	return json.MarshalIndent(document(r.Routes(), info, nil), "", "  ")
+}
+
+type object = map[string]interface{}
+
+// document builds the document of the routes, leaving out those that are skipped
+func document(routes []router.RouteInfo, info Info, skip func(router.RouteInfo) bool) object {
+	paths := object{}
+	for _, route := range routes {
+		if route.Inactive || route.Method == "" || skip != nil && skip(route) {
+			continue
+		}
+		path, params := convertPattern(route.Pattern)
+		item, ok := paths[path].(object)
+		if !ok {
+			item = object{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation(route, params)
+	}
+	return object{
+		"openapi": Version,
+		"info":    info,
+		"paths":   paths,
+	}
+}
+
+// convertPattern converts the route's pattern to an OpenAPI path, ex. `/users/:id` to
+// `/users/{id}`, returning the names of its params. The wildcard is named `path`.
+func convertPattern(pattern string) (string, []string) {
+	parts := strings.Split(pattern, "/")
+	var params []string
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, ":"):
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		case part == "*":
+			params = append(params, "path")
+			parts[i] = "{path}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+func operation(route router.RouteInfo, params []string) object {
+	doc := route.Doc
+	if doc == nil {
+		doc = &router.RouteDoc{}
+	}
+	op := object{}
+	if doc.Summary != "" {
+		op["summary"] = doc.Summary
+	}
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+	if len(doc.Tags) > 0 {
+		op["tags"] = doc.Tags
+	}
+	if route.Name != "" {
+		op["operationId"] = route.Name
+	}
+
+	if len(params) > 0 {
+		parameters := make([]object, 0, len(params))
+		for _, name := range params {
+			p := object{"name": name, "in": "path", "required": true, "schema": object{"type": "string"}}
+			if desc := doc.Params[name]; desc != "" {
+				p["description"] = desc
+			}
+			parameters = append(parameters, p)
+		}
+		op["parameters"] = parameters
+	}
+
+	if doc.Request != nil {
+		op["requestBody"] = object{
+			"required": true,
+			"content":  object{"application/json": object{"schema": Schema(doc.Request)}},
+		}
+	}
+
+	responses := object{}
+	statuses := make([]int, 0, len(doc.Responses))
+	for status := range doc.Responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		res := object{"description": http.StatusText(status)}
+		if body := doc.Responses[status]; body != nil {
+			res["content"] = object{"application/json": object{"schema": Schema(body)}}
+		}
+		responses[strconv.Itoa(status)] = res
+	}
+	if len(responses) == 0 {
+		responses["default"] = object{"description": "Undocumented response"}
+	}
+	op["responses"] = responses
+	return op
+}
+
+// Schema describes the type of the example value as a JSON schema, following the json tags of
+// structs and the rules of their validate tags
+func Schema(v interface{}) map[string]interface{} {
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaOf describes the type, seen tracking the structs being described to end recursion
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) object {
+	if t == nil {
+		return object{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		// the type controls its own encoding
+		return object{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number"}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return object{"type": "string", "format": "byte"}
+		}
+		return object{"type": "array", "items": schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return object{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := object{}
+		var required []string
+		structFields(t, seen, properties, &required)
+		s := object{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			sort.Strings(required)
+			s["required"] = required
+		}
+		return s
+	}
+	return object{}
+}
+
+// structFields adds the schemas of the struct's fields, including those of embedded structs
+func structFields(t reflect.Type, seen map[reflect.Type]bool, properties object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := strings.Split(sf.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			structFields(ft, seen, properties, required)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		s := schemaOf(sf.Type, seen)
+		for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+			applyRule(s, rule, required, name)
+		}
+		properties[name] = s
+	}
+}
+
+// applyRule describes the validate rule within the schema
+func applyRule(s object, rule string, required *[]string, name string) {
+	key, arg := rule, ""
+	if i := strings.IndexByte(rule, '='); i >= 0 {
+		key, arg = rule[:i], rule[i+1:]
+	}
+	n, _ := strconv.ParseFloat(arg, 64)
+	switch key {
+	case "required":
+		*required = append(*required, name)
+	case "email":
+		s["format"] = "email"
+	case "oneof":
+		s["enum"] = strings.Fields(arg)
+	case "min", "max", "len":
+		var prefix string
+		switch s["type"] {
+		case "string":
+			prefix = "Length"
+		case "array":
+			prefix = "Items"
+		case "object":
+			prefix = "Properties"
+		case "integer", "number":
+			if key == "min" {
+				s["minimum"] = n
+			} else if key == "max" {
+				s["maximum"] = n
+			}
+			return
+		default:
+			return
+		}
+		if key != "max" {
+			s["min"+prefix] = n
+		}
+		if key != "min" {
+			s["max"+prefix] = n
+		}
+	}
+}
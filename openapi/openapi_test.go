@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type node struct {
+	Children []node `json:"children"`
+}
+
+type signup struct {
+	Email    string            `json:"email" validate:"required,email"`
+	Name     string            `json:"name" validate:"required,max=50"`
+	Plan     string            `json:"plan" validate:"oneof=free pro"`
+	Age      int               `json:"age,omitempty" validate:"min=13"`
+	Tags     []string          `json:"tags" validate:"max=5"`
+	Address  *address          `json:"address"`
+	Meta     map[string]string `json:"meta"`
+	Joined   time.Time         `json:"joined"`
+	Tree     node              `json:"tree"`
+	Password string            `json:"-"`
+	internal string
+}
+
+func TestSchema(t *testing.T) {
+	actual, _ := json.Marshal(Schema(signup{}))
+	expected := `{"properties":{` +
+		`"address":{"properties":{"city":{"type":"string"}},"required":["city"],"type":"object"},` +
+		`"age":{"minimum":13,"type":"integer"},` +
+		`"email":{"format":"email","type":"string"},` +
+		`"joined":{"format":"date-time","type":"string"},` +
+		`"meta":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"name":{"maxLength":50,"type":"string"},` +
+		`"plan":{"enum":["free","pro"],"type":"string"},` +
+		`"tags":{"items":{"type":"string"},"maxItems":5,"type":"array"},` +
+		`"tree":{"properties":{"children":{"items":{"type":"object"},"type":"array"}},"type":"object"}` +
+		`},"required":["email","name"],"type":"object"}`
+	if string(actual) != expected {
+		t.Errorf("invalid schema\n%s\n!=\n%s", actual, expected)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	rr := router.New("/")
+	api := rr.SubRouter("/api")
+	api.Post("/users", h).Name("createUser").Doc(router.RouteDoc{
+		Summary:   "Create a user",
+		Tags:      []string{"users"},
+		Request:   signup{},
+		Responses: map[int]interface{}{201: address{}, 422: nil},
+	})
+	api.Get("/users/:id", h).Doc(router.RouteDoc{
+		Summary: "Get a user",
+		Params:  map[string]string{"id": "the user's id"},
+	})
+	api.Get("/files/*", h)
+	rr.Mount("/debug", http.NotFoundHandler())
+	rr.When("dev", false, func(r *router.Router) {
+		r.Get("/fixtures", h)
+	})
+
+	b, err := GenerateInfo(rr, Info{Title: "Users", Version: "2.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string
+		Info    Info
+		Paths   map[string]map[string]struct {
+			Summary     string
+			OperationID string `json:"operationId"`
+			Tags        []string
+			Parameters  []struct {
+				Name        string
+				In          string
+				Required    bool
+				Description string
+			}
+			RequestBody *struct {
+				Content map[string]struct{ Schema map[string]interface{} }
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Description string
+				Content     map[string]interface{}
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.OpenAPI != Version || doc.Info.Title != "Users" {
+		t.Errorf("invalid header %s %+v", doc.OpenAPI, doc.Info)
+	}
+	var paths []string
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	if len(doc.Paths) != 3 || doc.Paths["/fixtures"] != nil || doc.Paths["/debug"] != nil {
+		t.Errorf("invalid paths %v", paths)
+	}
+
+	create := doc.Paths["/api/users"]["post"]
+	if create.Summary != "Create a user" || create.OperationID != "createUser" || create.Tags[0] != "users" {
+		t.Errorf("invalid operation %+v", create)
+	}
+	if create.RequestBody == nil || create.RequestBody.Content["application/json"].Schema["type"] != "object" {
+		t.Errorf("invalid request body %+v", create.RequestBody)
+	}
+	if create.Responses["201"].Description != "Created" || create.Responses["201"].Content == nil ||
+		create.Responses["422"].Description != "Unprocessable Entity" || create.Responses["422"].Content != nil {
+		t.Errorf("invalid responses %+v", create.Responses)
+	}
+
+	get := doc.Paths["/api/users/{id}"]["get"]
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || get.Parameters[0].In != "path" ||
+		!get.Parameters[0].Required || get.Parameters[0].Description != "the user's id" {
+		t.Errorf("invalid parameters %+v", get.Parameters)
+	}
+	if _, ok := get.Responses["default"]; !ok {
+		t.Errorf("invalid undocumented responses %+v", get.Responses)
+	}
+	if files := doc.Paths["/api/files/{path}"]["get"]; len(files.Parameters) != 1 || files.Parameters[0].Name != "path" {
+		t.Errorf("invalid wildcard parameters %+v", files.Parameters)
+	}
+}
+
+func TestServe(t *testing.T) {
+	rr := router.New("/")
+	api := rr.SubRouter("/api")
+	Serve(*api, "/docs", Info{Title: "Users", Version: "1.0.0"})
+	api.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	r, _ := http.NewRequest("GET", "/api/docs/openapi.json", nil)
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+	if w.Code != 200 || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("invalid response %d %v", w.Code, w.Header())
+	}
+	var doc struct{ Paths map[string]interface{} }
+	json.Unmarshal(w.Body.Bytes(), &doc)
+	if len(doc.Paths) != 1 || doc.Paths["/api/users"] == nil {
+		t.Errorf("invalid paths %v", doc.Paths)
+	}
+
+	r, _ = http.NewRequest("GET", "/api/docs", nil)
+	w = httptest.NewRecorder()
+	rr.ServeHTTP(w, r)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `url: "/api/docs/openapi.json"`) {
+		t.Errorf("invalid swagger ui %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/chrisolsen/router"
+)
+
+// Serve registers routes under the path serving the router's document at `openapi.json` and
+// Swagger UI for browsing it. The document is generated on each request, so it includes routes
+// registered after Serve, and leaves out its own routes, named `openapi.spec` and `openapi.ui`.
+//
+//	openapi.Serve(rr, "/docs", openapi.Info{Title: "Billing", Version: "2.1.0"})
+func Serve(rr router.Router, path string, info Info) {
+	path = "/" + strings.Trim(path, "/")
+	rr.Get(strings.TrimRight(path, "/")+"/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		doc := document(rr.Routes(), info, func(route router.RouteInfo) bool {
+			return route.Name == "openapi.spec" || route.Name == "openapi.ui"
+		})
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(doc)
+	}).Name("openapi.spec")
+
+	rr.Get(path, func(w http.ResponseWriter, r *http.Request) {
+		spec, err := rr.URLFor("openapi.spec")
+		if err != nil {
+			router.Fail(r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		swaggerUI.Execute(w, struct {
+			Title string
+			URL   string
+		}{info.Title, spec})
+	}).Name("openapi.ui")
+}
+
+var swaggerUI = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({url: {{.URL}}, dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`))
@@ -0,0 +1,77 @@
+package router
+
+import "sync"
+
+// param is a url param matched from the path
+type param struct {
+	key, value string
+}
+
+// paramSource holds the url params bound to the request's context, either the pooled params of the
+// matched route or a map of them
+type paramSource interface {
+	get(key string) string
+	toMap() map[string]string
+}
+
+// routeParams are the url params of the matched route. They're pooled between requests, with the
+// keys and values sliced out of the route and request paths, so matching a route doesn't allocate.
+type routeParams struct {
+	params []param
+}
+
+var paramsPool = sync.Pool{
+	New: func() interface{} {
+		return &routeParams{params: make([]param, 0, 4)}
+	},
+}
+
+func acquireParams() *routeParams {
+	return paramsPool.Get().(*routeParams)
+}
+
+// release returns the params to the pool, once nothing within the request can read them
+func (p *routeParams) release() {
+	p.reset()
+	paramsPool.Put(p)
+}
+
+func (p *routeParams) reset() {
+	p.params = p.params[:0]
+}
+
+func (p *routeParams) add(key, value string) {
+	p.params = append(p.params, param{key: key, value: value})
+}
+
+func (p *routeParams) get(key string) string {
+	for _, kv := range p.params {
+		if kv.key == key {
+			return kv.value
+		}
+	}
+	return ""
+}
+
+// toMap copies the params, nil if there are none
+func (p *routeParams) toMap() map[string]string {
+	if len(p.params) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(p.params))
+	for _, kv := range p.params {
+		m[kv.key] = kv.value
+	}
+	return m
+}
+
+// paramMap are the params of a request that outlives the pooled params, or set by WithParams
+type paramMap map[string]string
+
+func (p paramMap) get(key string) string {
+	return p[key]
+}
+
+func (p paramMap) toMap() map[string]string {
+	return p
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestPooledParams(t *testing.T) {
+	rr := New("/")
+	api := rr.SubRouter("/api")
+	api.Get("/orgs/:org/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %v", Param(r.Context(), "org"), Param(r.Context(), "id"), Params(r.Context()))
+	})
+	api.Get("/files/*", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Param(r.Context(), "*")))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, _ := http.NewRequest("GET", fmt.Sprintf("/api/orgs/org%d/users/%d", i, i), nil)
+			r = Observe(r)
+			w := httptest.NewRecorder()
+			rr.ServeHTTP(w, r)
+			expected := fmt.Sprintf("org%d %d map[id:%d org:org%d]", i, i, i, i)
+			if w.Body.String() != expected {
+				t.Errorf("invalid params %q != %q", w.Body.String(), expected)
+			}
+			if p := RoutePattern(r.Context()); p != "/api/orgs/:org/users/:id" {
+				t.Errorf("invalid pattern %q after serving", p)
+			}
+
+			r, _ = http.NewRequest("GET", fmt.Sprintf("/api/files/%d/a.txt", i), nil)
+			w = httptest.NewRecorder()
+			rr.ServeHTTP(w, r)
+			if expected := fmt.Sprintf("%d/a.txt", i); w.Body.String() != expected {
+				t.Errorf("invalid wildcard %q != %q", w.Body.String(), expected)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestMatchRouteResetsParams(t *testing.T) {
+	rr := New("/")
+	params := acquireParams()
+	defer params.release()
+
+	// a route rejected after its params are extracted leaves none behind
+	if reason := matchRoute(&rr, Route{method: "GET", path: "/users/:id/posts"}, "GET", "/users/1/comments", false, false, params); reason != rejectSegment {
+		t.Errorf("invalid reason %q", reason)
+	}
+	if len(params.params) != 0 {
+		t.Errorf("params left behind %v", params.params)
+	}
+	if reason := matchRoute(&rr, Route{method: "GET", path: "/users/:id"}, "GET", "/users/2", false, false, params); reason != "" {
+		t.Errorf("invalid reason %q", reason)
+	}
+	if id := params.get("id"); id != "2" || len(params.params) != 1 {
+		t.Errorf("invalid params %v", params.params)
+	}
+}
+
+func TestParamsEmpty(t *testing.T) {
+	rr := New("/")
+	rr.Get("/x", func(w http.ResponseWriter, r *http.Request) {
+		params := Params(r.Context())
+		params["k"] = "v"
+		w.Write([]byte(params["k"]))
+	})
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/x", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "v" {
+		t.Errorf("params of routes without any should be writable, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestObservedParamsOutliveServe(t *testing.T) {
+	rr := New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+
+	r := Observe(httptest.NewRequest("GET", "/users/1", nil))
+	rr.ServeHTTP(httptest.NewRecorder(), r)
+	// the pooled params are reused by the next request
+	rr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+
+	state, _ := Get(r.Context(), stateCtxKey)
+	req, _ := state.request()
+	if id := Param(req.Context(), "id"); id != "1" {
+		t.Errorf("the observed request should keep its params, got %q", id)
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PatternError describes why a route's pattern is invalid
+type PatternError struct {
+	Pattern string
+	Problem string
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("router: invalid pattern %q: %s", e.Pattern, e.Problem)
+}
+
+// ValidatePattern checks that the route pattern is well formed, returning a *PatternError for
+// patterns with empty segments, unnamed, invalid or duplicate params, a wildcard that isn't the last
+// segment, or whitespace, control characters, `?` and `#`. The router panics with the error when a
+// route is registered with an invalid pattern, so the check is for patterns loaded from config.
+//
+//	router.ValidatePattern("/users/:id/posts/:id") // => duplicate param :id
+func ValidatePattern(pattern string) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &PatternError{Pattern: pattern, Problem: fmt.Sprintf(format, args...)}
+	}
+	for _, c := range pattern {
+		if c <= ' ' || c == 0x7f || c == '?' || c == '#' {
+			return invalid("invalid character %q", c)
+		}
+	}
+
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+	if trimmed == "" {
+		return nil
+	}
+	segments := strings.Split(trimmed, "/")
+	params := make(map[string]bool)
+	for i, segment := range segments {
+		switch {
+		case segment == "":
+			return invalid("empty segment")
+		case strings.Contains(segment, "*"):
+			if segment != "*" {
+				return invalid("wildcard must be a whole segment, got %s", segment)
+			}
+			if i != len(segments)-1 {
+				return invalid("segments after the wildcard")
+			}
+		case segment[0] == ':':
+			name := segment[1:]
+			if name == "" {
+				return invalid("param without a name")
+			}
+			for _, c := range name {
+				if !(c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+					return invalid("invalid param name %s", segment)
+				}
+			}
+			if params[name] {
+				return invalid("duplicate param %s", segment)
+			}
+			params[name] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		problem string
+	}{
+		{"/", ""},
+		{"", ""},
+		{"/users/:id/", ""},
+		{"/users/:user_id/posts/:post-id", ""},
+		{"/files/*", ""},
+		{"/v1/jobs:run", ""},
+		{"/café", ""},
+		{"/users//posts", "empty segment"},
+		{"/users/:id/posts/:id", "duplicate param :id"},
+		{"/users/:", "param without a name"},
+		{"/users/:id.json", "invalid param name :id.json"},
+		{"/files/*/meta", "segments after the wildcard"},
+		{"/files/*/:id", "segments after the wildcard"},
+		{"/files/a*", "wildcard must be a whole segment, got a*"},
+		{"/search?q", `invalid character '?'`},
+		{"/about us", `invalid character ' '`},
+		{"/users\n", `invalid character '\n'`},
+	}
+	for _, test := range tests {
+		err := ValidatePattern(test.pattern)
+		if test.problem == "" {
+			if err != nil {
+				t.Errorf("%q: should be valid, got %v", test.pattern, err)
+			}
+			continue
+		}
+		if perr, ok := err.(*PatternError); !ok || perr.Problem != test.problem || perr.Pattern != test.pattern {
+			t.Errorf("%q: invalid error %v", test.pattern, err)
+		}
+	}
+}
+
+func TestInvalidPatternPanics(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*PatternError)
+		if !ok || err.Error() != `router: invalid pattern "/users/:id/posts/:id": duplicate param :id` {
+			t.Errorf("registering an invalid pattern should panic with its error, got %v", err)
+		}
+	}()
+	rr := New("/")
+	rr.Get("/users/:id/posts/:id", func(w http.ResponseWriter, r *http.Request) {})
+}
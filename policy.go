@@ -0,0 +1,62 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Policy is a bundle of wrapping middleware attached to routes or routers, such as those of the
+// policy package
+type Policy interface {
+	// Middleware is called once when the policy is attached, allowing state such as rate limit
+	// counters to be shared by the requests of the routes it's attached to
+	Middleware() func(http.Handler) http.Handler
+}
+
+// Policy wraps the endpoint's handler with the policies, the first being the outermost
+func (e *Endpoint) Policy(policies ...Policy) *Endpoint {
+	for _, p := range policies {
+		e.policies = append(e.policies, p.Middleware())
+		e.attached = append(e.attached, p)
+	}
+	return e
+}
+
+// Policy wraps the handlers of all the router's routes, including those of its subrouters, with
+// the policies. The policies of parent routers wrap those of their subrouters, which wrap those of
+// the endpoints.
+func (r *Router) Policy(policies ...Policy) {
+	for _, p := range policies {
+		r.policies = append(r.policies, p.Middleware())
+		r.attached = append(r.attached, p)
+	}
+}
+
+// policyNames describes the policies within a snapshot, using their String methods if they have one
+func policyNames(policies []Policy) []string {
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		if s, ok := p.(fmt.Stringer); ok {
+			names[i] = s.String()
+		} else {
+			names[i] = fmt.Sprintf("%T", p)
+		}
+	}
+	return names
+}
+
+// applyPolicies wraps the handler with the policies of the endpoint and the routers leading to
+// the matched router. Policies run after the Before middleware, so they can rely on its context.
+func (r Router) applyPolicies(rr *Router, ep *Endpoint, h http.Handler) http.Handler {
+	wrap := func(policies []func(http.Handler) http.Handler) {
+		for i := len(policies) - 1; i >= 0; i-- {
+			h = policies[i](h)
+		}
+	}
+	wrap(ep.policies)
+	routers := append([]*Router{&r}, r.routerPath(rr)...)
+	for i := len(routers) - 1; i >= 0; i-- {
+		wrap(routers[i].policies)
+	}
+	return h
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"errors"
+
+	"github.com/chrisolsen/router"
+)
+
+// RequireCache is a router.LintRule check requiring a policy of the route to set a cache profile
+func RequireCache(route router.RouteInfo) error {
+	return require(route, "no cache profile", func(p Policy) bool {
+		return p.Cache.header() != ""
+	})
+}
+
+// RequireBodyLimit is a router.LintRule check requiring a policy of the route to limit the body size
+func RequireBodyLimit(route router.RouteInfo) error {
+	return require(route, "no body limit", func(p Policy) bool {
+		return p.MaxBodyBytes > 0
+	})
+}
+
+// RequireAuth is a router.LintRule check requiring a policy of the route to authenticate requests
+func RequireAuth(route router.RouteInfo) error {
+	return require(route, "no auth", func(p Policy) bool {
+		return p.Auth != nil
+	})
+}
+
+// RequireTimeout is a router.LintRule check requiring a policy of the route to set a timeout
+func RequireTimeout(route router.RouteInfo) error {
+	return require(route, "no timeout", func(p Policy) bool {
+		return p.Timeout > 0
+	})
+}
+
+// require checks whether any of the route's policies satisfy the test
+func require(route router.RouteInfo, message string, test func(Policy) bool) error {
+	for _, rp := range route.Policies {
+		switch p := rp.(type) {
+		case Policy:
+			if test(p) {
+				return nil
+			}
+		case *Policy:
+			if test(*p) {
+				return nil
+			}
+		}
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,129 @@
+// Package policy defines standard bundles of rate limits, body limits, timeouts, authentication
+// and caching that are attached to routes or routers as a whole:
+//
+//	var PublicRead = policy.Policy{
+//		RateLimit: middleware.Limit{Requests: 100, Window: time.Minute},
+//		Timeout:   5 * time.Second,
+//		Cache:     policy.Cache{MaxAge: time.Minute, Public: true},
+//	}
+//
+//	rr.Get("/products", listProducts).Policy(PublicRead)
+//	admin.Policy(policy.Policy{Auth: middleware.IsAuthenticated})
+package policy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisolsen/router/middleware"
+)
+
+// Policy is applied to the routes it's attached to. Zero values are left unrestricted.
+type Policy struct {
+	// Name identifies the policy within router snapshots
+	Name string
+
+	// RateLimit limits the requests of each client, keyed by RateLimitKey or the client's address
+	RateLimit    middleware.Limit
+	RateLimitKey func(r *http.Request) string
+
+	// MaxBodyBytes limits the size of request bodies, reads past it fail
+	MaxBodyBytes int64
+
+	// Timeout responds with a 503 when the handler doesn't complete in time
+	Timeout time.Duration
+
+	// Auth rejects requests it doesn't match with a 401. It runs after the router's Before
+	// middleware, ex. middleware.IsAuthenticated following middleware.JWT.
+	Auth middleware.Predicate
+
+	// Cache sets the Cache-Control header of the response
+	Cache Cache
+}
+
+// Cache is a Cache-Control profile
+type Cache struct {
+	MaxAge  time.Duration
+	Public  bool
+	NoStore bool
+}
+
+// header returns the Cache-Control value of the profile, empty when unset
+func (c Cache) header() string {
+	if c.NoStore {
+		return "no-store"
+	}
+	if c.MaxAge <= 0 {
+		return ""
+	}
+	directives := []string{"private"}
+	if c.Public {
+		directives[0] = "public"
+	}
+	directives = append(directives, "max-age="+strconv.Itoa(int(c.MaxAge.Seconds())))
+	return strings.Join(directives, ", ")
+}
+
+// String names the policy within router snapshots, describing its settings when it has no name
+func (p Policy) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	var settings []string
+	if p.RateLimit.Requests > 0 {
+		settings = append(settings, fmt.Sprintf("ratelimit=%d/%s", p.RateLimit.Requests, p.RateLimit.Window))
+	}
+	if p.MaxBodyBytes > 0 {
+		settings = append(settings, fmt.Sprintf("maxbody=%d", p.MaxBodyBytes))
+	}
+	if p.Timeout > 0 {
+		settings = append(settings, "timeout="+p.Timeout.String())
+	}
+	if p.Auth != nil {
+		settings = append(settings, "auth")
+	}
+	if cache := p.Cache.header(); cache != "" {
+		settings = append(settings, "cache="+strings.ReplaceAll(cache, " ", ""))
+	}
+	return "policy(" + strings.Join(settings, " ") + ")"
+}
+
+// Middleware implements router.Policy
+func (p Policy) Middleware() func(http.Handler) http.Handler {
+	var limit http.HandlerFunc
+	if p.RateLimit.Requests > 0 {
+		limit = middleware.RateLimit(p.RateLimit, p.RateLimitKey)
+	}
+	var timeout func(http.Handler) http.Handler
+	if p.Timeout > 0 {
+		timeout = middleware.Timeout(p.Timeout)
+	}
+	cacheControl := p.Cache.header()
+
+	return func(next http.Handler) http.Handler {
+		if timeout != nil {
+			next = timeout(next)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p.Auth != nil && !p.Auth(r) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if limit != nil {
+				if limit(w, r); r.Context().Err() != nil {
+					return
+				}
+			}
+			if p.MaxBodyBytes > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, p.MaxBodyBytes)
+			}
+			if cacheControl != "" {
+				w.Header().Set("Cache-Control", cacheControl)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/middleware"
+)
+
+func TestPolicy(t *testing.T) {
+	publicRead := Policy{
+		RateLimit: middleware.Limit{Requests: 2, Window: time.Minute},
+		Cache:     Cache{MaxAge: time.Minute, Public: true},
+	}
+	upload := Policy{
+		Auth:         func(r *http.Request) bool { return r.Header.Get("Authorization") != "" },
+		MaxBodyBytes: 4,
+		Cache:        Cache{NoStore: true},
+	}
+
+	rr := router.New("/")
+	rr.Get("/products", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("products"))
+	}).Policy(publicRead)
+	rr.Get("/categories", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("categories"))
+	}).Policy(publicRead)
+	rr.Post("/uploads", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}).Policy(upload)
+
+	send := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.Header.Set("Authorization", "token")
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("GET", "/products", "", false)
+	if rec.Code != 200 || rec.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("invalid response %d %v", rec.Code, rec.Header())
+	}
+	send("GET", "/products", "", false)
+
+	// each attachment of the policy has its own limits
+	if rec := send("GET", "/products", "", false); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("rate limit not applied %d", rec.Code)
+	}
+	if rec := send("GET", "/categories", "", false); rec.Code != 200 {
+		t.Errorf("rate limit shared between attachments %d", rec.Code)
+	}
+
+	if rec := send("POST", "/uploads", "data", false); rec.Code != 401 {
+		t.Errorf("auth not required %d", rec.Code)
+	}
+	rec = send("POST", "/uploads", "data", true)
+	if rec.Code != 201 || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("invalid response %d %v", rec.Code, rec.Header())
+	}
+	if rec := send("POST", "/uploads", "too large", true); rec.Code != 413 {
+		t.Errorf("body limit not applied %d", rec.Code)
+	}
+}
+
+func TestPolicyTimeout(t *testing.T) {
+	rr := router.New("/")
+	rr.Policy(Policy{Timeout: 10 * time.Millisecond})
+	rr.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("timeout not applied %d", rec.Code)
+	}
+}
+
+func TestPolicyString(t *testing.T) {
+	tests := []struct {
+		policy   Policy
+		expected string
+	}{
+		{Policy{Name: "public-read", Timeout: time.Second}, "public-read"},
+		{Policy{}, "policy()"},
+		{Policy{
+			RateLimit:    middleware.Limit{Requests: 10, Window: time.Minute},
+			MaxBodyBytes: 1024,
+			Timeout:      time.Second,
+			Auth:         middleware.IsAuthenticated,
+			Cache:        Cache{MaxAge: time.Minute},
+		}, "policy(ratelimit=10/1m0s maxbody=1024 timeout=1s auth cache=private,max-age=60)"},
+	}
+	for _, test := range tests {
+		if s := test.policy.String(); s != test.expected {
+			t.Errorf("%q != %q", s, test.expected)
+		}
+	}
+}
+
+func TestLintChecks(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := router.New("/")
+	admin := rr.SubRouter("/admin")
+	admin.Policy(Policy{Auth: middleware.IsAuthenticated})
+	admin.Post("/users", fn).Policy(&Policy{MaxBodyBytes: 1024})
+	rr.Get("/products", fn).Policy(Policy{Cache: Cache{MaxAge: time.Minute}})
+	rr.Post("/orders", fn).Policy(Policy{Timeout: time.Second})
+
+	err := rr.Lint(
+		router.LintRule{Name: "auth", Routes: router.PathPrefix("/admin"), Check: RequireAuth},
+		router.LintRule{Name: "body", Routes: router.Methods("POST"), Check: RequireBodyLimit},
+		router.LintRule{Name: "cache", Routes: router.Methods("GET"), Check: RequireCache},
+		router.LintRule{Name: "timeout", Check: RequireTimeout},
+	)
+	lintErr, ok := err.(*router.LintError)
+	if !ok {
+		t.Fatalf("invalid error %v", err)
+	}
+	expected := []string{"timeout /admin/users", "body /orders", "timeout /products"}
+	if len(lintErr.Violations) != len(expected) {
+		t.Fatalf("invalid violations %+v", lintErr.Violations)
+	}
+	for i, v := range lintErr.Violations {
+		if s := v.Rule + " " + v.Route.Pattern; s != expected[i] {
+			t.Errorf("%q != %q", s, expected[i])
+		}
+	}
+}
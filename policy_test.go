@@ -0,0 +1,43 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testPolicy string
+
+func (p testPolicy) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(string(p) + ">"))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestPolicyOrder(t *testing.T) {
+	rr := New("/")
+	rr.Policy(testPolicy("root"))
+	admin := rr.SubRouter("/admin")
+	admin.Policy(testPolicy("admin"))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("handler"))
+	}
+	admin.Get("/users", handler).Policy(testPolicy("a"), testPolicy("b"))
+	rr.Get("/", handler)
+
+	tests := map[string]string{
+		"/":            "root>handler",
+		"/admin/users": "root>admin>a>b>handler",
+	}
+	for path, expected := range tests {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Body.String() != expected {
+			t.Errorf("%s: %q != %q", path, rec.Body.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// preload is an asset the client is told to fetch along with the route's response
+type preload struct {
+	path string
+	as   string
+}
+
+// Preload hints the client to fetch the asset while the route's response is being generated,
+// sending a `Link: <path>; rel=preload; as=<as>` header. Over HTTP/2 the asset is also pushed
+// when the connection supports it, and with EarlyHints it's announced ahead of the response in a
+// 103 Early Hints. as is the destination of the asset, ex. `style`, `script`, `font` or `image`.
+//
+//	rr.Get("/", home).Preload("/css/app.css", "style").Preload("/js/app.js", "script")
+func (e *Endpoint) Preload(path, as string) *Endpoint {
+	e.preloads = append(e.preloads, preload{path: path, as: as})
+	return e
+}
+
+// EarlyHints sends the Link headers of the routes' preloads in a 103 Early Hints response ahead of
+// the final response, letting the client start fetching while the handler runs. It's disabled by
+// default, as middleware wrapping the router must pass the informational response on rather than
+// mistake it for the final status.
+func (r *Router) EarlyHints(enabled bool) {
+	r.earlyHints = enabled
+}
+
+// EarlyHints sends the links in a 103 Early Hints response, letting the client start fetching them
+// while the handler prepares the final response. The links are full Link header values, and are
+// sent with the final response too. Handlers should only send hints for GET requests made over
+// HTTP/1.1 or later.
+//
+//	router.EarlyHints(w, []string{"</css/app.css>; rel=preload; as=style"})
+//	page := render(slowQuery())
+func EarlyHints(w http.ResponseWriter, links []string) {
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+}
+
+// EarlyHints sends the links in a 103 Early Hints response before the route's handler runs, when
+// the router sends early hints, and as Link headers of the response otherwise. The links are full
+// Link header values, ex. `<https://cdn.example.com>; rel=preconnect`.
+func (e *Endpoint) EarlyHints(links ...string) *Endpoint {
+	e.earlyHints = append(e.earlyHints, links...)
+	return e
+}
+
+// sendHints sets the Link headers of the endpoint's preloads and early hints, pushing the assets
+// and sending the early hints when possible
+func (r Router) sendHints(w http.ResponseWriter, req *http.Request, ep *Endpoint) {
+	links := make([]string, 0, len(ep.preloads)+len(ep.earlyHints))
+	for _, p := range ep.preloads {
+		link := "<" + p.path + ">; rel=preload"
+		if p.as != "" {
+			link += "; as=" + p.as
+		}
+		links = append(links, link)
+	}
+
+	if pusher, ok := w.(http.Pusher); ok {
+		for _, p := range ep.preloads {
+			// only assets of the same origin can be pushed
+			if strings.HasPrefix(p.path, "/") && !strings.HasPrefix(p.path, "//") {
+				pusher.Push(p.path, nil)
+			}
+		}
+	}
+
+	// HEAD responses are held back by the router, and HTTP/1.0 clients don't expect 1xx responses
+	links = append(links, ep.earlyHints...)
+	if r.earlyHints && req.Method == http.MethodGet && req.ProtoAtLeast(1, 1) {
+		EarlyHints(w, links)
+		return
+	}
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+}
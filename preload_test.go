@@ -0,0 +1,155 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (pr *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	pr.pushed = append(pr.pushed, target)
+	return nil
+}
+
+func TestPreload(t *testing.T) {
+	rr := New("/")
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {}).
+		Preload("/css/app.css", "style").
+		Preload("https://cdn.example.com/font.woff2", "font")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rr.ServeHTTP(w, req)
+
+	links := w.Result().Header.Values("Link")
+	expected := []string{
+		"</css/app.css>; rel=preload; as=style",
+		"<https://cdn.example.com/font.woff2>; rel=preload; as=font",
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("invalid links %q", links)
+	}
+	if !reflect.DeepEqual(w.pushed, []string{"/css/app.css"}) {
+		t.Errorf("only same origin assets should be pushed, got %q", w.pushed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("invalid status %d", w.Code)
+	}
+	if !strings.Contains(rr.Snapshot(), "preload=/css/app.css,https://cdn.example.com/font.woff2") {
+		t.Errorf("snapshot should list the preloads\n%s", rr.Snapshot())
+	}
+}
+
+// getEarlyHints requests the url, returning the links of its early hints and its response
+func getEarlyHints(t *testing.T, url string) ([]string, *http.Response) {
+	var hints []string
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, header.Values("Link")...)
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequest("GET", url, nil)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return hints, resp
+}
+
+func TestEarlyHints(t *testing.T) {
+	rr := New("/")
+	rr.EarlyHints(true)
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home"))
+	}).Preload("/css/app.css", "style")
+	srv := httptest.NewServer(rr)
+	defer srv.Close()
+
+	hints, resp := getEarlyHints(t, srv.URL)
+	if !reflect.DeepEqual(hints, []string{"</css/app.css>; rel=preload; as=style"}) {
+		t.Errorf("invalid early hints %q", hints)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Link") == "" {
+		t.Errorf("invalid response %d %q", resp.StatusCode, resp.Header.Get("Link"))
+	}
+}
+
+func TestRouteEarlyHints(t *testing.T) {
+	rr := New("/")
+	rr.EarlyHints(true)
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {}).
+		Preload("/css/app.css", "style").
+		EarlyHints("<https://cdn.example.com>; rel=preconnect")
+	rr.Get("/report", func(w http.ResponseWriter, r *http.Request) {
+		EarlyHints(w, []string{"</js/chart.js>; rel=preload; as=script"})
+		w.Write([]byte("report"))
+	})
+	rr.Get("/reports", func(w http.ResponseWriter, r *http.Request) {
+		EarlyHints(w, nil)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("report"))
+	})
+	srv := httptest.NewServer(rr)
+	defer srv.Close()
+
+	tests := []struct {
+		path  string
+		hints []string
+	}{
+		{"/", []string{"</css/app.css>; rel=preload; as=style", "<https://cdn.example.com>; rel=preconnect"}},
+		{"/report", []string{"</js/chart.js>; rel=preload; as=script"}},
+	}
+	for _, test := range tests {
+		hints, resp := getEarlyHints(t, srv.URL+test.path)
+		if !reflect.DeepEqual(hints, test.hints) {
+			t.Errorf("%s: invalid early hints %q", test.path, hints)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: invalid status %d", test.path, resp.StatusCode)
+		}
+	}
+	if !strings.Contains(rr.Snapshot(), "earlyhints=<https://cdn.example.com>") {
+		t.Errorf("snapshot should list the early hints\n%s", rr.Snapshot())
+	}
+
+	// HEAD requests served by GET hold back the final status only
+	req, _ := http.NewRequest("HEAD", srv.URL+"/reports", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || resp.ContentLength != int64(len("report")) {
+		t.Errorf("invalid HEAD response %d %d", resp.StatusCode, resp.ContentLength)
+	}
+}
+
+func TestRouteEarlyHintsDisabled(t *testing.T) {
+	rr := New("/")
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {}).
+		EarlyHints("<https://cdn.example.com>; rel=preconnect")
+	srv := httptest.NewServer(rr)
+	defer srv.Close()
+
+	hints, resp := getEarlyHints(t, srv.URL)
+	if len(hints) != 0 {
+		t.Errorf("early hints should only be sent once enabled, got %q", hints)
+	}
+	if link := resp.Header.Get("Link"); link != "<https://cdn.example.com>; rel=preconnect" {
+		t.Errorf("the hints should be sent with the response, got %q", link)
+	}
+}
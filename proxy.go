@@ -0,0 +1,59 @@
+package router
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+// ProxyOptions configures a reverse proxy
+type ProxyOptions struct {
+	// FlushInterval is how often the response is flushed to the client while it's being copied.
+	// Zero flushes after every write so chunked and streamed responses aren't held back; a
+	// positive interval batches writes of large downloads.
+	FlushInterval time.Duration
+
+	// PreserveHost passes on the request's Host header rather than the target's host
+	PreserveHost bool
+
+	// Transport defaults to http.DefaultTransport
+	Transport http.RoundTripper
+
+	// ErrorLog receives the errors of failed upstream requests, which respond with a 502
+	ErrorLog *log.Logger
+}
+
+// NewProxy creates a reverse proxy to the target, typically mounted under a prefix. Request and
+// response bodies are streamed rather than buffered, so large multipart uploads and downloads
+// pass through with constant memory. Middleware that buffers the response, such as
+// middleware.Timeout, undoes the streaming.
+//
+//	files, _ := url.Parse("http://files.internal:8080")
+//	rr.Mount("/files", router.NewProxy(files, router.ProxyOptions{}))
+func NewProxy(target *url.URL, opts ProxyOptions) http.Handler {
+	return newReverseProxy(target, opts)
+}
+
+func newReverseProxy(target *url.URL, opts ProxyOptions) *httputil.ReverseProxy {
+	p := httputil.NewSingleHostReverseProxy(target)
+	p.FlushInterval = opts.FlushInterval
+	if p.FlushInterval == 0 {
+		p.FlushInterval = -1
+	}
+	p.Transport = opts.Transport
+	p.ErrorLog = opts.ErrorLog
+
+	director := p.Director
+	p.Director = func(r *http.Request) {
+		host := r.Host
+		director(r)
+		if opts.PreserveHost {
+			r.Host = host
+		} else {
+			r.Host = target.Host
+		}
+	}
+	return p
+}
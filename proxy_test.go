@@ -0,0 +1,108 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyStreamsResponses(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s\n", r.URL.Path, r.Host)
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "done\n")
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	target, _ := url.Parse(backend.URL)
+	rr := New("/")
+	rr.Mount("/files", NewProxy(target, ProxyOptions{}))
+	front := httptest.NewServer(rr)
+	defer front.Close()
+
+	resp, err := http.Get(front.URL + "/files/report.csv")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+
+	// the first line arrives while the backend is still blocked
+	line := make(chan string, 1)
+	go func() {
+		s, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		line <- s
+	}()
+	select {
+	case s := <-line:
+		if s != "/report.csv "+target.Host+"\n" {
+			t.Errorf("invalid first line %q", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("response was buffered")
+	}
+}
+
+func TestProxyStreamsMultipartUploads(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		part, err := mr.NextPart()
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		n, _ := io.Copy(io.Discard, part)
+		fmt.Fprintf(w, "%s %d", part.FileName(), n)
+	}))
+	defer backend.Close()
+
+	target, _ := url.Parse(backend.URL)
+	rr := New("/")
+	rr.Mount("/files", NewProxy(target, ProxyOptions{PreserveHost: true}))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "big.bin")
+	fw.Write(bytes.Repeat([]byte("x"), 1<<20))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/files/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != 200 || rec.Body.String() != "big.bin 1048576" {
+		t.Errorf("invalid response %d %s", rec.Code, rec.Body.String())
+	}
+	if req.MultipartForm != nil {
+		t.Error("upload should not be parsed by the router")
+	}
+}
+
+func TestProxyUnavailable(t *testing.T) {
+	target, _ := url.Parse("http://127.0.0.1:1")
+	rr := New("/")
+	rr.Mount("/files", NewProxy(target, ProxyOptions{ErrorLog: log.New(io.Discard, "", 0)}))
+
+	req := httptest.NewRequest("GET", "/files/a", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("invalid status %d %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+}
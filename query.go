@@ -0,0 +1,92 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// queryConstraint requires the query param to be present, and to have one of the values if any are set
+type queryConstraint struct {
+	key    string
+	values []string
+}
+
+// Query requires the request to have the query param, optionally with one of the values. Requests that
+// fail the constraints fall through to the route's other endpoints or the other matching routes,
+// receiving a 400 if no route matches them.
+//
+//	rr.Get("/search", search).Query("q")
+//	rr.Get("/export", exportCSV).Query("format", "csv")
+//	rr.Get("/export", exportJSON)
+func (e *Endpoint) Query(key string, values ...string) *Endpoint {
+	e.query = append(e.query, queryConstraint{key: key, values: values})
+	return e
+}
+
+// matchesQuery checks whether all the query constraints of the endpoint are met
+func (e *Endpoint) matchesQuery(query url.Values) bool {
+	for _, c := range e.query {
+		got, ok := query[c.key]
+		if !ok {
+			return false
+		}
+		if len(c.values) > 0 && !anyEqual(got, c.values) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyEqual(got, values []string) bool {
+	for _, g := range got {
+		for _, v := range values {
+			if g == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// queryEndpoints returns the endpoints of the route whose query constraints are met by the request,
+// ordered so the most constrained, and then the most recently registered, come last
+func (r Router) queryEndpoints(route Route, req *http.Request) []*Endpoint {
+	eps := r.endpoints[route]
+	constrained := false
+	for _, ep := range eps {
+		if len(ep.query) > 0 {
+			constrained = true
+			break
+		}
+	}
+	if !constrained {
+		return eps
+	}
+
+	query := req.URL.Query()
+	matched := make([]*Endpoint, 0, len(eps))
+	for _, ep := range eps {
+		if ep.matchesQuery(query) {
+			matched = append(matched, ep)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return len(matched[i].query) < len(matched[j].query)
+	})
+	return matched
+}
+
+// describeQuery formats the constraints as they would appear in a query string, ex. `q&format=csv|json`
+func (e *Endpoint) describeQuery() string {
+	parts := make([]string, 0, len(e.query))
+	for _, c := range e.query {
+		if len(c.values) == 0 {
+			parts = append(parts, c.key)
+		} else {
+			parts = append(parts, c.key+"="+strings.Join(c.values, "|"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
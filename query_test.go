@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	write := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	rr := New("/")
+	rr.Get("/search", write("search")).Query("q")
+	rr.Get("/export", write("csv")).Query("format", "csv")
+	rr.Get("/export", write("json"))
+	rr.Get("/export", write("spreadsheet")).Query("format", "xls", "xlsx").Query("sheet")
+	rr.Get("/:page", write("page")).Query("preview", "true")
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/search?q=router", 200, "search"},
+		{"/search?q=", 200, "search"},
+		{"/search", 400, "Bad Request\n"},
+		{"/search?preview=true", 200, "page"},
+		{"/export?format=csv", 200, "csv"},
+		{"/export?format=tsv", 200, "json"},
+		{"/export", 200, "json"},
+		{"/export?format=xlsx&sheet=1", 200, "spreadsheet"},
+		{"/export?format=xlsx", 200, "json"},
+		{"/about", 400, "Bad Request\n"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d != %d", test.path, rec.Code, test.status)
+		}
+		if rec.Body.String() != test.body {
+			t.Errorf("%s: invalid body %q != %q", test.path, rec.Body.String(), test.body)
+		}
+	}
+}
+
+func TestExplainQuery(t *testing.T) {
+	rr := New("/")
+	rr.Get("/search", func(w http.ResponseWriter, r *http.Request) {}).Query("q")
+
+	if result := rr.Explain("GET", "/search?q=router"); result.Matched == nil || result.Path != "/search" {
+		t.Errorf("search should match %+v", result)
+	}
+	result := rr.Explain("GET", "/search")
+	if result.Matched != nil || result.Candidates[0].Reason != rejectQuery {
+		t.Errorf("search should be rejected by its query %+v", result)
+	}
+}
@@ -0,0 +1,297 @@
+package router
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Redirect describes a legacy path that should be sent on to a new location. The path can be a
+// pattern, whose params and wildcard are carried over to the location, ex. `/old/:id` to `/new/:id`.
+type Redirect struct {
+	From   string
+	To     string
+	Status int
+}
+
+// Redirect registers a redirect from the old path or pattern to the new location, sent with the
+// status. The request's query string is carried over to the location. An error is returned if the
+// old path is already redirected, the pattern overlaps another redirect's pattern or an existing
+// route, or the location uses a param the pattern doesn't have. Paths redirected exactly take
+// precedence over patterns, and routes registered later take precedence over the redirect.
+//
+//	rr.Redirect("/blog/:year/:slug", "/posts/:slug", http.StatusMovedPermanently)
+func (r Router) Redirect(from, to string, status int) error {
+	return r.addRedirects([]Redirect{{From: from, To: to, Status: status}})
+}
+
+// RedirectRoutes registers the old => new redirects between paths or patterns, using a 301 status,
+// as Redirect does
+//
+//	rr.RedirectRoutes(map[string]string{
+//		"/users/:id/profile": "/people/:id",
+//		"/downloads/*":       "https://cdn.example.com/*",
+//	})
+func (r Router) RedirectRoutes(paths map[string]string) error {
+	redirects := make([]Redirect, 0, len(paths))
+	for from, to := range paths {
+		redirects = append(redirects, Redirect{From: from, To: to})
+	}
+	return r.addRedirects(redirects)
+}
+
+// Redirects registers the old => new path redirects, using a 301 status. It's the same as
+// RedirectRoutes.
+func (r Router) Redirects(paths map[string]string) error {
+	return r.RedirectRoutes(paths)
+}
+
+// LoadRedirects reads and registers the redirects within the reader. Each line contains the old path,
+// the new path and an optional status code separated by whitespace. Blank lines and lines starting
+// with `#` are ignored.
+//
+//	/about-us   /about
+//	/blog/2019  https://blog.example.com  302
+func (r Router) LoadRedirects(rd io.Reader) error {
+	redirects, err := ParseRedirects(rd)
+	if err != nil {
+		return err
+	}
+	return r.addRedirects(redirects)
+}
+
+// ParseRedirects parses the redirects file format used by LoadRedirects
+func ParseRedirects(rd io.Reader) ([]Redirect, error) {
+	var redirects []Redirect
+	scanner := bufio.NewScanner(rd)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("redirects: line %d: expected `from to [status]`", line)
+		}
+		redirect := Redirect{From: fields[0], To: fields[1]}
+		if len(fields) == 3 {
+			status, err := strconv.Atoi(fields[2])
+			if err != nil || status < 300 || status > 399 {
+				return nil, fmt.Errorf("redirects: line %d: invalid status %q", line, fields[2])
+			}
+			redirect.Status = status
+		}
+		redirects = append(redirects, redirect)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return redirects, nil
+}
+
+// addRedirects validates all the redirects before registering any of them, to prevent a partially
+// loaded redirect list
+func (r Router) addRedirects(redirects []Redirect) error {
+	seen := make(map[string]bool)
+	for key := range r.redirects {
+		seen[key] = true
+	}
+	patterns := make([]string, 0, len(r.redirectPatterns))
+	for key := range r.redirectPatterns {
+		patterns = append(patterns, key)
+	}
+	for _, redirect := range redirects {
+		if redirect.From == "" || redirect.To == "" {
+			return fmt.Errorf("redirects: missing path in %q => %q", redirect.From, redirect.To)
+		}
+		key := redirectKey(&r, redirect.From)
+		if !strings.ContainsAny(key, ":*") {
+			if seen[key] {
+				return fmt.Errorf("redirects: %s is redirected more than once", redirect.From)
+			}
+			seen[key] = true
+		} else {
+			// the patterns are tried in no particular order, so a path must match at most one of them
+			for _, pattern := range patterns {
+				if patternsOverlap(slicePath(key), slicePath(pattern)) {
+					return fmt.Errorf("redirects: %s overlaps the redirect of /%s", redirect.From, pattern)
+				}
+			}
+			patterns = append(patterns, key)
+		}
+		if route, ok := r.redirectConflict(key); ok {
+			return fmt.Errorf("redirects: %s conflicts with the %s route", redirect.From, r.fullPath(route.path))
+		}
+		if param := missingRedirectParam(redirect); param != "" {
+			return fmt.Errorf("redirects: %s uses %s, which %s doesn't have", redirect.To, param, redirect.From)
+		}
+	}
+	for _, redirect := range redirects {
+		if redirect.Status == 0 {
+			redirect.Status = http.StatusMovedPermanently
+		}
+		key := redirectKey(&r, redirect.From)
+		if strings.ContainsAny(key, ":*") {
+			r.redirectPatterns[key] = redirect
+		} else {
+			r.redirects[key] = redirect
+		}
+	}
+	return nil
+}
+
+// redirectConflict returns the route the redirect would shadow, one matching the redirect's path or
+// matched by its pattern. Redirects are looked up before the routes, so they must not overlap.
+func (r *Router) redirectConflict(key string) (Route, bool) {
+	params := acquireParams()
+	defer params.release()
+	for route := range r.routes {
+		_, shadowed := matchRedirect(key, redirectKey(r, route.path))
+		if shadowed || matchRoute(r, route, "", key, true, false, params) == "" {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// missingRedirectParam returns the first param or wildcard of the location that the redirect's
+// pattern doesn't have
+func missingRedirectParam(redirect Redirect) string {
+	params := make(map[string]bool)
+	for _, part := range slicePath(redirect.From) {
+		if strings.HasPrefix(part, ":") || part == "*" {
+			params[part] = true
+		}
+	}
+	to := strings.SplitN(redirect.To, "?", 2)[0]
+	for _, part := range strings.Split(to, "/") {
+		if (strings.HasPrefix(part, ":") || part == "*") && !params[part] {
+			return part
+		}
+	}
+	return ""
+}
+
+// redirectKey normalizes the path so that lookups can be done directly against the request path
+func redirectKey(router *Router, path string) string {
+	if router.basePath != "/" {
+		path = strings.Replace(path, router.basePath, "", 1)
+	}
+	return strings.Trim(path, "/")
+}
+
+// findRedirect returns the redirect registered for the request path, with the params of pattern
+// redirects filled into its location
+func (r Router) findRedirect(path string) (Redirect, bool) {
+	key := strings.Trim(path, "/")
+	redirect, ok := r.redirects[key]
+	if !ok {
+		for pattern, patternRedirect := range r.redirectPatterns {
+			if params, matched := matchRedirect(pattern, key); matched {
+				redirect = patternRedirect
+				redirect.To, ok = fillRedirect(redirect.To, params)
+				break
+			}
+		}
+	}
+	// routes registered after the redirect take precedence over it
+	if !ok || r.routeMatches(key) {
+		return Redirect{}, false
+	}
+	return redirect, true
+}
+
+// routeMatches reports whether any of the router's routes matches the path, whatever its method
+func (r *Router) routeMatches(path string) bool {
+	params := acquireParams()
+	defer params.release()
+	for route := range r.routes {
+		if matchRoute(r, route, "", path, true, false, params) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRedirect matches the trimmed path against the trimmed pattern, returning its params
+func matchRedirect(pattern, path string) (map[string]string, bool) {
+	patternParts, pathParts := strings.Split(pattern, "/"), strings.Split(path, "/")
+	params := make(map[string]string)
+	for i, part := range patternParts {
+		if part == "*" {
+			if i >= len(pathParts) || path == "" {
+				return nil, false
+			}
+			params["*"] = strings.Join(pathParts[i:], "/")
+			return params, true
+		}
+		if i >= len(pathParts) {
+			return nil, false
+		}
+		switch {
+		case strings.HasPrefix(part, ":"):
+			if pathParts[i] == "" {
+				return nil, false
+			}
+			params[part] = pathParts[i]
+		case part != pathParts[i]:
+			return nil, false
+		}
+	}
+	return params, len(patternParts) == len(pathParts)
+}
+
+// fillRedirect replaces the params and wildcard of the location's path with their escaped values.
+// Only the wildcard's values keep their slashes. Locations of the router's own site that the values
+// would turn into another site's, ex. `//evil.com` from `/go//evil.com`, aren't redirected to.
+func fillRedirect(to string, params map[string]string) (string, bool) {
+	template, query := to, ""
+	if i := strings.Index(to, "?"); i >= 0 {
+		to, query = to[:i], to[i:]
+	}
+	parts := strings.Split(to, "/")
+	for i, part := range parts {
+		v, ok := params[part]
+		if !ok {
+			continue
+		}
+		if part == "*" {
+			segments := strings.Split(v, "/")
+			for j, segment := range segments {
+				segments[j] = url.PathEscape(segment)
+			}
+			parts[i] = strings.Join(segments, "/")
+		} else {
+			parts[i] = url.PathEscape(v)
+		}
+	}
+	location := strings.Join(parts, "/") + query
+	if !offsite(template) && offsite(location) {
+		return "", false
+	}
+	return location, true
+}
+
+// offsite reports whether the location could be of another site, having a scheme or host
+func offsite(location string) bool {
+	u, err := url.Parse(location)
+	return err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(location, "//")
+}
+
+// redirectLocation carries the request's query string over to the redirect's location
+func redirectLocation(to string, req *http.Request) string {
+	if req.URL.RawQuery == "" {
+		return to
+	}
+	if strings.Contains(to, "?") {
+		return to + "&" + req.URL.RawQuery
+	}
+	return to + "?" + req.URL.RawQuery
+}
@@ -0,0 +1,219 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirects(t *testing.T) {
+	rr := New("/")
+	admin := rr.SubRouter("/admin")
+	if err := rr.Redirects(map[string]string{"/old": "/new"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := admin.Redirects(map[string]string{"/payroll": "/admin/pay"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		path     string
+		location string
+	}{
+		{"/old", "/new"},
+		{"/old/", "/new"},
+		{"/admin/payroll", "/admin/pay"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("%s: invalid status %d", test.path, rec.Code)
+		}
+		if loc := rec.Header().Get("Location"); loc != test.location {
+			t.Errorf("%s: invalid location %s != %s", test.path, loc, test.location)
+		}
+	}
+}
+
+func TestRedirectsConflict(t *testing.T) {
+	rr := New("/")
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	err := rr.Redirects(map[string]string{"/users/": "/people", "/other": "/people"})
+	if err == nil {
+		t.Error("conflict not detected")
+		return
+	}
+	if len(rr.redirects) != 0 {
+		t.Error("redirects should not be partially registered")
+	}
+}
+
+func TestLoadRedirects(t *testing.T) {
+	file := `
+# legacy marketing pages
+/about-us   /about
+/blog/2019  https://blog.example.com  302
+`
+	rr := New("/")
+	if err := rr.LoadRedirects(strings.NewReader(file)); err != nil {
+		t.Error(err)
+		return
+	}
+
+	req, _ := http.NewRequest("GET", "/blog/2019", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != http.StatusFound {
+		t.Errorf("invalid status %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "https://blog.example.com" {
+		t.Errorf("invalid location %s", loc)
+	}
+}
+
+func TestParseRedirectsErrors(t *testing.T) {
+	tests := []string{
+		"/only-one-path",
+		"/a /b /c /d",
+		"/a /b 200",
+		"/a /b abc",
+	}
+	for _, test := range tests {
+		if _, err := ParseRedirects(strings.NewReader(test)); err == nil {
+			t.Errorf("%q: expected error", test)
+		}
+	}
+}
+
+func TestRedirectPatterns(t *testing.T) {
+	rr := New("/")
+	if err := rr.Redirect("/blog/:year/:slug", "/posts/:slug", http.StatusFound); err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.RedirectRoutes(map[string]string{"/go/*": "/*", "/tag/:name": "/tags/:name"}); err != nil {
+		t.Fatal(err)
+	}
+	api := rr.SubRouter("/api")
+	if err := api.RedirectRoutes(map[string]string{
+		"/users/:id/profile": "/api/people/:id",
+		"/downloads/*":       "https://cdn.example.com/files/*?v=2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/blog/2019/hello", http.StatusFound, "/posts/hello"},
+		{"/blog/2019/hello?ref=rss&utm=x", http.StatusFound, "/posts/hello?ref=rss&utm=x"},
+		{"/api/users/5/profile", http.StatusMovedPermanently, "/api/people/5"},
+		{"/api/downloads/2020/report.pdf?dl=1", http.StatusMovedPermanently, "https://cdn.example.com/files/2020/report.pdf?v=2&dl=1"},
+		{"/blog/2019", http.StatusNotFound, ""},
+		{"/api/downloads", http.StatusNotFound, ""},
+		{"/go/docs/a%20b", http.StatusMovedPermanently, "/docs/a%20b"},
+		{"/tag/a%3Fb", http.StatusMovedPermanently, "/tags/a%3Fb"},
+		{"/go//evil.com", http.StatusNotFound, ""},
+		{"/go/%2Fevil.com", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Code != test.status || rec.Header().Get("Location") != test.location {
+			t.Errorf("%s: invalid redirect %d %s", test.path, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	if err := rr.Redirect("/old/:id", "/new/:slug", http.StatusFound); err == nil {
+		t.Error("missing param not detected")
+	}
+	if !strings.Contains(rr.Snapshot(), "redirect /blog/:year/:slug /posts/:slug 302\n") {
+		t.Errorf("pattern redirect missing from snapshot\n%s", rr.Snapshot())
+	}
+}
+
+func TestRedirectsParamConflicts(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := New("/")
+	rr.Get("/users/:id", fn)
+	rr.Post("/posts/new", fn)
+
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{"/users/new", "/people/new"},
+		{"/users/:name", "/people/:name"},
+		{"/posts/:id", "/articles/:id"},
+		{"/posts/*", "/articles/*"},
+	}
+	for _, test := range tests {
+		if err := rr.Redirect(test.from, test.to, http.StatusFound); err == nil {
+			t.Errorf("%s: conflict not detected", test.from)
+		}
+	}
+	if err := rr.Redirect("/users/1/profile", "/people/1", http.StatusFound); err != nil {
+		t.Errorf("unexpected conflict %v", err)
+	}
+}
+
+func TestRedirectsDuplicates(t *testing.T) {
+	rr := New("/")
+	if err := rr.LoadRedirects(strings.NewReader("/a /b\n/a/ /c")); err == nil {
+		t.Error("duplicate in the same list not detected")
+	}
+	if err := rr.Redirect("/old/:id", "/new/:id", http.StatusFound); err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.Redirect("/old/:slug", "/other/:slug", http.StatusFound); err == nil {
+		t.Error("duplicate pattern not detected")
+	}
+
+	// patterns matching a path in common are tried in no particular order
+	if err := rr.Redirect("/a/:x", "/x/:x", http.StatusFound); err != nil {
+		t.Fatal(err)
+	}
+	for _, from := range []string{"/a/*", "/:y/b", "/*"} {
+		if err := rr.Redirect(from, "/elsewhere", http.StatusFound); err == nil {
+			t.Errorf("%s: overlapping pattern not detected", from)
+		}
+	}
+	if err := rr.RedirectRoutes(map[string]string{"/b/:x/c": "/c", "/b/*": "/d"}); err == nil {
+		t.Error("overlapping patterns in the same list not detected")
+	}
+	for _, from := range []string{"/a/:x/c", "/b/:y", "/a"} {
+		if err := rr.Redirect(from, "/elsewhere", http.StatusFound); err != nil {
+			t.Errorf("%s: %v", from, err)
+		}
+	}
+}
+
+func TestRedirectShadowedByLaterRoute(t *testing.T) {
+	rr := New("/")
+	if err := rr.Redirect("/promo/:id", "/offers/:id", http.StatusFound); err != nil {
+		t.Fatal(err)
+	}
+	rr.Get("/promo/summer", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("summer"))
+	})
+
+	for path, expected := range map[string]int{"/promo/summer": http.StatusOK, "/promo/winter": http.StatusFound} {
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != expected {
+			t.Errorf("%s: invalid status %d", path, rec.Code)
+		}
+	}
+	if result := rr.Explain("GET", "/promo/summer"); result.Redirect != nil {
+		t.Errorf("explain should not redirect %+v", result.Redirect)
+	}
+}
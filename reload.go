@@ -0,0 +1,102 @@
+package router
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// reloadState holds the route tree swapped in by Reload, shared by the copies of the root router
+type reloadState struct {
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+// Builder edits a copy of the router's route tree within Reload. It embeds the copy, so routes,
+// subrouters and middleware are added and removed as usual.
+type Builder struct {
+	*Router
+}
+
+// Reload edits the routes while the router is serving, ex. to add the endpoints of a plugin or of a
+// feature flag that was just turned on. fn is given a copy of the current route tree, which is
+// swapped in atomically once fn returns: requests in flight complete on the tree they started on,
+// and later ones are served by the new one. Reloads are serialized, each building on the previous
+// one. Once reloaded, routes must only be changed through Reload, as those registered on the router
+// directly are no longer served. Reload must be called on the root router.
+//
+//	rr.Reload(func(b *router.Builder) {
+//		b.Remove(http.MethodGet, "/beta")
+//		b.SubRouter("/plugins/billing").Get("/invoices", invoices)
+//	})
+func (r Router) Reload(fn func(b *Builder)) {
+	if r.reload == nil {
+		panic("router: Reload must be called on the root router")
+	}
+	r.reload.mu.Lock()
+	defer r.reload.mu.Unlock()
+
+	current := &r
+	if reloaded := r.reloaded(); reloaded != nil {
+		current = reloaded
+	}
+	next := current.clone()
+	next.reload = nil
+	fn(&Builder{Router: next})
+	r.reload.current.Store(next)
+}
+
+// reloaded is the route tree swapped in by Reload, nil if the router hasn't been reloaded
+func (r Router) reloaded() *Router {
+	if r.reload == nil {
+		return nil
+	}
+	next, _ := r.reload.current.Load().(*Router)
+	return next
+}
+
+// clone copies the router and its subrouters, so routes can be added and removed without affecting
+// the requests being served by the original. Endpoints are shared, as Builder doesn't change them.
+func (r *Router) clone() *Router {
+	c := *r
+	c.routes = make(map[Route]*Endpoint, len(r.routes))
+	for route, ep := range r.routes {
+		c.routes[route] = ep
+	}
+	c.endpoints = make(map[Route][]*Endpoint, len(r.endpoints))
+	for route, eps := range r.endpoints {
+		c.endpoints[route] = append([]*Endpoint(nil), eps...)
+	}
+	c.redirects = make(map[string]Redirect, len(r.redirects))
+	for from, redirect := range r.redirects {
+		c.redirects[from] = redirect
+	}
+	c.redirectPatterns = make(map[string]Redirect, len(r.redirectPatterns))
+	for from, redirect := range r.redirectPatterns {
+		c.redirectPatterns[from] = redirect
+	}
+	if r.errorRenderers != nil {
+		c.errorRenderers = make(map[string]ErrorRenderer, len(r.errorRenderers))
+		for mediaType, render := range r.errorRenderers {
+			c.errorRenderers[mediaType] = render
+		}
+	}
+
+	c.subRouters = make([]*Router, len(r.subRouters))
+	for i, sub := range r.subRouters {
+		c.subRouters[i] = sub.clone()
+	}
+	c.inactive = make([]*Router, len(r.inactive))
+	for i, shadow := range r.inactive {
+		c.inactive[i] = shadow.clone()
+	}
+
+	// the slices are copied so that appending to the clone's doesn't write to the original's array
+	c.values = append([]routeValue(nil), r.values...)
+	c.policies = append([]func(http.Handler) http.Handler(nil), r.policies...)
+	c.attached = append([]Policy(nil), r.attached...)
+	c.mw = append([]http.HandlerFunc(nil), r.mw...)
+	c.use = append([]func(http.Handler) http.Handler(nil), r.use...)
+	c.after = append([]http.HandlerFunc(nil), r.after...)
+	return &c
+}
@@ -0,0 +1,111 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	rr := New("/")
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/beta", func(w http.ResponseWriter, r *http.Request) {})
+	admin := rr.SubRouter("/admin")
+	admin.Get("/stats", func(w http.ResponseWriter, r *http.Request) {})
+
+	// the server holds its own copy of the router
+	var srv http.Handler = rr
+	status := func(path string) int {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	rr.Reload(func(b *Builder) {
+		if !b.Remove(http.MethodGet, "/beta") {
+			t.Error("should remove /beta")
+		}
+		if !b.Remove(http.MethodGet, "/admin/stats") {
+			t.Error("should remove /admin/stats")
+		}
+		if b.Remove(http.MethodPost, "/users") {
+			t.Error("should not remove missing routes")
+		}
+		b.Get("/reports", func(w http.ResponseWriter, r *http.Request) {}).Name("reports")
+		b.SubRouter("/plugins").Get("/billing", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/users", http.StatusOK},
+		{"/beta", http.StatusNotFound},
+		{"/admin/stats", http.StatusNotFound},
+		{"/reports", http.StatusOK},
+		{"/plugins/billing", http.StatusOK},
+	}
+	for _, test := range tests {
+		if code := status(test.path); code != test.status {
+			t.Errorf("%s: invalid status %d", test.path, code)
+		}
+	}
+	if url, err := rr.URLFor("reports"); err != nil || url != "/reports" {
+		t.Errorf("invalid url %q %v", url, err)
+	}
+	if n := len(rr.Routes()); n != 3 {
+		t.Errorf("invalid routes %d", n)
+	}
+
+	// the original tree is left as it was
+	if _, ok := admin.routes[Route{method: http.MethodGet, path: "/stats"}]; !ok {
+		t.Error("reload should not change the original router")
+	}
+
+	// reloads build on the previous one
+	rr.Reload(func(b *Builder) {
+		b.Remove(http.MethodGet, "/reports")
+	})
+	if code := status("/reports"); code != http.StatusNotFound {
+		t.Errorf("/reports: invalid status %d", code)
+	}
+	if code := status("/plugins/billing"); code != http.StatusOK {
+		t.Errorf("/plugins/billing: invalid status %d", code)
+	}
+}
+
+func TestReloadWhileServing(t *testing.T) {
+	rr := New("/")
+	rr.Get("/flag", func(w http.ResponseWriter, r *http.Request) {})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				req, _ := http.NewRequest("GET", "/flag", nil)
+				rr.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		rr.Reload(func(b *Builder) {
+			b.Remove(http.MethodGet, "/flag")
+			b.Get("/flag", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	}
+	wg.Wait()
+}
+
+func TestReloadSubRouter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("reloading a subrouter should panic")
+		}
+	}()
+	rr := New("/")
+	rr.SubRouter("/admin").Reload(func(b *Builder) {})
+}
@@ -0,0 +1,154 @@
+// Package render writes handler responses with the correct headers
+//
+//	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+//		user, err := users.Get(r.Context(), router.Param(r.Context(), "id"))
+//		if err != nil {
+//			router.Fail(r, err)
+//			return
+//		}
+//		render.JSON(w, http.StatusOK, user)
+//	})
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/chrisolsen/router"
+)
+
+// Dev pretty prints the JSON and XML responses, and reloads templates on every render
+var Dev = false
+
+// JSON encodes the value as the response. The value is encoded before anything is written, so an
+// encoding error can still be responded to, ex. with router.Fail.
+func JSON(w http.ResponseWriter, status int, v interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if Dev {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return write(w, status, "application/json; charset=utf-8", buf.Bytes())
+}
+
+// EncryptedJSON encrypts the value's `encrypt` tagged fields, in place, with the KMS bound by
+// router.UseKMS before encoding it
+func EncryptedJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	kms := router.RequestKMS(r.Context())
+	if kms == nil {
+		return errors.New("render: no KMS bound to the request")
+	}
+	if err := router.EncryptFields(r.Context(), kms, v); err != nil {
+		return err
+	}
+	return JSON(w, status, v)
+}
+
+// JSONArray streams a JSON array of the values emitted by each, flushing after every value, so
+// large result sets are never held in memory. each stops when emit returns an error, typically
+// because the client went away. Errors occurring after the first value has been written can only
+// be returned, as the status has already been sent.
+//
+//	render.JSONArray(w, http.StatusOK, func(emit func(interface{}) error) error {
+//		for rows.Next() {
+//			...
+//			if err := emit(row); err != nil {
+//				return err
+//			}
+//		}
+//		return rows.Err()
+//	})
+func JSONArray(w http.ResponseWriter, status int, each func(emit func(v interface{}) error) error) error {
+	flusher, _ := w.(http.Flusher)
+	started := false
+	start := func() error {
+		if started {
+			_, err := io.WriteString(w, ",\n")
+			return err
+		}
+		started = true
+		setHeaders(w, "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		_, err := io.WriteString(w, "[\n")
+		return err
+	}
+
+	err := each(func(v interface{}) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err := start(); err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !started {
+		return write(w, status, "application/json; charset=utf-8", []byte("[]\n"))
+	}
+	_, err = io.WriteString(w, "\n]\n")
+	return err
+}
+
+// XML encodes the value as the response, including the XML header
+func XML(w http.ResponseWriter, status int, v interface{}) error {
+	var b []byte
+	var err error
+	if Dev {
+		b, err = xml.MarshalIndent(v, "", "  ")
+	} else {
+		b, err = xml.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	return write(w, status, "application/xml; charset=utf-8", append([]byte(xml.Header), append(b, '\n')...))
+}
+
+// Text formats the response as plain text
+func Text(w http.ResponseWriter, status int, format string, args ...interface{}) error {
+	return write(w, status, "text/plain; charset=utf-8", []byte(fmt.Sprintf(format, args...)))
+}
+
+// NoContent responds with a 204 and no body
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Redirect redirects the request to the url, which may be relative to the request's path. A
+// status outside of the 3xx range is replaced by a 302.
+func Redirect(w http.ResponseWriter, r *http.Request, status int, url string) {
+	if status < 300 || status > 399 {
+		status = http.StatusFound
+	}
+	http.Redirect(w, r, url, status)
+}
+
+func setHeaders(w http.ResponseWriter, contentType string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+func write(w http.ResponseWriter, status int, contentType string, b []byte) error {
+	setHeaders(w, contentType)
+	w.WriteHeader(status)
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"encoding/xml"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+type testUser struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	ID      int      `json:"id" xml:"id"`
+	SSN     string   `json:"ssn,omitempty" xml:"-" encrypt:"true"`
+}
+
+func TestJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := JSON(rec, 201, testUser{ID: 1}); err != nil {
+		t.Error(err)
+	}
+	if rec.Code != 201 || rec.Header().Get("Content-Type") != "application/json; charset=utf-8" || rec.Body.String() != `{"id":1}`+"\n" {
+		t.Errorf("invalid response %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+
+	Dev = true
+	defer func() { Dev = false }()
+	rec = httptest.NewRecorder()
+	JSON(rec, 200, testUser{ID: 1})
+	if rec.Body.String() != "{\n  \"id\": 1\n}\n" {
+		t.Errorf("not pretty printed %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	if err := JSON(rec, 200, math.Inf(1)); err == nil {
+		t.Error("expected encoding error")
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Error("nothing should be written when encoding fails")
+	}
+}
+
+func TestEncryptedJSON(t *testing.T) {
+	kms, _ := router.NewAESKMS(make([]byte, 32))
+	rr := router.New("/")
+	rr.Before(router.UseKMS(kms))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		EncryptedJSON(w, r, 200, &testUser{ID: 1, SSN: "123"})
+	})
+
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != 200 || rec.Body.String() == `{"id":1,"ssn":"123"}`+"\n" {
+		t.Errorf("field not encrypted %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	if err := EncryptedJSON(rec, httptest.NewRequest("GET", "/", nil), 200, &testUser{}); err == nil {
+		t.Error("expected missing KMS error")
+	}
+}
+
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushRecorder) Flush() { f.flushes++ }
+
+func TestJSONArray(t *testing.T) {
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	err := JSONArray(rec, 200, func(emit func(interface{}) error) error {
+		for i := 1; i <= 3; i++ {
+			if err := emit(testUser{ID: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if rec.Body.String() != "[\n{\"id\":1},\n{\"id\":2},\n{\"id\":3}\n]\n" || rec.flushes != 3 {
+		t.Errorf("invalid stream %q %d", rec.Body.String(), rec.flushes)
+	}
+
+	rec = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	JSONArray(rec, 200, func(emit func(interface{}) error) error { return nil })
+	if rec.Body.String() != "[]\n" {
+		t.Errorf("invalid empty array %q", rec.Body.String())
+	}
+
+	errFailed := errors.New("failed")
+	rec = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	if err := JSONArray(rec, 200, func(emit func(interface{}) error) error { return errFailed }); err != errFailed {
+		t.Errorf("invalid error %v", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Error("nothing should be written before the first value")
+	}
+}
+
+func TestXML(t *testing.T) {
+	rec := httptest.NewRecorder()
+	XML(rec, 200, testUser{ID: 1})
+	if rec.Header().Get("Content-Type") != "application/xml; charset=utf-8" || rec.Body.String() != xml.Header+"<user><id>1</id></user>\n" {
+		t.Errorf("invalid response %v %q", rec.Header(), rec.Body.String())
+	}
+}
+
+func TestText(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Text(rec, 404, "no user %d", 5)
+	if rec.Code != 404 || rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" || rec.Body.String() != "no user 5" {
+		t.Errorf("invalid response %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+}
+
+func TestNoContentAndRedirect(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NoContent(rec)
+	if rec.Code != 204 || rec.Body.Len() != 0 {
+		t.Errorf("invalid no content response %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	Redirect(rec, httptest.NewRequest("POST", "/users", nil), http.StatusSeeOther, "/users/5")
+	if rec.Code != 303 || rec.Header().Get("Location") != "/users/5" {
+		t.Errorf("invalid redirect %d %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	Redirect(rec, httptest.NewRequest("GET", "/", nil), 200, "/login")
+	if rec.Code != 302 {
+		t.Errorf("invalid status should fall back on a 302: %d", rec.Code)
+	}
+}
+
+func TestEncryptedJSONByValue(t *testing.T) {
+	kms, _ := router.NewAESKMS(make([]byte, 32))
+	rr := router.New("/")
+	rr.Before(router.UseKMS(kms))
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := EncryptedJSON(w, r, 200, testUser{ID: 1, SSN: "123"}); err == nil {
+			t.Error("values passed by value should fail rather than render plaintext")
+		}
+	})
+	rr.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,127 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+)
+
+// TemplateOptions configures how templates are assembled
+type TemplateOptions struct {
+	// Layout is the name of the template that wraps every page, ex. `layouts/base`. Pages define
+	// the blocks, ex. `{{define "content"}}`, that the layout renders.
+	Layout string
+
+	// Partials is a glob of the templates shared by every page, ex. `partials/*`
+	Partials string
+
+	// Ext is the extension of the template files, `.html` by default
+	Ext string
+
+	Funcs template.FuncMap
+}
+
+// Templates renders html/template pages. Parsed pages are cached, unless Dev is set, in which case
+// they are parsed on every render so changes show up without a restart.
+type Templates struct {
+	fsys fs.FS
+	opts TemplateOptions
+
+	mu    sync.Mutex
+	cache map[string]*template.Template
+}
+
+// NewTemplates loads the templates from the file system, ex. an embed.FS
+func NewTemplates(fsys fs.FS, opts TemplateOptions) *Templates {
+	if opts.Ext == "" {
+		opts.Ext = ".html"
+	}
+	return &Templates{fsys: fsys, opts: opts, cache: make(map[string]*template.Template)}
+}
+
+// LoadTemplates loads the templates from the directory
+func LoadTemplates(dir string, opts TemplateOptions) *Templates {
+	return NewTemplates(os.DirFS(dir), opts)
+}
+
+// Render executes the page, named by its path without the extension, ex. `users/show`. The page
+// is executed before anything is written, so template errors can still be responded to.
+func (t *Templates) Render(w http.ResponseWriter, status int, name string, data interface{}) error {
+	tmpl, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+	entry := path.Base(name) + t.opts.Ext
+	if t.opts.Layout != "" {
+		entry = path.Base(t.opts.Layout) + t.opts.Ext
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, entry, data); err != nil {
+		return err
+	}
+	return write(w, status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// lookup returns the parsed page, from the cache outside of dev mode
+func (t *Templates) lookup(name string) (*template.Template, error) {
+	if Dev {
+		return t.parse(name)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tmpl, ok := t.cache[name]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := t.parse(name)
+	if err != nil {
+		return nil, err
+	}
+	t.cache[name] = tmpl
+	return tmpl, nil
+}
+
+// parse parses the layout, the partials and the page together
+func (t *Templates) parse(name string) (*template.Template, error) {
+	var patterns []string
+	if t.opts.Layout != "" {
+		patterns = append(patterns, t.opts.Layout+t.opts.Ext)
+	}
+	if t.opts.Partials != "" {
+		partials, err := fs.Glob(t.fsys, t.opts.Partials+t.opts.Ext)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, partials...)
+	}
+	patterns = append(patterns, name+t.opts.Ext)
+
+	tmpl, err := template.New(path.Base(name)).Funcs(t.opts.Funcs).ParseFS(t.fsys, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("render: template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+var defaultTemplates *Templates
+
+// UseTemplates sets the templates rendered by HTML
+func UseTemplates(t *Templates) {
+	defaultTemplates = t
+}
+
+// HTML renders the page of the templates set with UseTemplates
+//
+//	render.HTML(w, http.StatusOK, "users/show", user)
+func HTML(w http.ResponseWriter, status int, name string, data interface{}) error {
+	if defaultTemplates == nil {
+		return errors.New("render: no templates set, see UseTemplates")
+	}
+	return defaultTemplates.Render(w, status, name, data)
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testTemplateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`<title>{{template "title" .}}</title>{{template "nav"}}<main>{{template "content" .}}</main>`)},
+		"partials/nav.html": {Data: []byte(`{{define "nav"}}<nav>home</nav>{{end}}`)},
+		"users/show.html":   {Data: []byte(`{{define "title"}}{{.Name}}{{end}}{{define "content"}}<p>{{shout .Name}}</p>{{end}}`)},
+		"plain.html":        {Data: []byte(`<p>{{.}}</p>`)},
+		"broken.html":       {Data: []byte(`{{define "content"}}{{.Missing.Field}}{{end}}{{define "title"}}{{end}}`)},
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	tmpls := NewTemplates(testTemplateFS(), TemplateOptions{
+		Layout:   "layouts/base",
+		Partials: "partials/*",
+		Funcs:    template.FuncMap{"shout": strings.ToUpper},
+	})
+
+	rec := httptest.NewRecorder()
+	if err := tmpls.Render(rec, 200, "users/show", struct{ Name string }{"<bob>"}); err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `<title>&lt;bob&gt;</title><nav>home</nav><main><p>&lt;BOB&gt;</p></main>`
+	if rec.Header().Get("Content-Type") != "text/html; charset=utf-8" || rec.Body.String() != expected {
+		t.Errorf("invalid response %v %q", rec.Header(), rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	if err := tmpls.Render(rec, 200, "broken", 5); err == nil || rec.Body.Len() != 0 {
+		t.Errorf("execution error should be returned without writing: %v %q", err, rec.Body.String())
+	}
+	if err := tmpls.Render(httptest.NewRecorder(), 200, "missing", nil); err == nil {
+		t.Error("expected missing template error")
+	}
+}
+
+func TestTemplatesReload(t *testing.T) {
+	fsys := testTemplateFS()
+	tmpls := NewTemplates(fsys, TemplateOptions{})
+
+	render := func() string {
+		rec := httptest.NewRecorder()
+		if err := tmpls.Render(rec, 200, "plain", "hi"); err != nil {
+			t.Error(err)
+		}
+		return rec.Body.String()
+	}
+
+	render()
+	fsys["plain.html"] = &fstest.MapFile{Data: []byte(`<b>{{.}}</b>`)}
+	if body := render(); body != "<p>hi</p>" {
+		t.Errorf("templates should be cached: %s", body)
+	}
+
+	Dev = true
+	defer func() { Dev = false }()
+	if body := render(); body != "<b>hi</b>" {
+		t.Errorf("templates should be reloaded in dev mode: %s", body)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	defer UseTemplates(nil)
+	if err := HTML(httptest.NewRecorder(), 200, "plain", nil); err == nil {
+		t.Error("expected missing templates error")
+	}
+
+	UseTemplates(NewTemplates(testTemplateFS(), TemplateOptions{}))
+	rec := httptest.NewRecorder()
+	HTML(rec, 404, "plain", "missing")
+	if rec.Code != 404 || rec.Body.String() != "<p>missing</p>" {
+		t.Errorf("invalid response %d %q", rec.Code, rec.Body.String())
+	}
+}
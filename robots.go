@@ -0,0 +1,71 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NoIndex marks the route as private to crawlers, sending the `X-Robots-Tag: noindex` header with
+// its responses and disallowing it within the generated robots.txt
+func (e *Endpoint) NoIndex() *Endpoint {
+	e.noIndex = true
+	return e
+}
+
+// RobotsTxt serves a robots.txt file at the path, typically `/robots.txt`, that disallows all the
+// routes marked with NoIndex, including those within subrouters
+func (r *Router) RobotsTxt(path string) *Endpoint {
+	return r.Get(path, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(r.robotsTxt()))
+	})
+}
+
+func (r Router) robotsTxt() string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+
+	disallowed := r.disallowedPaths(make(map[string]bool))
+	sort.Strings(disallowed)
+	if len(disallowed) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	for _, path := range disallowed {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	return b.String()
+}
+
+func (r Router) disallowedPaths(seen map[string]bool) []string {
+	var paths []string
+	for route, ep := range r.routes {
+		if !ep.noIndex {
+			continue
+		}
+		path := robotsPath(r.fullPath(route.path))
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, sub := range r.subRouters {
+		paths = append(paths, sub.disallowedPaths(seen)...)
+	}
+	return paths
+}
+
+// robotsPath converts the route pattern into a robots.txt path, where url params become `*`
+// and wildcard routes disallow everything under their prefix
+func robotsPath(pattern string) string {
+	parts := slicePath(pattern)
+	for i, part := range parts {
+		switch {
+		case part == "*":
+			return "/" + strings.Join(parts[:i], "/") + "/"
+		case strings.HasPrefix(part, ":"):
+			parts[i] = "*"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
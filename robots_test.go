@@ -0,0 +1,59 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoIndex(t *testing.T) {
+	rr := New("/")
+	rr.Get("/public", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/private", func(w http.ResponseWriter, r *http.Request) {}).NoIndex()
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/public", ""},
+		{"/private", "noindex"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if tag := rec.Header().Get("X-Robots-Tag"); tag != test.expected {
+			t.Errorf("%s: invalid X-Robots-Tag %q != %q", test.path, tag, test.expected)
+		}
+	}
+}
+
+func TestRobotsTxt(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+
+	rr := New("/")
+	rr.RobotsTxt("/robots.txt")
+	rr.Get("/", fn)
+	rr.Get("/users/:id/edit", fn).NoIndex()
+	rr.Post("/users/:id/edit", fn).NoIndex()
+	admin := rr.SubRouter("/admin")
+	admin.Get("/*", fn).NoIndex()
+	admin.Get("/reports", fn).NoIndex()
+
+	req, _ := http.NewRequest("GET", "/robots.txt", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	expected := "User-agent: *\nDisallow: /admin/\nDisallow: /admin/reports\nDisallow: /users/*/edit\n"
+	if rec.Body.String() != expected {
+		t.Errorf("invalid robots.txt\n%s\n!=\n%s", rec.Body.String(), expected)
+	}
+}
+
+func TestRobotsTxtEmpty(t *testing.T) {
+	rr := New("/")
+	if txt := rr.robotsTxt(); txt != "User-agent: *\nDisallow:\n" {
+		t.Errorf("invalid robots.txt %q", txt)
+	}
+}
@@ -4,15 +4,38 @@ import (
 	"context"
 	"net/http"
 	"strings"
-)
+	"sync/atomic"
 
-type ctxKey string
+	"github.com/chrisolsen/router/internal/wrap"
+)
 
-var paramsCtxKey = ctxKey("params")
+var (
+	paramsCtxKey   = NewKey[paramSource]("params")
+	patternCtxKey  = NewKey[string]("pattern")
+	notFoundCtxKey = NewKey[http.HandlerFunc]("notfound")
+)
 
-type ops struct {
+// Endpoint is the handler registered for a route, allowing for additional route options to be set
+type Endpoint struct {
 	fn      http.HandlerFunc
 	handler http.Handler
+
+	noIndex         bool
+	stub            bool
+	streaming       bool
+	doc             *RouteDoc
+	sitemapPriority float64
+	examples        map[string]string
+	accept          []string
+	preloads        []preload
+	earlyHints      []string
+	query           []queryConstraint
+	name            string
+	pattern         string
+	formats         []string
+	env             string
+	policies        []func(http.Handler) http.Handler
+	attached        []Policy
 }
 
 // Route is a route
@@ -27,13 +50,25 @@ func New(path string) Router {
 		path = "/"
 	}
 	return Router{
-		basePath: path,
-		routes:   make(map[Route]*ops),
+		basePath:         path,
+		routes:           make(map[Route]*Endpoint),
+		endpoints:        make(map[Route][]*Endpoint),
+		redirects:        make(map[string]Redirect),
+		redirectPatterns: make(map[string]Redirect),
+		configs:          newConfigs(),
+		hooks:            &hooks{},
+		health:           newHealth(),
+		drain:            &drainState{},
+		maintenance:      &maintenance{},
+		reload:           &reloadState{},
 	}
 }
 
 // BindContext links the new context with the request to allow for any context values
-// to be available later in the chain
+// to be available later in the Before middleware chain. It replaces the request in place, so it
+// mustn't be used while other goroutines read the request. The router passes the chain its own copy
+// of the request, leaving the caller's untouched. Middleware registered with Use instead passes the
+// request derived with WithContext on to the next handler.
 func BindContext(c context.Context, r *http.Request) {
 	*r = *r.WithContext(c)
 }
@@ -46,29 +81,87 @@ func HaltRequest(r *http.Request) {
 	cancel()
 }
 
-// Params retrieves the url parameters matched
+// Params retrieves a copy of the url parameters matched, empty if there are none
 func Params(c context.Context) map[string]string {
-	switch c.Value(paramsCtxKey).(type) {
-	case map[string]string:
-		return c.Value(paramsCtxKey).(map[string]string)
-	default:
-		return map[string]string{}
+	if params, ok := Get(c, paramsCtxKey); ok {
+		if m := params.toMap(); m != nil {
+			return m
+		}
 	}
+	return map[string]string{}
 }
 
-// Param gets the names url param
+// Param gets the named url param without copying the params. The params are reused once the
+// router's handling of the request completes, so handlers that outlive the request, ex. by
+// starting a goroutine, should copy the values they need beforehand.
 func Param(c context.Context, key string) string {
-	return Params(c)[key]
+	if params, ok := Get(c, paramsCtxKey); ok {
+		return params.get(key)
+	}
+	return ""
+}
+
+// WithParams returns a copy of the context containing the url params, allowing handlers to be
+// unit tested without going through the router
+func WithParams(c context.Context, params map[string]string) context.Context {
+	return Set[paramSource](c, paramsCtxKey, paramMap(params))
+}
+
+// RoutePattern retrieves the pattern of the matched route, ex. `/users/:id`, including from the
+// context of a request passed through Observe once it's served
+func RoutePattern(c context.Context) string {
+	if pattern, ok := Get(c, patternCtxKey); ok {
+		return pattern
+	}
+	if state, ok := Get(c, stateCtxKey); ok {
+		_, pattern := state.request()
+		return pattern
+	}
+	return ""
+}
+
+// WithRoutePattern returns a copy of the context containing the matched route pattern
+func WithRoutePattern(c context.Context, pattern string) context.Context {
+	return Set(c, patternCtxKey, pattern)
 }
 
 // Router is a custom mux that allows for url parameter to be extracted from the path
 type Router struct {
-	basePath        string
-	routes          map[Route]*ops
-	subRouters      []*Router
-	notFoundHandler http.HandlerFunc
-
-	mw []http.HandlerFunc
+	basePath             string
+	routes               map[Route]*Endpoint
+	endpoints            map[Route][]*Endpoint
+	redirects            map[string]Redirect
+	redirectPatterns     map[string]Redirect
+	subRouters           []*Router
+	notFoundHandler      http.HandlerFunc
+	internalErrorHandler http.HandlerFunc
+	slashPolicy          TrailingSlashPolicy
+	casePolicy           CasePolicy
+	errorRenderers       map[string]ErrorRenderer
+	stats                *Stats
+	legacy               *Legacy
+	locales              *LocaleOptions
+	version              string
+	versioning           VersionOptions
+	deprecation          *Deprecation
+	env                  string
+	inactive             []*Router
+	disableAutoHead      bool
+	earlyHints           bool
+	values               []routeValue
+	policies             []func(http.Handler) http.Handler
+	attached             []Policy
+	configs              *configs
+	hooks                *hooks
+	health               *health
+	drain                *drainState
+	maintenance          *maintenance
+	reload               *reloadState
+	isolated             bool
+
+	mw    []http.HandlerFunc
+	use   []func(http.Handler) http.Handler
+	after []http.HandlerFunc
 }
 
 // Before injects the passed in handler functions into the handler chain
@@ -76,190 +169,552 @@ func (r *Router) Before(fns ...http.HandlerFunc) {
 	r.mw = append(r.mw, fns...)
 }
 
-// Run executes the handler chain, followed by the final http handler passed in
+// Use wraps the handlers of the router's routes with next-style middleware, which passes the
+// request derived with its context on to the next handler rather than binding it with BindContext.
+// It runs after the Before middleware and is inherited by subrouters in the same way, the first
+// being the outermost.
+//
+//	rr.Use(func(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey, user(r))))
+//		})
+//	})
+func (r *Router) Use(mw ...func(http.Handler) http.Handler) {
+	r.use = append(r.use, mw...)
+}
+
+// After injects the passed in handler functions to run once the handler chain is done, including
+// when it's halted by the Before middleware, ex. to log the request or release its resources.
+// They aren't run when the handler panics.
+func (r *Router) After(fns ...http.HandlerFunc) {
+	r.after = append(r.after, fns...)
+}
+
+// Run executes the handler chain, followed by the final http handler passed in and the After
+// handler functions
 func (r Router) run(last http.HandlerFunc) http.HandlerFunc {
+	return runChain(r.mw, r.after, last)
+}
+
+// runChain executes the Before middleware and, unless it's halted, the final handler, followed by
+// the After middleware
+func runChain(before, after []http.HandlerFunc, last http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		for _, fn := range r.mw {
+		halted := false
+		for _, fn := range before {
 			fn(w, req)
 			if req.Context().Err() != nil {
-				return
+				halted = true
+				break
 			}
 		}
-		last(w, req)
+		if !halted {
+			last(w, req)
+		}
+		for _, fn := range after {
+			fn(w, req)
+		}
+	}
+}
+
+// middlewareOf returns the middleware run by the router's routes, inherited from the routers
+// leading to it up to the closest isolated one. Parents' Before and Use middleware runs first and
+// their After middleware last.
+func (r Router) middlewareOf(rr *Router) (before []http.HandlerFunc, use []func(http.Handler) http.Handler, after []http.HandlerFunc) {
+	routers := append([]*Router{&r}, r.routerPath(rr)...)
+	if len(routers) == 1 {
+		return r.mw, r.use, r.after
+	}
+	start := 0
+	for i, router := range routers {
+		if router.isolated {
+			start = i
+		}
 	}
+	for i := start; i < len(routers); i++ {
+		before = append(before, routers[i].mw...)
+		use = append(use, routers[i].use...)
+	}
+	for i := len(routers) - 1; i >= start; i-- {
+		after = append(after, routers[i].after...)
+	}
+	return before, use, after
 }
 
 func (r Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	method := getMethod(req)
-	rr := r.findMatchingRouter(req.URL.Path)
-	for route, ops := range rr.routes {
-		path := strings.Replace(req.URL.Path, rr.basePath, "", 1)
-		if ok, params := matches(rr, route, method, path, ops.handler != nil); ok {
-			var handler http.HandlerFunc
-			if ops.fn != nil {
-				handler = ops.fn
-			} else if ops.handler != nil {
-				handler = ops.handler.ServeHTTP
-			}
+	if reloaded := r.reloaded(); reloaded != nil {
+		reloaded.ServeHTTP(w, req)
+		return
+	}
+
+	// values are bound to copies of the request, never the caller's, with the outcome shared
+	// through the state of requests passed through Observe
+	state, ok := Get(req.Context(), stateCtxKey)
+	if !ok {
+		req = Observe(req)
+		state, _ = Get(req.Context(), stateCtxKey)
+	}
+	state.setRequest(req, "")
+	defer r.recover(w, state)
 
-			rr.Before(setURLParams(req, params))
-			rr.run(handler)(w, req)
+	if r.refuseDraining(w, req) || r.refuseMaintenance(w, req) {
+		return
+	}
+	atomic.AddInt64(&r.drain.inFlight, 1)
+	defer atomic.AddInt64(&r.drain.inFlight, -1)
 
+	if r.legacy != nil {
+		var redirected bool
+		if req, redirected = r.legacy.translate(w, req); redirected {
 			return
 		}
+		state.setRequest(req, "")
+	}
+	if r.locales != nil {
+		req = r.routeLocale(req)
+		state.setRequest(req, "")
+	}
+	method := req.Method
+	rr := r.findMatchingRouter(req.URL.Path)
+	if rr.hasVersions() {
+		req = r.selectVersion(rr, w.Header(), req)
+		state.setRequest(req, "")
+		rr = r.findMatchingRouter(req.URL.Path)
+	}
+	path := strings.Replace(req.URL.Path, rr.basePath, "", 1)
+	if redirect, ok := rr.findRedirect(path); ok {
+		http.Redirect(w, req, redirectLocation(localizePath(req, redirect.To), req), redirect.Status)
+		return
+	}
+	served, slashMismatch, queryMismatch := r.dispatch(rr, method, path, w, req)
+	if !served && method == http.MethodHead && !r.disableAutoHead {
+		hw := &headWriter{ResponseWriter: w}
+		if served, slashMismatch, queryMismatch = r.dispatch(rr, http.MethodGet, path, wrap.Writer(hw, w), req); served {
+			hw.finish()
+		}
+	}
+	if served {
+		return
+	}
+	if slashMismatch && r.slashPolicy == RedirectTrailingSlash {
+		redirectSlash(w, req)
+		return
+	}
+	if r.casePolicy != CaseSensitive && r.serveFolded(method, w, req) {
+		return
+	}
+	if queryMismatch {
+		r.renderError(rr, w, req, http.StatusBadRequest, nil)
+		return
+	}
+	r.notFound(rr, w, req)
+}
+
+// dispatch serves the first route matching the method and path, reporting whether a route was
+// only rejected because of its trailing slash or its query constraints
+func (r Router) dispatch(rr *Router, method, path string, w http.ResponseWriter, req *http.Request) (served, slashMismatch, queryMismatch bool) {
+	params := acquireParams()
+	for route, ep := range rr.routes {
+		reason, format := r.matchEndpoint(rr, route, ep, method, path, req, params)
+		if reason != "" {
+			slashMismatch = slashMismatch || reason == rejectSlash
+			queryMismatch = queryMismatch || reason == rejectQuery
+			continue
+		}
+		if format != "" {
+			req = req.WithContext(Set(req.Context(), formatCtxKey, format))
+		}
+		r.serve(rr, route, params, w, req)
+		return true, false, false
+	}
+	params.release()
+	return false, slashMismatch, queryMismatch
+}
+
+// matchEndpoint checks the route against the request, as dispatched and explained, returning why it
+// was rejected, empty if it matches, and the format suffix it was requested with
+func (r Router) matchEndpoint(rr *Router, route Route, ep *Endpoint, method, path string, req *http.Request, params *routeParams) (reason, format string) {
+	matchPath := path
+	if len(ep.formats) > 0 {
+		matchPath, format = splitFormat(path, ep.formats)
+	}
+	if reason := matchRoute(rr, route, method, matchPath, ep.handler != nil, false, params); reason != "" {
+		return reason, ""
+	}
+	if r.slashPolicy != IgnoreTrailingSlash && !slashMatches(rr.fullPath(route.path), req.URL.Path) {
+		return rejectSlash, ""
 	}
-	w.WriteHeader(http.StatusNotFound)
-	if r.notFoundHandler != nil {
-		r.notFoundHandler(w, req)
+	if len(rr.queryEndpoints(route, req)) == 0 {
+		return rejectQuery, ""
 	}
+	return "", format
+}
+
+// serve runs the matched endpoint through the matched router's middleware chain, releasing the
+// params once the request completes. They're left to the garbage collector if the handler panics,
+// as the error handler may still read them.
+func (r Router) serve(rr *Router, route Route, params *routeParams, w http.ResponseWriter, req *http.Request) {
+	ep, ok := rr.negotiateEndpoint(route, w, req)
+	if !ok {
+		params.release()
+		r.renderError(rr, w, req, http.StatusNotAcceptable, nil)
+		return
+	}
+
+	var handler http.HandlerFunc
+	if ep.fn != nil {
+		handler = ep.fn
+	} else if ep.handler != nil {
+		handler = ep.handler.ServeHTTP
+	}
+	if ep.noIndex {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+	if len(ep.preloads) > 0 || len(ep.earlyHints) > 0 {
+		r.sendHints(w, req, ep)
+	}
+	var sw *statusWriter
+	if r.stats != nil {
+		sw = &statusWriter{StatusWriter: wrap.StatusWriter{ResponseWriter: w}}
+		defer r.stats.record(route.method, rr.fullPath(route.path), sw)
+		w = wrap.Writer(sw, w)
+	}
+
+	// the pattern is kept out of the pooled params, as it's read by middleware wrapping the router
+	c := WithRoutePattern(Set[paramSource](req.Context(), paramsCtxKey, params), rr.fullPath(route.path))
+	c = Set(c, notFoundCtxKey, func(w http.ResponseWriter, req *http.Request) {
+		r.notFound(rr, w, req)
+	})
+	if ep.streaming {
+		c = Set(c, streamingCtxKey, true)
+	}
+	c = r.bindValues(rr, c)
+	c = r.bindVersion(rr, w, c)
+	req = req.WithContext(c)
+	if state, ok := Get(c, stateCtxKey); ok {
+		state.setRequest(req, rr.fullPath(route.path))
+	}
+	r.requestMatched(rr, route, ep, req)
+
+	h := r.applyPolicies(rr, ep, handler)
+	before, use, after := r.middlewareOf(rr)
+	for i := len(use) - 1; i >= 0; i-- {
+		h = use[i](h)
+	}
+	runChain(before, after, h.ServeHTTP)(w, req)
+	if RequestError(req.Context()) != nil {
+		r.internalError(w, req)
+	}
+	if sw != nil {
+		sw.completed = true
+	}
+	if state, ok := Get(req.Context(), stateCtxKey); ok {
+		// the observed request outlives the pooled params, so it's left with a copy of them
+		state.setRequest(req.WithContext(Set[paramSource](req.Context(), paramsCtxKey, paramMap(params.toMap()))), "")
+	}
+	params.release()
 }
 
 // HandleFunc allows the handler to be called when the path matches the request's url path
-func (r Router) HandleFunc(method, path string, fn http.HandlerFunc) {
-	r.bindRoute(method, path, &ops{fn: fn})
+func (r Router) HandleFunc(method, path string, fn http.HandlerFunc) *Endpoint {
+	return r.bindRoute(method, path, &Endpoint{fn: fn})
 }
 
-// SubRouter creates a child router with a custom base path
+// SubRouter creates a child router with a custom base path. Its routes run the Before and After
+// middleware of its parents along with its own.
 func (r *Router) SubRouter(path string) *Router {
 	var basePath string
 	if r.basePath != "/" {
 		basePath = r.basePath
 	}
 	sub := Router{
-		basePath: basePath + path,
-		routes:   make(map[Route]*ops),
+		basePath:         basePath + path,
+		routes:           make(map[Route]*Endpoint),
+		endpoints:        make(map[Route][]*Endpoint),
+		redirects:        make(map[string]Redirect),
+		redirectPatterns: make(map[string]Redirect),
+		env:              r.env,
+		configs:          r.configs,
+		hooks:            r.hooks,
+		health:           r.health,
+		drain:            r.drain,
+		maintenance:      r.maintenance,
 	}
 	r.subRouters = append(r.subRouters, &sub)
 	return &sub
 }
 
-func (r Router) bindRoute(method, path string, p *ops) {
-	r.routes[Route{method: method, path: path}] = p
+// SubRouterIsolated creates a child router that doesn't inherit the Before and After middleware of
+// its parents, ex. for webhooks that mustn't run the session middleware of the site. Policies are
+// still inherited.
+func (r *Router) SubRouterIsolated(path string) *Router {
+	sub := r.SubRouter(path)
+	sub.isolated = true
+	return sub
+}
+
+// bindRoute registers the endpoint, replacing the route's previous endpoint unless the endpoints
+// are set to be negotiated with Accept or selected with Query. It panics if the pattern is invalid.
+func (r Router) bindRoute(method, path string, ep *Endpoint) *Endpoint {
+	if err := ValidatePattern(path); err != nil {
+		panic(err)
+	}
+	route := Route{method: method, path: path}
+	ep.pattern = r.fullPath(path)
+	ep.env = r.env
+	r.routes[route] = ep
+	r.endpoints[route] = append(r.endpoints[route], ep)
+	r.routeRegistered(route, ep)
+	return ep
 }
 
 // Get handles GET requests
-func (r Router) Get(path string, fn http.HandlerFunc) {
-	r.bindRoute(http.MethodGet, path, &ops{fn: fn})
+func (r Router) Get(path string, fn http.HandlerFunc) *Endpoint {
+	return r.bindRoute(http.MethodGet, path, &Endpoint{fn: fn})
 }
 
 // Post handles POST requests
-func (r Router) Post(path string, fn http.HandlerFunc) {
-	r.bindRoute(http.MethodPost, path, &ops{fn: fn})
+func (r Router) Post(path string, fn http.HandlerFunc) *Endpoint {
+	return r.bindRoute(http.MethodPost, path, &Endpoint{fn: fn})
 }
 
 // Put handles PUT requests
-func (r Router) Put(path string, fn http.HandlerFunc) {
-	r.bindRoute(http.MethodPut, path, &ops{fn: fn})
+func (r Router) Put(path string, fn http.HandlerFunc) *Endpoint {
+	return r.bindRoute(http.MethodPut, path, &Endpoint{fn: fn})
 }
 
 // Delete handles DELETE requests
-func (r Router) Delete(path string, fn http.HandlerFunc) {
-	r.bindRoute(http.MethodDelete, path, &ops{fn: fn})
+func (r Router) Delete(path string, fn http.HandlerFunc) *Endpoint {
+	return r.bindRoute(http.MethodDelete, path, &Endpoint{fn: fn})
 }
 
 // Patch handles PATCH requests
-func (r Router) Patch(path string, fn http.HandlerFunc) {
-	r.bindRoute(http.MethodPatch, path, &ops{fn: fn})
+func (r Router) Patch(path string, fn http.HandlerFunc) *Endpoint {
+	return r.bindRoute(http.MethodPatch, path, &Endpoint{fn: fn})
+}
+
+// standardMethods are the methods registered by Any
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// Any handles requests of all the standard methods
+func (r Router) Any(path string, fn http.HandlerFunc) *Endpoint {
+	return r.Match(standardMethods, path, fn)
+}
+
+// Match handles requests of any of the methods. The methods share the returned endpoint, so
+// route options apply to all of them.
+func (r Router) Match(methods []string, path string, fn http.HandlerFunc) *Endpoint {
+	ep := &Endpoint{fn: fn}
+	for _, method := range methods {
+		r.bindRoute(strings.ToUpper(method), path, ep)
+	}
+	return ep
 }
 
-// Handle foo
-func (r Router) Handle(path string, h http.Handler) {
-	r.routes[Route{path: path}] = &ops{handler: h}
+// Handle allows the handler to be called for all methods when the path matches the request's url path
+func (r Router) Handle(path string, h http.Handler) *Endpoint {
+	return r.bindRoute("", path, &Endpoint{handler: h})
 }
 
-// NotFound allows for a custom 404 handler to be set
+// NotFound allows for a custom 404 handler to be set. The handler owns the response, so it sets
+// its own status, ex. redirecting legacy urls with a 301. Subrouters without a handler of their own
+// inherit that of their closest parent.
 func (r *Router) NotFound(h http.HandlerFunc) {
 	r.notFoundHandler = h
 }
 
+// NotFoundFromHandler responds with the router's 404 for handlers that only find out that the
+// resource is missing once they run, ex. a file or slug lookup. It must be called before anything
+// is written to the response.
+func NotFoundFromHandler(w http.ResponseWriter, r *http.Request) {
+	fn, ok := Get(r.Context(), notFoundCtxKey)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	fn(w, r)
+}
+
+// notFound runs the custom 404 handler of the matched router, or of its closest parent with one,
+// falling back on the default error renderers
+func (r Router) notFound(rr *Router, w http.ResponseWriter, req *http.Request) {
+	if h := r.notFoundHandlerOf(rr); h != nil {
+		h(w, req)
+		return
+	}
+	r.renderError(rr, w, req, http.StatusNotFound, nil)
+}
+
+// notFoundHandlerOf finds the 404 handler inherited by the router
+func (r Router) notFoundHandlerOf(rr *Router) http.HandlerFunc {
+	if rr != nil {
+		routers := r.routerPath(rr)
+		for i := len(routers) - 1; i >= 0; i-- {
+			if routers[i].notFoundHandler != nil {
+				return routers[i].notFoundHandler
+			}
+		}
+	}
+	return r.notFoundHandler
+}
+
 // Finds the matching router
 func (r Router) findMatchingRouter(urlPath string) *Router {
+	return r.findRouter(urlPath, false)
+}
+
+// findRouter finds the matching router, optionally ignoring the case of the base paths
+func (r Router) findRouter(urlPath string, fold bool) *Router {
 	for _, child := range r.subRouters {
-		if r := child.findMatchingRouter(urlPath); r != nil {
+		if r := child.findRouter(urlPath, fold); r != nil {
 			return r
 		}
 	}
 	if strings.Index(urlPath, r.basePath) == 0 {
 		return &r
 	}
+	if fold && len(urlPath) >= len(r.basePath) && strings.EqualFold(urlPath[:len(r.basePath)], r.basePath) {
+		return &r
+	}
 	return nil
 }
 
-// method returns either the request's overridden method value if it exists or the original method value
-func getMethod(r *http.Request) string {
-	switch r.Header.Get("Content-Type") {
-	case "multipart/form-data":
-		r.ParseMultipartForm(10 << 20)
-	case "text/html":
-		r.ParseForm()
-	default:
-		return r.Method
+// fullPath returns the path prefixed with the router's base path
+func (r Router) fullPath(path string) string {
+	if r.basePath == "/" {
+		return "/" + strings.TrimLeft(path, "/")
+	}
+	if strings.HasPrefix(path, r.basePath) {
+		return path
 	}
-	if method := r.FormValue("_method"); method != "" {
-		return strings.ToUpper(method)
+	if strings.Trim(path, "/") == "" {
+		return r.basePath
 	}
-	return r.Method
+	return r.basePath + "/" + strings.TrimLeft(path, "/")
 }
 
+// reasons a route is rejected when matching, reported by Explain
+const (
+	rejectMethod       = "method does not match"
+	rejectPath         = "path does not match"
+	rejectSegmentCount = "segment count does not match"
+	rejectSegment      = "static segment does not match"
+	rejectWildcard     = "too few segments for wildcard"
+	rejectSlash        = "trailing slash does not match"
+	rejectQuery        = "query constraints not met"
+)
+
 func matches(router *Router, route Route, method, path string, ignoreMethod bool) (bool, map[string]string) {
-	routePath := strings.Replace(route.path, router.basePath, "", 1)
-	if strings.Index(routePath, "/") != 0 {
-		routePath = "/" + routePath
+	params := &routeParams{}
+	reason := matchRoute(router, route, method, path, ignoreMethod, false, params)
+	return reason == "", params.toMap()
+}
+
+// matchRoute returns the reason the route was rejected, or empty if it matches, setting the url
+// params of the matching route. When fold is set the static parts of the route are compared
+// without case. The segments are compared in place, as splitting the paths would allocate.
+func matchRoute(router *Router, route Route, method, path string, ignoreMethod, fold bool, params *routeParams) string {
+	params.reset()
+	routePath := route.path
+	if strings.HasPrefix(routePath, router.basePath) {
+		routePath = routePath[len(router.basePath):]
+	} else {
+		routePath = strings.Replace(routePath, router.basePath, "", 1)
 	}
 
 	if !ignoreMethod && route.method != method {
-		return false, nil
+		return rejectMethod
 	}
-	wildcard := strings.Contains(routePath, "*")
-	if !wildcard && !strings.Contains(routePath, ":") {
-		return strings.Trim(routePath, "/") == strings.Trim(path, "/"), nil
+	pattern := strings.Trim(routePath, "/")
+	trimmed := strings.Trim(path, "/")
+	wildcard := strings.Contains(pattern, "*")
+	if !wildcard && !strings.Contains(pattern, ":") {
+		if !equalPath(pattern, trimmed, fold) {
+			return rejectPath
+		}
+		return ""
 	}
 
-	pathParts, patternParts := slicePath(path), slicePath(routePath)
-
+	var wildcardParam string
+	segments := strings.Count(pattern, "/") + 1
 	if wildcard {
-		if len(pathParts) < len(patternParts) {
-			return false, nil
+		// the remainder is sliced directly out of the path, and only the segments before the
+		// wildcard need to match
+		segments--
+		offset := segmentOffset(trimmed, segments)
+		if offset < 0 {
+			return rejectWildcard
 		}
-		return true, map[string]string{
-			"*": strings.Join(pathParts[len(patternParts)-1:], "/"),
+		wildcardParam = trimmed[offset:]
+		trimmed = trimmed[:offset]
+		if i := strings.LastIndexByte(pattern, '/'); i >= 0 {
+			pattern = pattern[:i]
+		} else {
+			pattern = ""
 		}
+	} else if strings.Count(trimmed, "/")+1 != segments {
+		return rejectSegmentCount
 	}
 
-	patternPartCount, pathPartCount := len(patternParts), len(pathParts)
-	if pathPartCount != patternPartCount {
-		return false, nil
-	}
-
-	// check parts
-	for i := 0; i < patternPartCount; i++ {
-		pathPart, patternPart := pathParts[i], patternParts[i]
-		if patternPart[0] == ':' {
+	// check parts, extracting the params
+	for i := 0; i < segments; i++ {
+		var patternPart, pathPart string
+		patternPart, pattern = nextSegment(pattern)
+		pathPart, trimmed = nextSegment(trimmed)
+		if len(patternPart) > 0 && patternPart[0] == ':' {
+			params.add(patternPart[1:], pathPart)
 			continue
 		}
-		if pathPart != patternPart {
-			return false, nil
+		if !equalPath(pathPart, patternPart, fold) {
+			params.reset()
+			return rejectSegment
 		}
 	}
+	if wildcard {
+		params.add("*", wildcardParam)
+	}
+	return ""
+}
 
-	// extract pattern params
-	params := make(map[string]string)
-	for i, part := range patternParts {
-		if part[0] == ':' {
-			params[part[1:]] = pathParts[i]
-		}
+// nextSegment splits the first segment from the rest of the path
+func nextSegment(path string) (segment, rest string) {
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		return path[:i], path[i+1:]
 	}
+	return path, ""
+}
 
-	return true, params
+func equalPath(a, b string, fold bool) bool {
+	if fold {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
 }
 
 func slicePath(path string) []string {
 	return strings.Split(strings.Trim(path, "/"), "/")
 }
 
-func setURLParams(r *http.Request, params map[string]string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		c := context.WithValue(r.Context(), paramsCtxKey, params)
-		*r = *r.WithContext(c)
+// segmentOffset returns the index within the trimmed path at which the nth (zero based) segment
+// starts, or -1 if the path doesn't contain that many segments
+func segmentOffset(trimmed string, n int) int {
+	offset := 0
+	for i := 0; i < n; i++ {
+		next := strings.IndexByte(trimmed[offset:], '/')
+		if next < 0 {
+			return -1
+		}
+		offset += next + 1
 	}
+	return offset
 }
@@ -4,7 +4,6 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"sync"
 	"testing"
 	"time"
@@ -59,6 +58,7 @@ func TestRouteMatching(t *testing.T) {
 			{&rootRouter, "GET", "/users/a/b/c", true, false},
 			{&rootRouter, "POST", "/users/a", false, false},
 			{&rootRouter, "POST", "/users/a", true, true},
+			{&rootRouter, "GET", "/projects/a", false, false},
 		},
 		{method: "GET", path: "/foo/users"}: {
 			{subRouter, "GET", "/users", true, false},
@@ -260,7 +260,7 @@ func TestServeHTTPHandlersWithSubroutes(t *testing.T) {
 }
 
 func TestMiddleware(t *testing.T) {
-	testKey := ctxKey("test")
+	testKey := NewKey[string]("test")
 	ch := make(chan bool)
 
 	tests := []struct {
@@ -356,9 +356,10 @@ func TestNotFound(t *testing.T) {
 			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(200)
 			},
-			calledMethod:   "GET",
-			calledPath:     "/invalid_path",
-			expectedStatus: 404,
+			calledMethod:     "GET",
+			calledPath:       "/invalid_path",
+			expectedStatus:   404,
+			expectedResponse: "Not Found\n",
 		},
 		{
 			// validate the method matches
@@ -367,9 +368,10 @@ func TestNotFound(t *testing.T) {
 			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(200)
 			},
-			calledMethod:   "POST",
-			calledPath:     "/",
-			expectedStatus: 404,
+			calledMethod:     "POST",
+			calledPath:       "/",
+			expectedStatus:   404,
+			expectedResponse: "Not Found\n",
 		},
 		{
 			// validate the custom 404 handler is run
@@ -380,6 +382,7 @@ func TestNotFound(t *testing.T) {
 			expectedStatus:   404,
 			expectedResponse: "not found yo",
 			notFoundHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(404)
 				w.Write([]byte("not found yo"))
 			},
 		},
@@ -483,6 +486,38 @@ func TestUrlParamsAreExtractedIntoContext(t *testing.T) {
 	}
 }
 
+func TestRoutePatternInContext(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected string
+	}{
+		{"/users/:id", "/users/123", "/users/:id"},
+		{"/files/*", "/files/a/b", "/files/*"},
+		{"/reports/:year", "/admin/reports/2020", "/admin/reports/:year"},
+	}
+
+	for _, test := range tests {
+		var pattern string
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			pattern = RoutePattern(r.Context())
+		}
+		rr := New("/")
+		admin := rr.SubRouter("/admin")
+		if test.expected != test.pattern {
+			admin.Get(test.pattern, fn)
+		} else {
+			rr.Get(test.pattern, fn)
+		}
+
+		req, _ := http.NewRequest("GET", test.path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), req)
+		if pattern != test.expected {
+			t.Errorf("%s: invalid pattern %q != %q", test.path, pattern, test.expected)
+		}
+	}
+}
+
 // validate method helper functions (Get, Post, etc)
 func TestGetHelper(t *testing.T) {
 	rr := New("/")
@@ -573,6 +608,37 @@ func TestHandlerFuncHelper(t *testing.T) {
 	}
 }
 
+func TestAnyHelper(t *testing.T) {
+	rr := New("/")
+	ep := rr.Any("/foo", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, method := range standardMethods {
+		route := rr.routes[Route{method: method, path: "/foo"}]
+		if route == nil {
+			t.Errorf("no %s route found", method)
+			continue
+		}
+		if route != ep {
+			t.Errorf("%s route doesn't share the endpoint", method)
+		}
+	}
+}
+
+func TestMatchHelper(t *testing.T) {
+	rr := New("/")
+	rr.Match([]string{"get", "POST"}, "/foo", func(w http.ResponseWriter, r *http.Request) {})
+
+	if len(rr.routes) != 2 {
+		t.Errorf("invalid route count %d", len(rr.routes))
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		route := rr.routes[Route{method: method, path: "/foo"}]
+		if route == nil || route.fn == nil {
+			t.Errorf("no %s route found", method)
+		}
+	}
+}
+
 func TestHandle(t *testing.T) {
 	rr := New("/")
 	rr.Handle("/foo", testHandler{})
@@ -590,13 +656,11 @@ func TestHandle(t *testing.T) {
 
 // validate Params
 func TestParams(t *testing.T) {
-	var paramsCtxKey = ctxKey("params")
-
 	params := map[string]string{
 		"foo": "bar",
 	}
 	p := context.Background()
-	c := context.WithValue(p, paramsCtxKey, params)
+	c := WithParams(p, params)
 
 	data := Params(c)
 
@@ -619,6 +683,23 @@ func TestEmptyParams(t *testing.T) {
 	}
 }
 
+// validate the test helpers
+func TestWithParams(t *testing.T) {
+	c := WithParams(context.Background(), map[string]string{"id": "123"})
+	c = WithRoutePattern(c, "/users/:id")
+
+	r, _ := http.NewRequest("GET", "/users/123", nil)
+	r = r.WithContext(c)
+	func(w http.ResponseWriter, r *http.Request) {
+		if id := Param(r.Context(), "id"); id != "123" {
+			t.Errorf("invalid param %s", id)
+		}
+		if p := RoutePattern(r.Context()); p != "/users/:id" {
+			t.Errorf("invalid route pattern %s", p)
+		}
+	}(httptest.NewRecorder(), r)
+}
+
 // validate slicePath
 func TestSlicePath(t *testing.T) {
 	tests := []struct {
@@ -650,6 +731,31 @@ func TestSlicePath(t *testing.T) {
 	}
 }
 
+func TestWildcardRemainder(t *testing.T) {
+	rr := New("/")
+	tests := []struct {
+		pattern  string
+		path     string
+		expected string
+	}{
+		{"/files/*", "/files/a", "a"},
+		{"/files/*", "/files/a/b/c/", "a/b/c"},
+		{"/files/*", "/files/a//b", "a//b"},
+		{"/*", "/", ""},
+		{"/*", "/a/b", "a/b"},
+	}
+	for _, test := range tests {
+		ok, params := matches(&rr, Route{method: "GET", path: test.pattern}, "GET", test.path, false)
+		if !ok {
+			t.Errorf("%s should match %s", test.pattern, test.path)
+			continue
+		}
+		if params["*"] != test.expected {
+			t.Errorf("invalid wildcard %q != %q", params["*"], test.expected)
+		}
+	}
+}
+
 func TestSubRouterMatching(t *testing.T) {
 	r := New("/")
 	s := r.SubRouter("/admin")
@@ -711,55 +817,47 @@ func TestSubRouterMatching(t *testing.T) {
 	}
 }
 
-func Test_SetMethod(t *testing.T) {
-
-	tests := []struct {
-		contentType string
-		_method     string
-	}{
-		{"text/html", "PATCH"},
-		{"text/html", ""},
-		{"multipart/form-data", "PATCH"},
-	}
-
-	for _, test := range tests {
-		form := url.Values{}
-		form.Add("_method", test._method)
-		form.Add("first_name", "John")
-
-		wg := sync.WaitGroup{}
-		wg.Add(1)
-
-		ch := make(chan bool)
-		rr := New("/")
-		rr.Post("/", func(w http.ResponseWriter, r *http.Request) {
-			if test._method != "" {
-				t.Error("should not make it into the post handler")
-			} else {
-				// if _method is blank the method is not overwritten
-				ch <- true
-			}
-		})
-		rr.Patch("/", func(w http.ResponseWriter, r *http.Request) {
-			ch <- true
-		})
-
-		r, _ := http.NewRequest("POST", "/", nil)
-		r.Form = form
-		r.Header.Set("Content-Type", test.contentType)
-		w := httptest.NewRecorder()
-
-		go func() {
-			select {
-			case <-ch:
-				wg.Done()
-			case <-time.Tick(100 * time.Millisecond):
-				t.Error("Patch request not run")
-				wg.Done()
-			}
-		}()
-
-		rr.ServeHTTP(w, r)
-		wg.Wait()
+func TestNotFoundFromHandler(t *testing.T) {
+	rr := New("/")
+	stats := NewStats()
+	rr.Stats(stats)
+	rr.Get("/pages/:slug", func(w http.ResponseWriter, r *http.Request) {
+		if Param(r.Context(), "slug") != "about" {
+			NotFoundFromHandler(w, r)
+			return
+		}
+		w.Write([]byte("about"))
+	})
+	admin := rr.SubRouter("/admin")
+	admin.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		NotFoundFromHandler(w, r)
+	})
+
+	req, _ := http.NewRequest("GET", "/pages/missing", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != 404 || rec.Body.String() != "Not Found\n" {
+		t.Errorf("invalid default response %d %q", rec.Code, rec.Body.String())
+	}
+	if snapshot := stats.Snapshot(); len(snapshot) != 1 || snapshot[0].Pattern != "/pages/:slug" {
+		t.Errorf("request not recorded against the route %+v", snapshot)
+	}
+
+	rr.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte("custom"))
+	})
+	req, _ = http.NewRequest("GET", "/admin/users/5", nil)
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if rec.Code != 404 || rec.Body.String() != "custom" {
+		t.Errorf("invalid custom response %d %q", rec.Code, rec.Body.String())
+	}
+
+	// outside of a router
+	rec = httptest.NewRecorder()
+	NotFoundFromHandler(rec, req)
+	if rec.Code != 404 {
+		t.Errorf("invalid status code %d", rec.Code)
 	}
 }
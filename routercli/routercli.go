@@ -0,0 +1,156 @@
+// Package routercli provides `routes` subcommands for inspecting a router from the app's own
+// binary. It is embedded within the app's main once the router has been built:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "routes" {
+//		if err := routercli.Run(rr, os.Args[2:], os.Stdout); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			os.Exit(1)
+//		}
+//		return
+//	}
+package routercli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chrisolsen/router"
+)
+
+// Usage describes the subcommands
+const Usage = `usage: routes <command>
+
+commands:
+  list                  list all the routes
+  check                 report routes that match the same requests
+  explain METHOD PATH   show how a request would be routed`
+
+// ErrConflicts is returned by the check command when conflicting routes are found
+var ErrConflicts = errors.New("routercli: conflicting routes")
+
+// Run executes the subcommand named by the first arg, writing its output to w
+func Run(rr router.Router, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(Usage)
+	}
+	switch args[0] {
+	case "list":
+		return List(rr, w)
+	case "check":
+		return Check(rr, w)
+	case "explain":
+		if len(args) != 3 {
+			return errors.New("usage: routes explain METHOD PATH")
+		}
+		return Explain(rr, args[1], args[2], w)
+	default:
+		return fmt.Errorf("routercli: unknown command %q\n%s", args[0], Usage)
+	}
+}
+
+// List writes a table of all the routes
+func List(rr router.Router, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tFLAGS")
+	for _, route := range rr.Routes() {
+		var flags []string
+		if route.NoIndex {
+			flags = append(flags, "noindex")
+		}
+		if route.Name != "" {
+			flags = append(flags, "name="+route.Name)
+		}
+		if len(route.Formats) > 0 {
+			flags = append(flags, "formats="+strings.Join(route.Formats, "|"))
+		}
+		if route.Env != "" {
+			flags = append(flags, "env="+route.Env)
+		}
+		if route.Inactive {
+			flags = append(flags, "inactive")
+		}
+		if route.Stub {
+			flags = append(flags, "stub")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", method(route.Method), route.Pattern, strings.Join(flags, ","))
+	}
+	return tw.Flush()
+}
+
+// Check writes the conflicting routes, returning ErrConflicts if there are any
+func Check(rr router.Router, w io.Writer) error {
+	conflicts := rr.Check()
+	if len(conflicts) == 0 {
+		_, err := fmt.Fprintln(w, "ok: no conflicting routes")
+		return err
+	}
+	for _, c := range conflicts {
+		fmt.Fprintf(w, "conflict: %s %s <> %s %s\n", method(c.A.Method), c.A.Pattern, method(c.B.Method), c.B.Pattern)
+	}
+	return ErrConflicts
+}
+
+// Explain writes the routes considered for the request and why they were rejected
+func Explain(rr router.Router, m, path string, w io.Writer) error {
+	result := rr.Explain(m, path)
+	fmt.Fprintf(w, "%s %s\nrouter: %s\n", result.Method, result.Path, result.BasePath)
+	if result.Locale != "" {
+		fmt.Fprintf(w, "locale: %s\n", result.Locale)
+	}
+	if result.Redirect != nil {
+		_, err := fmt.Fprintf(w, "redirect: %d %s\n", result.Redirect.Status, result.Redirect.To)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nMETHOD\tPATTERN\tRESULT")
+	for _, c := range result.Candidates {
+		outcome := "rejected: " + c.Reason
+		if c.Matched {
+			outcome = "matched"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", method(c.Method), c.Pattern, outcome)
+	}
+	tw.Flush()
+
+	if result.Ambiguous {
+		_, err := fmt.Fprintln(w, "\nresult: ambiguous, any of the matched routes may handle the request")
+		return err
+	}
+	if result.Matched == nil {
+		_, err := fmt.Fprintln(w, "\nresult: 404 not found")
+		return err
+	}
+	note := ""
+	if result.AutoHead {
+		note = " (HEAD served by GET)"
+	} else if result.Folded {
+		note = " (matched regardless of case)"
+	}
+	fmt.Fprintf(w, "\nresult: %s %s%s\n", method(result.Matched.Method), result.Matched.Pattern, note)
+	for _, key := range sortedKeys(result.Params) {
+		fmt.Fprintf(w, "  %s = %s\n", key, result.Params[key])
+	}
+	return nil
+}
+
+// method names the routes that match all methods
+func method(m string) string {
+	if m == "" {
+		return "*"
+	}
+	return m
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
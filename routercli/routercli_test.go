@@ -0,0 +1,95 @@
+package routercli
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func testRouter() router.Router {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+	rr := router.New("/")
+	rr.Get("/", fn)
+	rr.Get("/users/:id", fn)
+	rr.Get("/users/new", fn).NoIndex()
+	rr.Post("/users", fn)
+	admin := rr.SubRouter("/admin")
+	admin.Get("/reports", fn).Name("reports").Formats("csv", "json")
+	rr.Redirects(map[string]string{"/old": "/"})
+	return rr
+}
+
+func TestList(t *testing.T) {
+	var out bytes.Buffer
+	if err := Run(testRouter(), []string{"list"}, &out); err != nil {
+		t.Error(err)
+		return
+	}
+	expected := `METHOD  PATTERN         FLAGS
+GET     /
+GET     /admin/reports  name=reports,formats=csv|json
+POST    /users
+GET     /users/:id
+GET     /users/new      noindex
+`
+	lines := strings.Split(out.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	if strings.Join(lines, "\n") != expected {
+		t.Errorf("invalid output\n%s", out.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var out bytes.Buffer
+	if err := Run(testRouter(), []string{"check"}, &out); err != ErrConflicts {
+		t.Errorf("invalid error %v", err)
+	}
+	if out.String() != "conflict: GET /users/:id <> GET /users/new\n" {
+		t.Errorf("invalid output %q", out.String())
+	}
+
+	rr := router.New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	out.Reset()
+	if err := Run(rr, []string{"check"}, &out); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	var out bytes.Buffer
+	if err := Run(testRouter(), []string{"explain", "get", "/users/5"}, &out); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, s := range []string{"GET /users/5\n", "rejected: method does not match", "result: GET /users/:id\n  id = 5\n"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("missing %q in\n%s", s, out.String())
+		}
+	}
+
+	out.Reset()
+	Run(testRouter(), []string{"explain", "GET", "/old"}, &out)
+	if !strings.Contains(out.String(), "redirect: 301 /") {
+		t.Errorf("missing redirect in\n%s", out.String())
+	}
+
+	out.Reset()
+	Run(testRouter(), []string{"explain", "HEAD", "/users/new"}, &out)
+	if !strings.Contains(out.String(), "result: ambiguous") {
+		t.Errorf("missing ambiguity in\n%s", out.String())
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	for _, args := range [][]string{nil, {"drop"}, {"explain", "GET"}} {
+		if err := Run(testRouter(), args, &bytes.Buffer{}); err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+	}
+}
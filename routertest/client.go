@@ -0,0 +1,224 @@
+package routertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Client sends requests to a handler, typically a router, without a server. Each request is built
+// fluently and checked with the assertions of its Response.
+//
+//	c := routertest.New(rr)
+//	c.Post("/users").WithJSON(user).Expect(t).Status(201).JSONPath("$.name", "bob")
+type Client struct {
+	h      http.Handler
+	header http.Header
+}
+
+// New creates a client sending requests to the handler
+func New(h http.Handler) *Client {
+	return &Client{h: h, header: make(http.Header)}
+}
+
+// WithHeader sets a header sent with all of the client's requests, ex. an Authorization header
+func (c *Client) WithHeader(key, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+// Get starts a GET request
+func (c *Client) Get(path string) *Request { return c.Request(http.MethodGet, path) }
+
+// Head starts a HEAD request
+func (c *Client) Head(path string) *Request { return c.Request(http.MethodHead, path) }
+
+// Post starts a POST request
+func (c *Client) Post(path string) *Request { return c.Request(http.MethodPost, path) }
+
+// Put starts a PUT request
+func (c *Client) Put(path string) *Request { return c.Request(http.MethodPut, path) }
+
+// Patch starts a PATCH request
+func (c *Client) Patch(path string) *Request { return c.Request(http.MethodPatch, path) }
+
+// Delete starts a DELETE request
+func (c *Client) Delete(path string) *Request { return c.Request(http.MethodDelete, path) }
+
+// Request starts a request with the method
+func (c *Client) Request(method, path string) *Request {
+	return &Request{client: c, method: method, path: path, header: c.header.Clone()}
+}
+
+// Request is a request being built, sent by Expect
+type Request struct {
+	client *Client
+	method string
+	path   string
+	header http.Header
+	body   io.Reader
+	err    error
+}
+
+// WithHeader sets a header of the request
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithBody sets the body of the request along with its content type
+func (r *Request) WithBody(contentType string, body io.Reader) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// WithJSON sets the body of the request to v encoded as JSON
+func (r *Request) WithJSON(v interface{}) *Request {
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+	}
+	return r.WithBody("application/json", bytes.NewReader(b))
+}
+
+// Expect sends the request, returning its response to be checked. Assertions that fail are
+// reported to t without stopping the test.
+func (r *Request) Expect(t testing.TB) *Response {
+	t.Helper()
+	if r.err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.path, r.err)
+	}
+	req := httptest.NewRequest(r.method, r.path, r.body)
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	r.client.h.ServeHTTP(rec, req)
+	return &Response{ResponseRecorder: rec, t: t, name: r.method + " " + r.path}
+}
+
+// Response is the recorded response of a request
+type Response struct {
+	*httptest.ResponseRecorder
+	t    testing.TB
+	name string
+}
+
+// Status asserts the status code of the response
+func (r *Response) Status(status int) *Response {
+	r.t.Helper()
+	if r.Code != status {
+		r.t.Errorf("%s: status %d != %d\n%s", r.name, r.Code, status, strings.TrimSpace(r.Body.String()))
+	}
+	return r
+}
+
+// Header asserts the value of a response header
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+	if v := r.Result().Header.Get(key); v != value {
+		r.t.Errorf("%s: header %s %q != %q", r.name, key, v, value)
+	}
+	return r
+}
+
+// BodyContains asserts that the body of the response contains s
+func (r *Response) BodyContains(s string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Body.String(), s) {
+		r.t.Errorf("%s: body doesn't contain %q\n%s", r.name, s, r.Body.String())
+	}
+	return r
+}
+
+// JSON decodes the body of the response into v
+func (r *Response) JSON(v interface{}) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Errorf("%s: invalid json: %v\n%s", r.name, err, r.Body.String())
+	}
+	return r
+}
+
+// JSONPath asserts the value at the path of the JSON body, compared to expected after it's
+// encoded as JSON, so `1` matches the number 1. Paths are written as `$.users[0].name`.
+func (r *Response) JSONPath(path string, expected interface{}) *Response {
+	r.t.Helper()
+	var body interface{}
+	if err := json.Unmarshal(r.Body.Bytes(), &body); err != nil {
+		r.t.Errorf("%s: invalid json: %v\n%s", r.name, err, r.Body.String())
+		return r
+	}
+	actual, err := lookupJSONPath(body, path)
+	if err != nil {
+		r.t.Errorf("%s: %s: %v", r.name, path, err)
+		return r
+	}
+	b, err := json.Marshal(expected)
+	if err != nil {
+		r.t.Errorf("%s: %s: %v", r.name, path, err)
+		return r
+	}
+	var want interface{}
+	json.Unmarshal(b, &want)
+	if !reflect.DeepEqual(actual, want) {
+		got, _ := json.Marshal(actual)
+		r.t.Errorf("%s: %s %s != %s", r.name, path, got, b)
+	}
+	return r
+}
+
+// lookupJSONPath finds the value of the path, a subset of JSONPath made of fields and indexes
+func lookupJSONPath(v interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path must start with $")
+	}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			rest = rest[end+1:]
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", key)
+			}
+			if v, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("missing field %s", key)
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %s", rest[1:end])
+			}
+			rest = rest[end+1:]
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[%d] is not an array", i)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range", i)
+			}
+			v = arr[i]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+	return v, nil
+}
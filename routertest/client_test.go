@@ -0,0 +1,88 @@
+package routertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+// recordingT records the failures of assertions expected to fail
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func testClient() *Client {
+	rr := router.New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": %s, "name": "bob", "roles": ["admin", "dev"], "auth": %q}`, router.Param(r.Context(), "id"), r.Header.Get("Authorization"))
+	})
+	rr.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		var user map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&user)
+		user["id"] = 2
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+	})
+	return New(rr).WithHeader("Authorization", "token")
+}
+
+func TestClient(t *testing.T) {
+	c := testClient()
+	c.Get("/users/1").Expect(t).
+		Status(200).
+		Header("Content-Type", "application/json").
+		JSONPath("$.id", 1).
+		JSONPath("$.roles[1]", "dev").
+		JSONPath("$.roles", []string{"admin", "dev"}).
+		JSONPath("$.auth", "token")
+
+	var user struct{ ID int }
+	c.Post("/users").WithJSON(map[string]string{"name": "ann"}).WithHeader("X-Request-ID", "1").Expect(t).
+		Status(201).
+		BodyContains(`"name":"ann"`).
+		JSON(&user)
+	if user.ID != 2 {
+		t.Errorf("invalid user %+v", user)
+	}
+}
+
+func TestClientFailures(t *testing.T) {
+	rt := &recordingT{}
+	testClient().Get("/users/1").Expect(rt).
+		Status(404).
+		Header("Content-Type", "text/plain").
+		BodyContains("alice").
+		JSONPath("$.name", "alice").
+		JSONPath("$.roles[2]", "ops").
+		JSONPath("$.name.first", "bob")
+
+	expected := []string{
+		"GET /users/1: status 200 != 404",
+		`GET /users/1: header Content-Type "application/json" != "text/plain"`,
+		`GET /users/1: body doesn't contain "alice"`,
+		`GET /users/1: $.name "bob" != "alice"`,
+		"GET /users/1: $.roles[2]: index 2 out of range",
+		"GET /users/1: $.name.first: first is not an object",
+	}
+	if len(rt.errors) != len(expected) {
+		t.Fatalf("invalid failures %q", rt.errors)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(rt.errors[i], prefix) {
+			t.Errorf("%q doesn't start with %q", rt.errors[i], prefix)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package routertest provides utilities for testing routers and their handlers
+package routertest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+// Failure is a route that responded with a 5xx to its smoke test request
+type Failure struct {
+	Method  string
+	Pattern string
+	Path    string
+	Status  int
+	Body    string
+}
+
+func (f Failure) String() string {
+	return fmt.Sprintf("%s %s (%s): %d %s", f.Method, f.Pattern, f.Path, f.Status, strings.TrimSpace(f.Body))
+}
+
+// Smoke sends a minimal request to every active route of the router, reporting the routes that
+// respond with a 5xx. URL params are filled with the values set by the route's Example, or `1` when
+// none is set, and the query params required by the route with their first allowed value, falling
+// back on the same. Requests with bodies are sent an empty JSON object.
+//
+// The requests run the handlers, so the router should be wired to test dependencies.
+func Smoke(rr router.Router) []Failure {
+	var failures []Failure
+	for _, route := range rr.Routes() {
+		if route.Inactive {
+			continue
+		}
+		method := route.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		path := SamplePath(route.Pattern, route.Examples)
+		if query := sampleQuery(route.Query, route.Examples); query != "" {
+			path += "?" + query
+		}
+
+		var req *http.Request
+		switch method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			req = httptest.NewRequest(method, path, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+		default:
+			req = httptest.NewRequest(method, path, nil)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code >= 500 {
+			failures = append(failures, Failure{
+				Method:  method,
+				Pattern: route.Pattern,
+				Path:    path,
+				Status:  rec.Code,
+				Body:    rec.Body.String(),
+			})
+		}
+	}
+	return failures
+}
+
+// SmokeTest runs Smoke, failing the test for every route that responded with a 5xx
+func SmokeTest(t testing.TB, rr router.Router) {
+	t.Helper()
+	for _, f := range Smoke(rr) {
+		t.Error(f)
+	}
+}
+
+// SamplePath fills the url params and wildcard of the pattern with the escaped example values, or
+// `1` when the param has no example
+func SamplePath(pattern string, examples map[string]string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		var name string
+		switch {
+		case part == "*":
+			name = "*"
+		case strings.HasPrefix(part, ":"):
+			name = part[1:]
+		default:
+			continue
+		}
+		val, ok := examples[name]
+		if !ok {
+			parts[i] = "1"
+			continue
+		}
+		// the wildcard's example spans several segments, whose separators are kept
+		segments := strings.Split(val, "/")
+		if name != "*" {
+			segments = []string{val}
+		}
+		for j, segment := range segments {
+			segments[j] = url.PathEscape(segment)
+		}
+		parts[i] = strings.Join(segments, "/")
+	}
+	return strings.Join(parts, "/")
+}
+
+// sampleQuery encodes the required query params with their first allowed value, or the example
+// value, or `1`
+func sampleQuery(required map[string][]string, examples map[string]string) string {
+	query := url.Values{}
+	for key, values := range required {
+		switch val, ok := examples[key]; {
+		case len(values) > 0:
+			query.Set(key, values[0])
+		case ok:
+			query.Set(key, val)
+		default:
+			query.Set(key, "1")
+		}
+	}
+	return query.Encode()
+}
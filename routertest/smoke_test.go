@@ -0,0 +1,77 @@
+package routertest
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+func TestSamplePath(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		examples map[string]string
+		expected string
+	}{
+		{"/", nil, "/"},
+		{"/users/:id", nil, "/users/1"},
+		{"/users/:name/posts/:id", map[string]string{"name": "bob"}, "/users/bob/posts/1"},
+		{"/files/*", map[string]string{"*": "docs/a.txt"}, "/files/docs/a.txt"},
+		{"/users/:name", map[string]string{"name": "jo/bo smith"}, "/users/jo%2Fbo%20smith"},
+		{"/files/*", map[string]string{"*": "my docs/a?.txt"}, "/files/my%20docs/a%3F.txt"},
+	}
+	for _, test := range tests {
+		if path := SamplePath(test.pattern, test.examples); path != test.expected {
+			t.Errorf("%s: %q != %q", test.pattern, path, test.expected)
+		}
+	}
+}
+
+func TestSmoke(t *testing.T) {
+	rr := router.New("/")
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if router.Param(r.Context(), "id") != "42" {
+			http.NotFound(w, r)
+		}
+	}).Example("id", "42")
+	rr.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		var db map[string]string
+		db["user"] = "nil map"
+	})
+	admin := rr.SubRouter("/admin")
+	admin.Handle("/reports", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	failures := Smoke(rr)
+	if len(failures) != 2 {
+		t.Errorf("invalid failures %v", failures)
+		return
+	}
+	if f := failures[0]; f.Method != "GET" || f.Pattern != "/admin/reports" || f.Status != 502 {
+		t.Errorf("invalid failure %v", f)
+	}
+	if f := failures[1]; f.Method != "POST" || f.Path != "/users" || f.Status != 500 {
+		t.Errorf("invalid failure %v", f)
+	}
+}
+
+func TestSmokeQuery(t *testing.T) {
+	rr := router.New("/")
+	var served []string
+	rr.Get("/search", func(w http.ResponseWriter, r *http.Request) {
+		served = append(served, r.URL.RawQuery)
+	}).Query("q").Query("page").Example("q", "router")
+	rr.Get("/export", func(w http.ResponseWriter, r *http.Request) {
+		served = append(served, r.URL.RawQuery)
+	}).Query("format", "csv", "json")
+
+	if failures := Smoke(rr); len(failures) != 0 {
+		t.Errorf("invalid failures %v", failures)
+	}
+	if !reflect.DeepEqual(served, []string{"format=csv", "page=1&q=router"}) {
+		t.Errorf("the required query params should be filled, got %v", served)
+	}
+}
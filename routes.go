@@ -0,0 +1,191 @@
+package router
+
+import (
+	"sort"
+	"strings"
+)
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	// Method is empty for routes registered with Handle or Mount, which match all methods
+	Method  string
+	Pattern string
+	NoIndex bool
+
+	// Name is the name used to build the route's url with URLFor
+	Name string
+
+	// Formats are the format suffixes the route can be requested with, ex. `csv` for `/reports/1.csv`
+	Formats []string
+
+	// Examples are the sample url param values set with Example
+	Examples map[string]string
+
+	// Query are the query params required with Query, along with the values allowed for each, none
+	// when any value is allowed
+	Query map[string][]string
+
+	// Policies are the policies wrapping the route's handler, outermost first
+	Policies []Policy
+
+	// Env is the environment label of routes registered with When
+	Env string
+
+	// Inactive is set for routes registered with When that aren't served in this environment
+	Inactive bool
+
+	// Stub is set for placeholder routes registered with Stub
+	Stub bool
+
+	// Streaming is set for long-lived routes marked with Streaming
+	Streaming bool
+
+	// Doc documents the route, nil if it's undocumented
+	Doc *RouteDoc
+}
+
+// RouteConflict is a pair of routes that can both match the same request. The router doesn't rank
+// routes, so which of the two handles the request is undefined.
+type RouteConflict struct {
+	A, B RouteInfo
+}
+
+// Example sets a sample value of the url param, or of a query param required with Query, used by
+// tooling that needs to build requests for the route, such as routertest's smoke tests
+func (e *Endpoint) Example(param, value string) *Endpoint {
+	if e.examples == nil {
+		e.examples = make(map[string]string)
+	}
+	e.examples[param] = value
+	return e
+}
+
+// Routes lists the routes of the router and its subrouters, ordered by pattern then method
+func (r Router) Routes() []RouteInfo {
+	if reloaded := r.reloaded(); reloaded != nil {
+		return reloaded.Routes()
+	}
+	routes := r.collectRoutes(nil)
+	sortRoutes(routes)
+	return routes
+}
+
+// collectRoutes lists the routes of the router and its subrouters, which inherit the policies
+func (r Router) collectRoutes(inherited []Policy) []RouteInfo {
+	inherited = append(append([]Policy(nil), inherited...), r.attached...)
+	routes := r.ownRoutes(inherited)
+	for _, sub := range r.subRouters {
+		routes = append(routes, sub.collectRoutes(inherited)...)
+	}
+	for _, shadow := range r.inactive {
+		for _, route := range shadow.collectRoutes(inherited) {
+			route.Inactive = true
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// ownRoutes lists the routes registered directly on the router
+func (r Router) ownRoutes(inherited []Policy) []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.routes))
+	for route, ep := range r.routes {
+		routes = append(routes, r.routeInfo(route, ep, inherited))
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// routeInfo describes the route, wrapped by the inherited policies and those of its endpoint
+func (r Router) routeInfo(route Route, ep *Endpoint, inherited []Policy) RouteInfo {
+	var policies []Policy
+	if len(inherited)+len(ep.attached) > 0 {
+		policies = append(append(policies, inherited...), ep.attached...)
+	}
+	var query map[string][]string
+	if len(ep.query) > 0 {
+		query = make(map[string][]string, len(ep.query))
+		for _, c := range ep.query {
+			query[c.key] = append(query[c.key], c.values...)
+		}
+	}
+	return RouteInfo{
+		Method:    route.method,
+		Pattern:   r.fullPath(route.path),
+		NoIndex:   ep.noIndex,
+		Name:      ep.name,
+		Formats:   ep.formats,
+		Examples:  ep.examples,
+		Query:     query,
+		Policies:  policies,
+		Env:       ep.env,
+		Stub:      ep.stub,
+		Streaming: ep.streaming,
+		Doc:       ep.doc,
+	}
+}
+
+func sortRoutes(routes []RouteInfo) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern == routes[j].Pattern {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Pattern < routes[j].Pattern
+	})
+}
+
+// Check reports the routes of each router whose patterns overlap for the same method, ex.
+// `/users/new` and `/users/:id`
+func (r Router) Check() []RouteConflict {
+	var conflicts []RouteConflict
+	routes := r.ownRoutes(nil)
+	for i, a := range routes {
+		for _, b := range routes[i+1:] {
+			if a.Method != b.Method && a.Method != "" && b.Method != "" {
+				continue
+			}
+			if patternsOverlap(slicePath(a.Pattern), slicePath(b.Pattern)) {
+				conflicts = append(conflicts, RouteConflict{A: a, B: b})
+			}
+		}
+	}
+	for _, sub := range r.subRouters {
+		conflicts = append(conflicts, sub.Check()...)
+	}
+	return conflicts
+}
+
+// patternsOverlap reports whether a path exists that both sliced patterns match
+func patternsOverlap(a, b []string) bool {
+	aWild, bWild := a[len(a)-1] == "*", b[len(b)-1] == "*"
+	if aWild {
+		a = a[:len(a)-1]
+	}
+	if bWild {
+		b = b[:len(b)-1]
+	}
+
+	// a wildcard requires at least one segment after its prefix
+	switch {
+	case !aWild && !bWild && len(a) != len(b):
+		return false
+	case aWild && !bWild && len(b) <= len(a):
+		return false
+	case bWild && !aWild && len(a) <= len(b):
+		return false
+	}
+
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if strings.HasPrefix(a[i], ":") || strings.HasPrefix(b[i], ":") {
+			continue
+		}
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
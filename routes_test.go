@@ -0,0 +1,25 @@
+package router
+
+import "testing"
+
+func TestPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"/users/:id", "/users/new", true},
+		{"/users/:id", "/users/:name", true},
+		{"/users/:id", "/users/:id/edit", false},
+		{"/users", "/accounts", false},
+		{"/files/*", "/files", false},
+		{"/files/*", "/files/:name", true},
+		{"/files/*", "/files/docs/:name", true},
+		{"/files/*", "/images/:name", false},
+		{"/files/*", "/:dir/*", true},
+	}
+	for _, test := range tests {
+		if ok := patternsOverlap(slicePath(test.a), slicePath(test.b)); ok != test.expected {
+			t.Errorf("%s %s: %v != %v", test.a, test.b, ok, test.expected)
+		}
+	}
+}
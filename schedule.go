@@ -0,0 +1,78 @@
+package router
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// now is swapped out within the tests
+var now = time.Now
+
+// Activation decides whether a scheduled handler is active for the request
+type Activation interface {
+	Active(r *http.Request) bool
+}
+
+// ActivationFunc allows a function to be used as an Activation
+type ActivationFunc func(r *http.Request) bool
+
+// Active calls the function
+func (fn ActivationFunc) Active(r *http.Request) bool {
+	return fn(r)
+}
+
+// Between activates the handler from the start time until the end time. A zero start or end
+// time leaves that side of the period open.
+func Between(start, end time.Time) Activation {
+	return ActivationFunc(func(r *http.Request) bool {
+		t := now()
+		if !start.IsZero() && t.Before(start) {
+			return false
+		}
+		if !end.IsZero() && !t.Before(end) {
+			return false
+		}
+		return true
+	})
+}
+
+// Percentage activates the handler for the percent (0-100) of requests. When a key func is provided
+// the same key, ex. a user id cookie, always receives the same decision; otherwise each request
+// is decided randomly.
+func Percentage(percent int, key func(r *http.Request) string) Activation {
+	return ActivationFunc(func(r *http.Request) bool {
+		if percent <= 0 {
+			return false
+		}
+		if percent >= 100 {
+			return true
+		}
+		if key == nil {
+			return rand.Intn(100) < percent
+		}
+		h := fnv.New32a()
+		h.Write([]byte(key(r)))
+		return int(h.Sum32()%100) < percent
+	})
+}
+
+// Schedule runs the handler while all of the activations are active for the request, and the
+// fallback handler otherwise. A nil fallback responds with a 404.
+//
+//	rr.Get("/promo", router.Schedule(promo, nil, router.Between(start, end)))
+func Schedule(h, fallback http.HandlerFunc, when ...Activation) http.HandlerFunc {
+	if fallback == nil {
+		fallback = http.NotFound
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, a := range when {
+			if !a.Active(r) {
+				fallback(w, r)
+				return
+			}
+		}
+		h(w, r)
+	}
+}
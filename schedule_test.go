@@ -0,0 +1,90 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduleBetween(t *testing.T) {
+	defer func() { now = time.Now }()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	tests := []struct {
+		now            time.Time
+		expectedStatus int
+	}{
+		{start.Add(-time.Second), 404},
+		{start, 200},
+		{end.Add(-time.Second), 200},
+		{end, 404},
+	}
+
+	h := Schedule(func(w http.ResponseWriter, r *http.Request) {}, nil, Between(start, end))
+	for _, test := range tests {
+		now = func() time.Time { return test.now }
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+		h(rec, req)
+
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%s: invalid status code %d != %d", test.now, rec.Code, test.expectedStatus)
+		}
+	}
+}
+
+func TestScheduleFallback(t *testing.T) {
+	h := Schedule(
+		func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("new")) },
+		func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("old")) },
+		ActivationFunc(func(r *http.Request) bool { return r.Header.Get("X-Beta") != "" }),
+	)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if rec.Body.String() != "old" {
+		t.Errorf("fallback not run: %s", rec.Body.String())
+	}
+
+	req.Header.Set("X-Beta", "1")
+	rec = httptest.NewRecorder()
+	h(rec, req)
+	if rec.Body.String() != "new" {
+		t.Errorf("handler not run: %s", rec.Body.String())
+	}
+}
+
+func TestSchedulePercentage(t *testing.T) {
+	key := func(r *http.Request) string { return r.Header.Get("X-User") }
+
+	if Percentage(0, key).Active(&http.Request{}) {
+		t.Error("0% should never be active")
+	}
+	if !Percentage(100, nil).Active(&http.Request{}) {
+		t.Error("100% should always be active")
+	}
+
+	active := 0
+	pct := Percentage(30, key)
+	for i := 0; i < 1000; i++ {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User", fmt.Sprintf("user-%d", i))
+		first := pct.Active(req)
+		if first != pct.Active(req) {
+			t.Error("decision should be sticky for the same key")
+			return
+		}
+		if first {
+			active++
+		}
+	}
+	if active < 200 || active > 400 {
+		t.Errorf("distribution too far off 30%%: %d/1000", active)
+	}
+}
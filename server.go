@@ -0,0 +1,143 @@
+package router
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// acmeChallengePath is where ACME CAs fetch HTTP-01 challenge tokens
+const acmeChallengePath = "/.well-known/acme-challenge"
+
+// CertManager issues certificates on demand. It is satisfied by golang.org/x/crypto/acme/autocert's
+// *Manager, which keeps the router free of the dependency:
+//
+//	m := &autocert.Manager{Prompt: autocert.AcceptTOS, Cache: autocert.DirCache("certs")}
+//	rr.ServeAutoCert(router.AutoCertOptions{Manager: m, Hosts: []string{"example.com"}})
+type CertManager interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	// HTTPHandler answers the HTTP-01 challenges, passing other requests to the fallback
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// AutoCertOptions configures ServeAutoCert
+type AutoCertOptions struct {
+	Manager CertManager
+
+	// Hosts are the only host names certificates are requested for
+	Hosts []string
+
+	// Addr is the HTTPS address, `:443` by default
+	Addr string
+
+	// HTTPAddr is the address answering challenges and redirecting to HTTPS, `:80` by default
+	HTTPAddr string
+}
+
+// ServeTLS serves the router over HTTPS using the certificate and key files
+func (r Router) ServeTLS(addr, certFile, keyFile string) error {
+	return newServer(addr, r).ListenAndServeTLS(certFile, keyFile)
+}
+
+// ServeAutoCert serves the router over HTTPS with certificates obtained by the manager, typically
+// from Let's Encrypt. The HTTP address answers the CA's challenges through the router and
+// redirects all other requests to HTTPS. It returns once either server fails.
+func (r *Router) ServeAutoCert(opts AutoCertOptions) error {
+	httpsSrv, httpSrv, err := r.autoCertServers(opts)
+	if err != nil {
+		return err
+	}
+	errs := make(chan error, 2)
+	go func() { errs <- httpSrv.ListenAndServe() }()
+	go func() { errs <- httpsSrv.ListenAndServeTLS("", "") }()
+	return <-errs
+}
+
+// autoCertServers builds the HTTPS and HTTP servers of ServeAutoCert
+func (r *Router) autoCertServers(opts AutoCertOptions) (*http.Server, *http.Server, error) {
+	if opts.Manager == nil {
+		return nil, nil, errors.New("router: missing cert manager")
+	}
+	if len(opts.Hosts) == 0 {
+		return nil, nil, errors.New("router: missing autocert hosts")
+	}
+	if opts.Addr == "" {
+		opts.Addr = ":443"
+	}
+	if opts.HTTPAddr == "" {
+		opts.HTTPAddr = ":80"
+	}
+
+	challenges := opts.Manager.HTTPHandler(nil)
+	r.Handle(acmeChallengePath+"/*", challenges)
+
+	httpsSrv := newServer(opts.Addr, *r)
+	httpsSrv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1", "acme-tls/1"},
+		GetCertificate: whitelistHosts(opts.Hosts, opts.Manager.GetCertificate),
+	}
+	_, port, _ := net.SplitHostPort(opts.Addr)
+	httpSrv := newServer(opts.HTTPAddr, redirectHTTPS(opts.Hosts, port, *r))
+	return httpsSrv, httpSrv, nil
+}
+
+func newServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// whitelistHosts only requests certificates for the hosts, preventing clients from triggering
+// certificate requests for arbitrary names
+func whitelistHosts(hosts []string, next func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if !allowedHost(hosts, hello.ServerName) {
+			return nil, fmt.Errorf("router: host %q not allowed", hello.ServerName)
+		}
+		return next(hello)
+	}
+}
+
+func allowedHost(hosts []string, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, allowed := range hosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectHTTPS passes ACME challenges on to the router and permanently redirects all other
+// requests for the hosts to HTTPS on the port
+func redirectHTTPS(hosts []string, port string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, acmeChallengePath+"/") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !allowedHost(hosts, r.Host) {
+			http.Error(w, http.StatusText(http.StatusMisdirectedRequest), http.StatusMisdirectedRequest)
+			return
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
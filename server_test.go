@@ -0,0 +1,88 @@
+package router
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testCertManager struct {
+	requested []string
+}
+
+func (m *testCertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.requested = append(m.requested, hello.ServerName)
+	return &tls.Certificate{}, nil
+}
+
+func (m *testCertManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("token"))
+	})
+}
+
+func TestAutoCertServers(t *testing.T) {
+	m := &testCertManager{}
+	rr := New("/")
+	rr.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("home"))
+	})
+
+	if _, _, err := rr.autoCertServers(AutoCertOptions{Manager: m}); err == nil {
+		t.Error("expected missing hosts error")
+	}
+	httpsSrv, httpSrv, err := rr.autoCertServers(AutoCertOptions{Manager: m, Hosts: []string{"example.com"}, Addr: ":8443"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if httpSrv.Addr != ":80" || httpsSrv.Addr != ":8443" {
+		t.Errorf("invalid addresses %s %s", httpSrv.Addr, httpsSrv.Addr)
+	}
+
+	getCert := httpsSrv.TLSConfig.GetCertificate
+	if _, err := getCert(&tls.ClientHelloInfo{ServerName: "Example.com"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := getCert(&tls.ClientHelloInfo{ServerName: "evil.com"}); err == nil {
+		t.Error("certificate requested for host outside the whitelist")
+	}
+	if len(m.requested) != 1 {
+		t.Errorf("invalid certificate requests %v", m.requested)
+	}
+
+	tests := []struct {
+		host     string
+		path     string
+		status   int
+		location string
+		body     string
+	}{
+		{"example.com", "/.well-known/acme-challenge/abc", 200, "", "token"},
+		{"example.com:80", "/users?page=2", 308, "https://example.com:8443/users?page=2", ""},
+		{"evil.com", "/", 421, "", ""},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Host = test.host
+		rec := httptest.NewRecorder()
+		httpSrv.Handler.ServeHTTP(rec, req)
+		if rec.Code != test.status || rec.Header().Get("Location") != test.location {
+			t.Errorf("%s%s: invalid response %d %q", test.host, test.path, rec.Code, rec.Header().Get("Location"))
+		}
+		if test.body != "" && rec.Body.String() != test.body {
+			t.Errorf("%s%s: invalid body %q", test.host, test.path, rec.Body.String())
+		}
+	}
+
+	// the HTTPS server serves the routes, including the challenges
+	for path, body := range map[string]string{"/": "home", "/.well-known/acme-challenge/abc": "token"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		httpsSrv.Handler.ServeHTTP(rec, req)
+		if rec.Body.String() != body {
+			t.Errorf("%s: invalid body %q", path, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SitemapEntry is a single url within the sitemap. Loc can be either a path, which is prefixed with
+// the sitemap's base url, or an absolute url.
+type SitemapEntry struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+// SitemapProvider returns the entries of dynamic routes, ex. all the product urls
+type SitemapProvider func(r *http.Request) ([]SitemapEntry, error)
+
+// SitemapPriority sets the priority (0.0 - 1.0) of the route within the generated sitemap
+func (e *Endpoint) SitemapPriority(priority float64) *Endpoint {
+	e.sitemapPriority = priority
+	return e
+}
+
+// Sitemap serves a sitemap at the path, typically `/sitemap.xml`, containing all the public GET routes
+// without url params, followed by the entries of the providers. Paths are prefixed with the base
+// url, ex. `https://example.com`, rather than the request's host, which clients control. The sitemap
+// is gzipped for clients that accept it. It panics if the base url isn't an absolute http(s) url.
+func (r *Router) Sitemap(path, baseURL string, providers ...SitemapProvider) *Endpoint {
+	base, err := url.Parse(baseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		panic(fmt.Sprintf("router: invalid sitemap base url %q", baseURL))
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	sitemapPath := r.fullPath(path)
+	return r.Get(path, func(w http.ResponseWriter, req *http.Request) {
+		entries := r.sitemapEntries(sitemapPath)
+		for _, provider := range providers {
+			more, err := provider(req)
+			if err != nil {
+				Fail(req, err)
+				return
+			}
+			entries = append(entries, more...)
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Header().Add("Vary", "Accept-Encoding")
+		var out io.Writer = w
+		if q, _ := acceptQuality(parseAccept(req.Header.Get("Accept-Encoding")), "gzip"); q > 0 {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+		writeSitemap(out, baseURL, entries)
+	})
+}
+
+// sitemapEntries collects the static GET routes of the router and its subrouters
+func (r Router) sitemapEntries(sitemapPath string) []SitemapEntry {
+	var entries []SitemapEntry
+	for route, ep := range r.routes {
+		path := r.fullPath(route.path)
+		if route.method != http.MethodGet || ep.noIndex || path == sitemapPath || strings.ContainsAny(path, ":*") {
+			continue
+		}
+		entries = append(entries, SitemapEntry{Loc: path, Priority: ep.sitemapPriority})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Loc < entries[j].Loc
+	})
+	for _, sub := range r.subRouters {
+		entries = append(entries, sub.sitemapEntries(sitemapPath)...)
+	}
+	return entries
+}
+
+func writeSitemap(w io.Writer, base string, entries []SitemapEntry) {
+	io.WriteString(w, xml.Header)
+	io.WriteString(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+"\n")
+	for _, entry := range entries {
+		loc := entry.Loc
+		if strings.HasPrefix(loc, "/") {
+			loc = base + loc
+		}
+		io.WriteString(w, "  <url>\n    <loc>")
+		xml.EscapeText(w, []byte(loc))
+		io.WriteString(w, "</loc>\n")
+		if !entry.LastMod.IsZero() {
+			io.WriteString(w, "    <lastmod>"+entry.LastMod.UTC().Format(time.RFC3339)+"</lastmod>\n")
+		}
+		if entry.ChangeFreq != "" {
+			io.WriteString(w, "    <changefreq>")
+			xml.EscapeText(w, []byte(entry.ChangeFreq))
+			io.WriteString(w, "</changefreq>\n")
+		}
+		if entry.Priority > 0 {
+			io.WriteString(w, "    <priority>"+strconv.FormatFloat(entry.Priority, 'f', 1, 64)+"</priority>\n")
+		}
+		io.WriteString(w, "  </url>\n")
+	}
+	io.WriteString(w, "</urlset>\n")
+}
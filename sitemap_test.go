@@ -0,0 +1,146 @@
+package router
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSitemap(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) {}
+
+	rr := New("/")
+	rr.Sitemap("/sitemap.xml", "http://example.com/", func(r *http.Request) ([]SitemapEntry, error) {
+		return []SitemapEntry{
+			{Loc: "/products/shoes", LastMod: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC), Priority: 0.5},
+			{Loc: "https://cdn.example.com/a&b", ChangeFreq: "daily"},
+		}, nil
+	})
+	rr.Get("/", fn).SitemapPriority(1)
+	rr.Get("/about", fn)
+	rr.Post("/contact", fn)
+	rr.Get("/products/:slug", fn)
+	rr.Get("/settings", fn).NoIndex()
+	rr.SubRouter("/blog").Get("/", fn)
+
+	// the host of the request is ignored
+	req, _ := http.NewRequest("GET", "http://attacker.example/sitemap.xml", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://example.com/</loc>
+    <priority>1.0</priority>
+  </url>
+  <url>
+    <loc>http://example.com/about</loc>
+  </url>
+  <url>
+    <loc>http://example.com/blog</loc>
+  </url>
+  <url>
+    <loc>http://example.com/products/shoes</loc>
+    <lastmod>2020-05-01T00:00:00Z</lastmod>
+    <priority>0.5</priority>
+  </url>
+  <url>
+    <loc>https://cdn.example.com/a&amp;b</loc>
+    <changefreq>daily</changefreq>
+  </url>
+</urlset>
+`
+	if rec.Body.String() != expected {
+		t.Errorf("invalid sitemap\n%s", rec.Body.String())
+	}
+}
+
+func TestSitemapGzip(t *testing.T) {
+	rr := New("/")
+	rr.Sitemap("/sitemap.xml", "https://example.com")
+	rr.Get("/about", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/sitemap.xml", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Error("sitemap not gzipped")
+		return
+	}
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	body, _ := ioutil.ReadAll(gr)
+	if !strings.Contains(string(body), "/about</loc>") {
+		t.Errorf("invalid sitemap %s", body)
+	}
+}
+
+func TestSitemapProviderError(t *testing.T) {
+	rr := New("/")
+	rr.Sitemap("/sitemap.xml", "https://example.com", func(r *http.Request) ([]SitemapEntry, error) {
+		return nil, errors.New("db down")
+	})
+
+	req, _ := http.NewRequest("GET", "/sitemap.xml", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("invalid status %d", rec.Code)
+	}
+}
+
+func TestSitemapGzipRefused(t *testing.T) {
+	rr := New("/")
+	rr.Sitemap("/sitemap.xml", "https://example.com")
+
+	for _, header := range []string{"gzip;q=0", "*;q=1, gzip;q=0", "identity", "x-gzip-like"} {
+		req, _ := http.NewRequest("GET", "/sitemap.xml", nil)
+		req.Header.Set("Accept-Encoding", header)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: sitemap should not be gzipped", header)
+		}
+	}
+}
+
+func TestSitemapSubRouter(t *testing.T) {
+	rr := New("/")
+	shop := rr.SubRouter("/shop")
+	shop.Sitemap("/sitemap.xml", "https://example.com")
+	shop.Get("/products", func(w http.ResponseWriter, r *http.Request) {})
+
+	req, _ := http.NewRequest("GET", "/shop/sitemap.xml", nil)
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if body := rec.Body.String(); strings.Contains(body, "sitemap.xml") || !strings.Contains(body, "https://example.com/shop/products") {
+		t.Errorf("invalid sitemap\n%s", body)
+	}
+}
+
+func TestSitemapInvalidBaseURL(t *testing.T) {
+	for _, base := range []string{"", "example.com", "/shop", "ftp://example.com"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: expected panic", base)
+				}
+			}()
+			rr := New("/")
+			rr.Sitemap("/sitemap.xml", base)
+		}()
+	}
+}
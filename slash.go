@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TrailingSlashPolicy controls how a trailing slash in the request's path is treated
+type TrailingSlashPolicy int
+
+const (
+	// IgnoreTrailingSlash treats `/users/` and `/users` as the same route
+	IgnoreTrailingSlash TrailingSlashPolicy = iota
+
+	// StrictSlash treats `/users/` and `/users` as distinct routes
+	StrictSlash
+
+	// RedirectTrailingSlash redirects requests to the form of the path the route was registered with,
+	// using a 301 for GET and HEAD requests and a 308 for all others
+	RedirectTrailingSlash
+)
+
+// TrailingSlash sets the trailing slash policy used for all routes, including those of the subrouters
+func (r *Router) TrailingSlash(policy TrailingSlashPolicy) {
+	r.slashPolicy = policy
+}
+
+// slashMatches checks whether the pattern and path agree on having a trailing slash. Wildcard
+// patterns match either form.
+func slashMatches(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return true
+	}
+	return hasTrailingSlash(pattern) == hasTrailingSlash(path)
+}
+
+func hasTrailingSlash(path string) bool {
+	return len(path) > 1 && strings.HasSuffix(path, "/")
+}
+
+// redirectSlash redirects to the request's path with the trailing slash added or removed
+func redirectSlash(w http.ResponseWriter, r *http.Request) {
+	location, status := slashLocation(r)
+	http.Redirect(w, r, location, status)
+}
+
+// slashLocation is the location and status of the redirect adding or removing the trailing slash
+func slashLocation(r *http.Request) (string, int) {
+	path := localizePath(r, r.URL.Path)
+	if hasTrailingSlash(path) {
+		path = strings.TrimRight(path, "/")
+	} else {
+		path += "/"
+	}
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+
+	status := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+	return path, status
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrailingSlash(t *testing.T) {
+	tests := []struct {
+		policy         TrailingSlashPolicy
+		method         string
+		path           string
+		expectedStatus int
+		location       string
+	}{
+		{IgnoreTrailingSlash, "GET", "/users", 200, ""},
+		{IgnoreTrailingSlash, "GET", "/users/", 200, ""},
+		{StrictSlash, "GET", "/users", 200, ""},
+		{StrictSlash, "GET", "/users/", 404, ""},
+		{StrictSlash, "GET", "/dir/", 200, ""},
+		{StrictSlash, "GET", "/dir", 404, ""},
+		{StrictSlash, "GET", "/files/a/", 200, ""},
+		{RedirectTrailingSlash, "GET", "/users/", 301, "/users"},
+		{RedirectTrailingSlash, "GET", "/users/?page=2", 301, "/users?page=2"},
+		{RedirectTrailingSlash, "POST", "/users/", 308, "/users"},
+		{RedirectTrailingSlash, "GET", "/dir", 301, "/dir/"},
+		{RedirectTrailingSlash, "GET", "/admin/reports/", 301, "/admin/reports"},
+		{RedirectTrailingSlash, "GET", "/missing/", 404, ""},
+	}
+
+	for _, test := range tests {
+		fn := func(w http.ResponseWriter, r *http.Request) {}
+		rr := New("/")
+		rr.TrailingSlash(test.policy)
+		rr.Get("/users", fn)
+		rr.Post("/users", fn)
+		rr.Get("/dir/", fn)
+		rr.Get("/files/*", fn)
+		rr.SubRouter("/admin").Get("/reports", fn)
+
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.expectedStatus {
+			t.Errorf("%d %s %s: invalid status %d != %d", test.policy, test.method, test.path, rec.Code, test.expectedStatus)
+		}
+		if loc := rec.Header().Get("Location"); loc != test.location {
+			t.Errorf("%d %s %s: invalid location %q != %q", test.policy, test.method, test.path, loc, test.location)
+		}
+	}
+}
+
+func TestExplainTrailingSlash(t *testing.T) {
+	rr := New("/")
+	rr.TrailingSlash(StrictSlash)
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	result := rr.Explain("GET", "/users/")
+	if result.Matched != nil || result.Candidates[0].Reason != rejectSlash {
+		t.Errorf("trailing slash not explained: %+v", result)
+	}
+}
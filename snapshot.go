@@ -0,0 +1,200 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Snapshot describes the router's configuration, routes, middleware, policies and redirects,
+// including those of its subrouters, as canonical text. The text only changes when the routing
+// does, so it can be diffed between releases or compared against a golden file within tests.
+func (r Router) Snapshot() string {
+	if reloaded := r.reloaded(); reloaded != nil {
+		return reloaded.Snapshot()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "config slash=%s case=%s autohead=%t", slashPolicyName(r.slashPolicy), casePolicyName(r.casePolicy), !r.disableAutoHead)
+	if r.locales != nil {
+		fmt.Fprintf(&sb, " locales=%s default=%s", strings.Join(r.locales.Locales, ","), r.locales.Default)
+	}
+	sb.WriteString("\n")
+	r.writeSnapshot(&sb)
+	return sb.String()
+}
+
+// Fingerprint is the SHA-256 hash of the router's Snapshot, changing whenever the routing does
+func (r Router) Fingerprint() string {
+	sum := sha256.Sum256([]byte(r.Snapshot()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r Router) writeSnapshot(sb *strings.Builder) {
+	if r.isolated {
+		fmt.Fprintf(sb, "router %s isolated\n", r.basePath)
+	} else {
+		fmt.Fprintf(sb, "router %s\n", r.basePath)
+	}
+	for _, fn := range r.mw {
+		fmt.Fprintf(sb, "  before %s\n", funcName(fn))
+	}
+	for _, fn := range r.use {
+		fmt.Fprintf(sb, "  use %s\n", funcName(fn))
+	}
+	for _, fn := range r.after {
+		fmt.Fprintf(sb, "  after %s\n", funcName(fn))
+	}
+	for _, name := range policyNames(r.attached) {
+		fmt.Fprintf(sb, "  policy %s\n", name)
+	}
+
+	routes := make([]Route, 0, len(r.endpoints))
+	for route := range r.endpoints {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		pi, pj := r.fullPath(routes[i].path), r.fullPath(routes[j].path)
+		if pi == pj {
+			return routes[i].method < routes[j].method
+		}
+		return pi < pj
+	})
+	for _, route := range routes {
+		// replaced endpoints are left out, leaving the active one and those selected by type or query
+		for _, ep := range r.endpoints[route] {
+			if len(ep.accept) > 0 || len(ep.query) > 0 || ep == r.routes[route] {
+				fmt.Fprintf(sb, "  route %s %s%s\n", method(route.method), r.fullPath(route.path), ep.describe())
+			}
+		}
+	}
+
+	redirects := make([]Redirect, 0, len(r.redirects)+len(r.redirectPatterns))
+	for _, redirect := range r.redirects {
+		redirects = append(redirects, redirect)
+	}
+	for _, redirect := range r.redirectPatterns {
+		redirects = append(redirects, redirect)
+	}
+	sort.Slice(redirects, func(i, j int) bool {
+		return redirects[i].From < redirects[j].From
+	})
+	for _, redirect := range redirects {
+		fmt.Fprintf(sb, "  redirect %s %s %d\n", redirect.From, redirect.To, redirect.Status)
+	}
+
+	subs := append([]*Router(nil), r.subRouters...)
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].basePath < subs[j].basePath
+	})
+	for _, sub := range subs {
+		sub.writeSnapshot(sb)
+	}
+}
+
+// describe lists the handler and options of the endpoint
+func (e *Endpoint) describe() string {
+	var sb strings.Builder
+	if name := e.handlerName(); name != "" {
+		sb.WriteString(" handler=" + name)
+	}
+	if e.name != "" {
+		sb.WriteString(" name=" + e.name)
+	}
+	if len(e.formats) > 0 {
+		sb.WriteString(" formats=" + strings.Join(e.formats, ","))
+	}
+	if len(e.accept) > 0 {
+		sb.WriteString(" accept=" + strings.Join(e.accept, ","))
+	}
+	if len(e.query) > 0 {
+		sb.WriteString(" query=" + e.describeQuery())
+	}
+	if len(e.attached) > 0 {
+		sb.WriteString(" policies=" + strings.Join(policyNames(e.attached), ","))
+	}
+	if len(e.preloads) > 0 {
+		paths := make([]string, len(e.preloads))
+		for i, p := range e.preloads {
+			paths[i] = p.path
+		}
+		sb.WriteString(" preload=" + strings.Join(paths, ","))
+	}
+	if len(e.earlyHints) > 0 {
+		targets := make([]string, len(e.earlyHints))
+		for i, link := range e.earlyHints {
+			targets[i] = strings.TrimSpace(strings.SplitN(link, ";", 2)[0])
+		}
+		sb.WriteString(" earlyhints=" + strings.Join(targets, ","))
+	}
+	if e.noIndex {
+		sb.WriteString(" noindex")
+	}
+	if e.stub {
+		sb.WriteString(" stub")
+	}
+	if e.streaming {
+		sb.WriteString(" streaming")
+	}
+	if e.sitemapPriority > 0 {
+		fmt.Fprintf(&sb, " priority=%.1f", e.sitemapPriority)
+	}
+	return sb.String()
+}
+
+// handlerName names the endpoint's handler, empty if it has none
+func (e *Endpoint) handlerName() string {
+	if e.fn != nil {
+		return funcName(e.fn)
+	} else if e.handler != nil {
+		return handlerName(e.handler)
+	}
+	return ""
+}
+
+func method(m string) string {
+	if m == "" {
+		return "*"
+	}
+	return m
+}
+
+// funcName returns the name of the function, ex. `main.listUsers` or `main.main.func1` for closures
+func funcName(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
+
+// handlerName names handlers by their type, and HandlerFuncs by their function
+func handlerName(h http.Handler) string {
+	if fn, ok := h.(http.HandlerFunc); ok {
+		return funcName(fn)
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+func slashPolicyName(p TrailingSlashPolicy) string {
+	switch p {
+	case StrictSlash:
+		return "strict"
+	case RedirectTrailingSlash:
+		return "redirect"
+	}
+	return "ignore"
+}
+
+func casePolicyName(p CasePolicy) string {
+	switch p {
+	case CaseInsensitive:
+		return "insensitive"
+	case RedirectCase:
+		return "redirect"
+	}
+	return "sensitive"
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+)
+
+func listUsers(w http.ResponseWriter, r *http.Request) {}
+
+func showUser(w http.ResponseWriter, r *http.Request) {}
+
+func TestSnapshot(t *testing.T) {
+	build := func() Router {
+		rr := New("/")
+		rr.TrailingSlash(StrictSlash)
+		rr.Before(UseKMS(nil))
+		rr.Get("/users", listUsers).NoIndex()
+		rr.Get("/users/:id", showUser).Accept("application/json")
+		rr.Post("/users", listUsers)
+		rr.Get("/replaced", showUser)
+		rr.Get("/replaced", listUsers)
+		rr.Redirects(map[string]string{"/people": "/users"})
+		admin := rr.SubRouter("/admin")
+		admin.Policy(testPolicy("admin"))
+		admin.Handle("/files", http.NotFoundHandler())
+		return rr
+	}
+
+	expected := `config slash=strict case=sensitive autohead=true
+router /
+  before github.com/chrisolsen/router.UseKMS.1
+  route GET /replaced handler=github.com/chrisolsen/router.listUsers
+  route GET /users handler=github.com/chrisolsen/router.listUsers noindex
+  route POST /users handler=github.com/chrisolsen/router.listUsers
+  route GET /users/:id handler=github.com/chrisolsen/router.showUser accept=application/json
+  redirect /people /users 301
+router /admin
+  policy router.testPolicy
+  route * /admin/files handler=net/http.NotFound
+`
+	rr := build()
+	if s := rr.Snapshot(); s != expected {
+		t.Errorf("invalid snapshot\n%s", s)
+	}
+
+	if build().Fingerprint() != rr.Fingerprint() {
+		t.Error("fingerprint should be stable")
+	}
+	rr.Delete("/users/:id", showUser)
+	if build().Fingerprint() == rr.Fingerprint() {
+		t.Error("fingerprint should change with the routes")
+	}
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	"hash/fnv"
+	"net"
+	"net/http"
+)
+
+var splitCtxKey = NewKey[string]("split")
+
+// RouteSplit is one of the handlers a route's traffic is split between. Requests are split in
+// proportion to the weights, so weights of 95 and 5 send 5% of the traffic to the second handler.
+type RouteSplit struct {
+	Name    string
+	Handler http.HandlerFunc
+	Weight  int
+}
+
+// Split serves the path, for all methods, with handlers chosen by a hash of the key, ex. to canary a
+// rewrite of the handler or run an A/B test. A client keeps being sent to the same handler as long as
+// its key and the weights don't change. Requests without a key are served by the first handler.
+//
+//	rr.Split("/checkout", router.SplitByCookie("uid"),
+//		router.RouteSplit{Name: "stable", Handler: checkout, Weight: 95},
+//		router.RouteSplit{Name: "canary", Handler: checkoutV2, Weight: 5},
+//	)
+func (r Router) Split(path string, key func(*http.Request) string, splits ...RouteSplit) *Endpoint {
+	if len(splits) == 0 {
+		panic("router: split of " + path + " has no handlers")
+	}
+	total := 0
+	for _, s := range splits {
+		if s.Weight < 0 {
+			panic("router: split " + s.Name + " of " + path + " has a negative weight")
+		}
+		total += s.Weight
+	}
+	if total == 0 {
+		panic("router: splits of " + path + " have no weight")
+	}
+	pattern := r.fullPath(path)
+
+	return r.Handle(path, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s := splits[0]
+		if k := key(req); k != "" {
+			h := fnv.New32a()
+			h.Write([]byte(pattern + ":" + k))
+			s = pickSplit(splits, int(h.Sum32()%uint32(total)))
+		}
+		s.Handler(w, req.WithContext(Set(req.Context(), splitCtxKey, s.Name)))
+	}))
+}
+
+// SplitName retrieves the name of the split that's serving the request, empty for routes that aren't
+// split
+func SplitName(c context.Context) string {
+	name, _ := Get(c, splitCtxKey)
+	return name
+}
+
+// SplitByCookie keys splits by the value of the cookie
+func SplitByCookie(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return c.Value
+	}
+}
+
+// SplitByHeader keys splits by the value of the header, ex. an API key
+func SplitByHeader(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// SplitByIP keys splits by the address of the client's connection
+func SplitByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// pickSplit returns the split at the point within the total weight
+func pickSplit(splits []RouteSplit, n int) RouteSplit {
+	for _, s := range splits {
+		if n < s.Weight {
+			return s
+		}
+		n -= s.Weight
+	}
+	return splits[len(splits)-1]
+}
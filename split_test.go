@@ -0,0 +1,77 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	rr := New("/")
+	splitHandler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name, " ", SplitName(r.Context()))
+		}
+	}
+	rr.Split("/checkout", SplitByHeader("X-User"),
+		RouteSplit{Name: "stable", Handler: splitHandler("a"), Weight: 80},
+		RouteSplit{Name: "canary", Handler: splitHandler("b"), Weight: 20},
+	)
+
+	serve := func(user string) string {
+		req, _ := http.NewRequest("POST", "/checkout", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		served := serve(user)
+		if again := serve(user); again != served {
+			t.Fatalf("%s: served %q then %q", user, served, again)
+		}
+		counts[served]++
+	}
+	if len(counts) != 2 || counts["b canary"] < 150 || counts["b canary"] > 250 {
+		t.Errorf("invalid split %v", counts)
+	}
+	if served := serve(""); served != "a stable" {
+		t.Errorf("requests without a key should be served by the first handler, got %q", served)
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.0.1:1234"
+	req.Header.Set("X-Api-Key", "key")
+	req.AddCookie(&http.Cookie{Name: "uid", Value: "42"})
+
+	if k := SplitByIP(req); k != "192.168.0.1" {
+		t.Errorf("invalid ip %q", k)
+	}
+	if k := SplitByHeader("X-Api-Key")(req); k != "key" {
+		t.Errorf("invalid header %q", k)
+	}
+	if k := SplitByCookie("uid")(req); k != "42" {
+		t.Errorf("invalid cookie %q", k)
+	}
+	if k := SplitByCookie("missing")(req); k != "" {
+		t.Errorf("invalid missing cookie %q", k)
+	}
+}
+
+func TestSplitNoWeight(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("splits without weight should panic")
+		}
+	}()
+	rr := New("/")
+	rr.Split("/", SplitByIP, RouteSplit{Name: "a", Handler: func(w http.ResponseWriter, r *http.Request) {}})
+}
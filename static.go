@@ -0,0 +1,180 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// StaticOption configures the files served by Static
+type StaticOption func(*staticOptions)
+
+type staticOptions struct {
+	throttle   int
+	attachment bool
+	listing    *ListingOptions
+}
+
+// WithThrottle limits the rate each file is sent at, ex. so large downloads don't starve other
+// responses of bandwidth
+func WithThrottle(bytesPerSec int) StaticOption {
+	return func(opts *staticOptions) {
+		opts.throttle = bytesPerSec
+	}
+}
+
+// WithAttachment has clients save the files rather than display them, sending them with an
+// attachment Content-Disposition
+func WithAttachment() StaticOption {
+	return func(opts *staticOptions) {
+		opts.attachment = true
+	}
+}
+
+// Static serves the files of fsys under the prefix. Range and If-Range requests are supported, so
+// interrupted downloads can be resumed, along with conditional requests through the Last-Modified
+// and ETag headers. Directories are only listed WithListing, and hidden files, whose names or
+// those of their directories start with a dot, aren't served unless the listing shows them.
+//
+//	rr.Static("/downloads", os.DirFS("/var/downloads"), router.WithThrottle(1<<20), router.WithAttachment())
+func (r Router) Static(prefix string, fsys fs.FS, opts ...StaticOption) *Endpoint {
+	var o staticOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	prefix = strings.TrimRight(prefix, "/")
+
+	serve := func(w http.ResponseWriter, req *http.Request) {
+		name := path.Clean(strings.TrimPrefix(Param(req.Context(), "*"), "/"))
+		hidden := hiddenPath(name) && (o.listing == nil || !o.listing.ShowHidden)
+		if !fs.ValidPath(name) || name == "." && o.listing == nil || hidden {
+			Fail(req, NotFoundErr)
+			return
+		}
+		f, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			Fail(req, NotFoundErr)
+			return
+		} else if err != nil {
+			Fail(req, err)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			Fail(req, err)
+			return
+		}
+		if info.IsDir() {
+			if o.listing == nil {
+				Fail(req, NotFoundErr)
+				return
+			}
+			serveListing(w, req, fsys, name, *o.listing)
+			return
+		}
+		content, ok := f.(io.ReadSeeker)
+		if !ok {
+			Fail(req, fmt.Errorf("router: static file %s isn't seekable", name))
+			return
+		}
+		if o.throttle > 0 {
+			content = &throttledReader{ReadSeeker: content, rate: o.throttle, c: req.Context()}
+		}
+
+		// the ETag is derived from the file's metadata so If-Range can be checked without reading it
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+		if o.attachment {
+			Attachment(w, path.Base(name))
+		}
+		http.ServeContent(w, req, name, info.ModTime(), content)
+	}
+
+	// the wildcard doesn't match the prefix itself, the root of the listing
+	if o.listing != nil {
+		r.Get(prefix, serve)
+	}
+	return r.Get(prefix+"/*", serve)
+}
+
+// hiddenPath reports whether any of the path's segments starts with a dot
+func hiddenPath(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, ".") && segment != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// Attachment has the client save the response as a file with the name, rather than display it
+func Attachment(w http.ResponseWriter, filename string) {
+	setDisposition(w, "attachment", filename)
+}
+
+// Inline has the client display the response, naming the file it's saved as
+func Inline(w http.ResponseWriter, filename string) {
+	setDisposition(w, "inline", filename)
+}
+
+// setDisposition sets the Content-Disposition, encoding names that aren't plain ASCII as defined
+// by RFC 6266
+func setDisposition(w http.ResponseWriter, disposition, filename string) {
+	if filename == "" {
+		w.Header().Set("Content-Disposition", disposition)
+		return
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+}
+
+// throttledReader reads no faster than the rate, in bytes per second, since it was last seeked
+type throttledReader struct {
+	io.ReadSeeker
+	rate int
+	c    context.Context
+
+	start time.Time
+	read  int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	if len(p) > t.rate {
+		p = p[:t.rate]
+	}
+	n, err := t.ReadSeeker.Read(p)
+	t.read += int64(n)
+
+	if wait := t.wait(); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-t.c.Done():
+			return n, t.c.Err()
+		}
+	}
+	return n, err
+}
+
+// wait is how long the bytes read are ahead of the rate. The elapsed time is computed in floating
+// point, as the nanoseconds of large files at low rates overflow an int64.
+func (t *throttledReader) wait() time.Duration {
+	return time.Duration(float64(t.read)/float64(t.rate)*float64(time.Second)) - time.Since(t.start)
+}
+
+// Seek restarts the throttling, as ServeContent seeks to measure the file before sending it
+func (t *throttledReader) Seek(offset int64, whence int) (int64, error) {
+	t.start, t.read = time.Time{}, 0
+	return t.ReadSeeker.Seek(offset, whence)
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStatic(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"report.csv":     {Data: []byte("0123456789"), ModTime: modTime},
+		"docs/guide.txt": {Data: []byte("guide"), ModTime: modTime},
+	}
+	rr := New("/")
+	rr.Static("/files", fsys)
+
+	serve := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/files/report.csv", nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || rec.Body.String() != "0123456789" || etag == "" || rec.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("invalid response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	tests := []struct {
+		desc    string
+		path    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"nested", "/files/docs/guide.txt", nil, 200, "guide"},
+		{"range", "/files/report.csv", map[string]string{"Range": "bytes=2-4"}, 206, "234"},
+		{"resume", "/files/report.csv", map[string]string{"Range": "bytes=7-", "If-Range": etag}, 206, "789"},
+		{"changed", "/files/report.csv", map[string]string{"Range": "bytes=7-", "If-Range": `"stale"`}, 200, "0123456789"},
+		{"not modified", "/files/report.csv", map[string]string{"If-None-Match": etag}, 304, ""},
+		{"unsatisfiable", "/files/report.csv", map[string]string{"Range": "bytes=20-"}, 416, ""},
+		{"missing", "/files/missing.csv", nil, 404, ""},
+		{"directory", "/files/docs", nil, 404, ""},
+		{"traversal", "/files/../router.go", nil, 404, ""},
+	}
+	for _, test := range tests {
+		rec := serve(test.path, test.headers)
+		if rec.Code != test.status {
+			t.Errorf("%s: invalid status %d", test.desc, rec.Code)
+		}
+		if test.body != "" && rec.Body.String() != test.body {
+			t.Errorf("%s: invalid body %q", test.desc, rec.Body.String())
+		}
+	}
+}
+
+func TestStaticThrottle(t *testing.T) {
+	fsys := fstest.MapFS{"big.bin": {Data: []byte(strings.Repeat("x", 500))}}
+	rr := New("/")
+	rr.Static("/downloads", fsys, WithThrottle(2000), WithAttachment())
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, httptest.NewRequest("GET", "/downloads/big.bin", nil))
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("download wasn't throttled, took %v", elapsed)
+	}
+	if rec.Body.Len() != 500 {
+		t.Errorf("invalid length %d", rec.Body.Len())
+	}
+	if d := rec.Header().Get("Content-Disposition"); d != `attachment; filename=big.bin` {
+		t.Errorf("invalid disposition %q", d)
+	}
+}
+
+func TestThrottleWaitLargeFiles(t *testing.T) {
+	// 10GB at 1KB/s is past the nanoseconds an int64 holds
+	tr := &throttledReader{rate: 1 << 10, start: time.Now(), read: 10 << 30}
+	if wait := tr.wait(); wait < 100*24*time.Hour {
+		t.Errorf("invalid wait %v", wait)
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		set      func(http.ResponseWriter, string)
+		filename string
+		expected string
+	}{
+		{Attachment, "report.csv", "attachment; filename=report.csv"},
+		{Attachment, "annual report.csv", `attachment; filename="annual report.csv"`},
+		{Inline, "résumé.pdf", "inline; filename*=utf-8''r%C3%A9sum%C3%A9.pdf"},
+		{Inline, "", "inline"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		test.set(rec, test.filename)
+		if d := rec.Header().Get("Content-Disposition"); d != test.expected {
+			t.Errorf("%s: invalid disposition %q", test.filename, d)
+		}
+	}
+}
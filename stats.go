@@ -0,0 +1,167 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+// RouteStats are the counters of a single route
+type RouteStats struct {
+	Method   string    `json:"method"`
+	Pattern  string    `json:"pattern"`
+	Requests uint64    `json:"requests"`
+	Errors   uint64    `json:"errors"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Stats counts the requests handled by each route, keyed by the route's pattern rather than the
+// requested url
+type Stats struct {
+	mu     sync.Mutex
+	routes map[string]*RouteStats
+}
+
+// NewStats creates an empty set of route counters
+func NewStats() *Stats {
+	return &Stats{routes: make(map[string]*RouteStats)}
+}
+
+// Stats enables the collection of per-route counters for all routes, including those of the subrouters
+func (r *Router) Stats(s *Stats) {
+	r.stats = s
+}
+
+// Snapshot returns a copy of the counters, ordered by pattern and method
+func (s *Stats) Snapshot() []RouteStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make([]RouteStats, 0, len(s.routes))
+	for _, rs := range s.routes {
+		snapshot = append(snapshot, *rs)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Pattern == snapshot[j].Pattern {
+			return snapshot[i].Method < snapshot[j].Method
+		}
+		return snapshot[i].Pattern < snapshot[j].Pattern
+	})
+	return snapshot
+}
+
+// record is deferred while the handler runs, so a handler that never completed, due to a panic,
+// is counted as an error
+func (s *Stats) record(method, pattern string, sw *statusWriter) {
+	failed := sw.Status >= 500 || !sw.completed
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rs := s.stat(method, pattern)
+	rs.Requests++
+	if failed {
+		rs.Errors++
+	}
+	rs.LastSeen = now()
+}
+
+func (s *Stats) stat(method, pattern string) *RouteStats {
+	key := method + " " + pattern
+	rs := s.routes[key]
+	if rs == nil {
+		rs = &RouteStats{Method: method, Pattern: pattern}
+		s.routes[key] = rs
+	}
+	return rs
+}
+
+// StatsSink persists snapshots of the route counters, ex. to a file, database or remote service
+type StatsSink interface {
+	Save(ctx context.Context, stats []RouteStats) error
+	Load(ctx context.Context) ([]RouteStats, error)
+}
+
+// Restore loads the last saved snapshot from the sink, adding its counters to the current counters
+func (s *Stats) Restore(ctx context.Context, sink StatsSink) error {
+	saved, err := sink.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, saved := range saved {
+		rs := s.stat(saved.Method, saved.Pattern)
+		rs.Requests += saved.Requests
+		rs.Errors += saved.Errors
+		if saved.LastSeen.After(rs.LastSeen) {
+			rs.LastSeen = saved.LastSeen
+		}
+	}
+	return nil
+}
+
+// SnapshotEvery saves a snapshot to the sink at each interval until the context is done, at which
+// point a final snapshot is saved. Save errors are passed to onError, which may be nil.
+func (s *Stats) SnapshotEvery(ctx context.Context, sink StatsSink, interval time.Duration, onError func(error)) {
+	save := func(ctx context.Context) {
+		if err := sink.Save(ctx, s.Snapshot()); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			save(ctx)
+		case <-ctx.Done():
+			save(context.Background())
+			return
+		}
+	}
+}
+
+// FileSink saves the snapshots as JSON to the file path
+type FileSink string
+
+// Save writes the snapshot to a temp file before renaming it, so a crash never leaves a partial file
+func (f FileSink) Save(ctx context.Context, stats []RouteStats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := string(f) + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, string(f))
+}
+
+// Load reads the snapshot, returning no stats if the file doesn't exist yet
+func (f FileSink) Load(ctx context.Context) ([]RouteStats, error) {
+	data, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stats []RouteStats
+	return stats, json.Unmarshal(data, &stats)
+}
+
+// statusWriter records the status code written by the handler
+type statusWriter struct {
+	wrap.StatusWriter
+
+	// completed is set once the handler has returned without panicking
+	completed bool
+}
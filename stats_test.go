@@ -0,0 +1,150 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	stats := NewStats()
+	rr := New("/")
+	rr.Stats(stats)
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	rr.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		Fail(r, errors.New("failed"))
+	})
+	rr.SubRouter("/admin").Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/users/1"},
+		{"GET", "/users/2"},
+		{"POST", "/users"},
+		{"GET", "/admin/panic"},
+		{"GET", "/missing"},
+	}
+	for _, req := range requests {
+		r, _ := http.NewRequest(req.method, req.path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	snapshot := stats.Snapshot()
+	expected := []RouteStats{
+		{Method: "GET", Pattern: "/admin/panic", Requests: 1, Errors: 1},
+		{Method: "POST", Pattern: "/users", Requests: 1, Errors: 1},
+		{Method: "GET", Pattern: "/users/:id", Requests: 2, Errors: 0},
+	}
+	if len(snapshot) != len(expected) {
+		t.Errorf("invalid snapshot %+v", snapshot)
+		return
+	}
+	for i, rs := range expected {
+		s := snapshot[i]
+		if s.Method != rs.Method || s.Pattern != rs.Pattern || s.Requests != rs.Requests || s.Errors != rs.Errors {
+			t.Errorf("%+v != %+v", s, rs)
+		}
+		if s.LastSeen.IsZero() {
+			t.Errorf("%s: last seen not set", s.Pattern)
+		}
+	}
+}
+
+func TestStatsFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	sink := FileSink(filepath.Join(dir, "stats.json"))
+
+	// loading before any snapshot exists
+	stats := NewStats()
+	if err := stats.Restore(context.Background(), sink); err != nil {
+		t.Error(err)
+		return
+	}
+
+	stats.stat("GET", "/users").Requests = 5
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		stats.SnapshotEvery(ctx, sink, time.Hour, func(err error) { t.Error(err) })
+		done <- true
+	}()
+	cancel()
+	<-done
+
+	restored := NewStats()
+	restored.stat("GET", "/users").Requests = 1
+	if err := restored.Restore(context.Background(), sink); err != nil {
+		t.Error(err)
+		return
+	}
+	snapshot := restored.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Requests != 6 {
+		t.Errorf("counters not restored: %+v", snapshot)
+	}
+}
+
+func TestStatsWriterInterfaces(t *testing.T) {
+	stats := NewStats()
+	rr := New("/")
+	rr.Stats(stats)
+	rr.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})
+	rr.Get("/file", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Pusher); ok {
+			t.Error("push should not be exposed over HTTP/1.1")
+		}
+		if _, ok := w.(interface{ Unwrap() http.ResponseWriter }); !ok {
+			t.Error("writer should unwrap")
+		}
+		f, _ := os.Open("stats.go")
+		defer f.Close()
+		if _, err := w.(io.ReaderFrom).ReadFrom(f); err != nil {
+			t.Error(err)
+		}
+	})
+	ts := httptest.NewServer(rr)
+	defer ts.Close()
+
+	for path, body := range map[string]string{"/ws": "hijacked", "/file": "package router"} {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK || !strings.HasPrefix(string(b), body) {
+			t.Errorf("%s: invalid response %d %q", path, res.StatusCode, b)
+		}
+	}
+	for _, rs := range stats.Snapshot() {
+		if rs.Requests != 1 || rs.Errors != 0 {
+			t.Errorf("%s: invalid stats %+v", rs.Pattern, rs)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"context"
+)
+
+var streamingCtxKey = NewKey[bool]("streaming")
+
+// Streaming marks the route as long-lived, such as server-sent events, websockets and large
+// downloads, exempting it from middleware that times out or buffers responses, including
+// middleware.Timeout, middleware.Compress and middleware.Singleflight. Middleware wrapping the
+// router itself runs before the route is matched, so can't exempt it.
+func (e *Endpoint) Streaming() *Endpoint {
+	e.streaming = true
+	return e
+}
+
+// IsStreaming reports whether the matched route is marked as Streaming, allowing middleware that
+// buffers responses or limits how long they take to skip it
+func IsStreaming(c context.Context) bool {
+	streaming, _ := Get(c, streamingCtxKey)
+	return streaming
+}
@@ -0,0 +1,35 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStreaming(t *testing.T) {
+	rr := New("/")
+	rr.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		if !IsStreaming(r.Context()) {
+			t.Error("route should be streaming")
+		}
+	}).Streaming()
+	rr.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+		if IsStreaming(r.Context()) {
+			t.Error("route should not be streaming")
+		}
+	})
+
+	for _, path := range []string{"/events", "/users"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, route := range rr.Routes() {
+		if route.Streaming != (route.Pattern == "/events") {
+			t.Errorf("%s: invalid streaming flag", route.Pattern)
+		}
+	}
+	if !strings.Contains(rr.Snapshot(), "/events handler=github.com/chrisolsen/router.TestStreaming.func1 streaming\n") {
+		t.Errorf("snapshot should mark streaming routes\n%s", rr.Snapshot())
+	}
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Stub registers a placeholder route responding with the example status and body, letting clients
+// be built against a route before its handler exists. Responses are sent with `X-Stubbed: true`,
+// and bodies that are valid JSON as application/json. Stubs are flagged by Routes, and the NoStubs
+// lint check fails while any remain.
+//
+//	rr.Stub("GET", "/invoices/:id", 200, `{"id": "inv_1", "total": 1200}`)
+func (r Router) Stub(method, path string, status int, body string) *Endpoint {
+	contentType := "text/plain; charset=utf-8"
+	if json.Valid([]byte(body)) {
+		contentType = "application/json"
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Stubbed", "true")
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+
+	ep := &Endpoint{stub: true}
+	if method == "" {
+		// like Handle, the stub matches all methods
+		ep.handler = http.HandlerFunc(fn)
+	} else {
+		ep.fn = fn
+	}
+	return r.bindRoute(strings.ToUpper(method), path, ep)
+}
+
+// NoStubs is a lint check failing routes registered with Stub, ex. to ensure none remain before a
+// release
+//
+//	err := rr.Lint(router.LintRule{Name: "no-stubs", Check: router.NoStubs})
+func NoStubs(route RouteInfo) error {
+	if route.Stub {
+		return errors.New("route is a stub")
+	}
+	return nil
+}
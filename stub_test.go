@@ -0,0 +1,59 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStub(t *testing.T) {
+	rr := New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	api := rr.SubRouter("/api")
+	api.Stub("get", "/invoices/:id", http.StatusOK, `{"id": "inv_1", "total": 1200}`)
+	api.Stub("POST", "/invoices", http.StatusCreated, "created")
+	api.Stub("", "/webhooks", http.StatusAccepted, "")
+
+	tests := []struct {
+		method      string
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"GET", "/api/invoices/7", 200, "application/json", `{"id": "inv_1", "total": 1200}`},
+		{"POST", "/api/invoices", 201, "text/plain; charset=utf-8", "created"},
+		{"DELETE", "/api/webhooks", 202, "text/plain; charset=utf-8", ""},
+	}
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, test.path, nil)
+		w := httptest.NewRecorder()
+		rr.ServeHTTP(w, r)
+
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%s %s: invalid response %d %q", test.method, test.path, w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Stubbed") != "true" {
+			t.Errorf("%s %s: missing X-Stubbed header", test.method, test.path)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != test.contentType {
+			t.Errorf("%s %s: invalid content type %q", test.method, test.path, ct)
+		}
+	}
+
+	var stubs []string
+	for _, route := range rr.Routes() {
+		if route.Stub {
+			stubs = append(stubs, method(route.Method)+" "+route.Pattern)
+		}
+	}
+	if strings.Join(stubs, ", ") != "POST /api/invoices, GET /api/invoices/:id, * /api/webhooks" {
+		t.Errorf("invalid stubs %v", stubs)
+	}
+
+	err := rr.Lint(LintRule{Name: "no-stubs", Check: NoStubs})
+	if err == nil || len(err.(*LintError).Violations) != 3 {
+		t.Errorf("stubs should fail the lint check: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RouteSpec declares a route of a route table, mapping a method and path to a handler by name
+type RouteSpec struct {
+	// Method is `*` for routes matching all the standard methods
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+
+	// Name is the name used to build the route's url with URLFor
+	Name string `json:"name,omitempty"`
+}
+
+// RouteTableError reports all the problems found in a route table
+type RouteTableError struct {
+	Problems []string
+}
+
+func (e *RouteTableError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("router: %d route table problems", len(e.Problems)))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseRouteTable decodes a JSON route table, an array of RouteSpec objects. Unknown fields are
+// rejected, so typos in the config are caught at startup.
+//
+//	[
+//		{"method": "GET", "path": "/users/:id", "handler": "users.show", "name": "user"},
+//		{"method": "POST", "path": "/users", "handler": "users.create"}
+//	]
+func ParseRouteTable(rd io.Reader) ([]RouteSpec, error) {
+	dec := json.NewDecoder(rd)
+	dec.DisallowUnknownFields()
+	var specs []RouteSpec
+	if err := dec.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("router: invalid route table: %w", err)
+	}
+	return specs, nil
+}
+
+// LoadRoutes registers the routes of the table, looking up their handlers by name. The whole table
+// is validated first, and nothing is registered if any route has an unknown handler or method, an
+// invalid path or duplicates another route, in which case a RouteTableError lists the problems.
+//
+//	specs, err := router.ParseRouteTable(f)
+//	...
+//	err = rr.LoadRoutes(specs, map[string]http.HandlerFunc{
+//		"users.show":   showUser,
+//		"users.create": createUser,
+//	})
+func (r Router) LoadRoutes(specs []RouteSpec, handlers map[string]http.HandlerFunc) error {
+	var problems []string
+	seen := make(map[Route]int)
+	for i, spec := range specs {
+		where := fmt.Sprintf("route %d (%s %s)", i, spec.Method, spec.Path)
+		if spec.Handler == "" {
+			problems = append(problems, where+": missing handler")
+		} else if handlers[spec.Handler] == nil {
+			problems = append(problems, fmt.Sprintf("%s: unknown handler %q", where, spec.Handler))
+		}
+		if !strings.HasPrefix(spec.Path, "/") {
+			problems = append(problems, where+": path must start with /")
+		} else if err := ValidatePattern(spec.Path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", where, err.(*PatternError).Problem))
+		}
+
+		methods, ok := specMethods(spec.Method)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown method %q", where, spec.Method))
+		}
+		for _, method := range methods {
+			route := Route{method: method, path: spec.Path}
+			if prev, ok := seen[route]; ok {
+				problems = append(problems, fmt.Sprintf("%s: duplicates route %d", where, prev))
+				break
+			}
+			seen[route] = i
+		}
+	}
+	if len(problems) > 0 {
+		return &RouteTableError{Problems: problems}
+	}
+
+	for _, spec := range specs {
+		methods, _ := specMethods(spec.Method)
+		ep := r.Match(methods, spec.Path, handlers[spec.Handler])
+		if spec.Name != "" {
+			ep.Name(spec.Name)
+		}
+	}
+	return nil
+}
+
+// specMethods is the methods of a route spec, reporting whether the method is known
+func specMethods(method string) ([]string, bool) {
+	if method == "*" {
+		return standardMethods, true
+	}
+	method = strings.ToUpper(method)
+	for _, m := range standardMethods {
+		if m == method {
+			return []string{method}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadRoutes(t *testing.T) {
+	specs, err := ParseRouteTable(strings.NewReader(`[
+		{"method": "GET", "path": "/users/:id", "handler": "users.show", "name": "user"},
+		{"method": "post", "path": "/users", "handler": "users.create"},
+		{"method": "*", "path": "/ping", "handler": "ping"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name, Param(r.Context(), "id"))
+		}
+	}
+
+	rr := New("/")
+	err = rr.LoadRoutes(specs, map[string]http.HandlerFunc{
+		"users.show":   handler("show"),
+		"users.create": handler("create"),
+		"ping":         handler("ping"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"GET", "/users/42", "show42"},
+		{"POST", "/users", "create"},
+		{"DELETE", "/ping", "ping"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Body.String() != test.body {
+			t.Errorf("%s %s: invalid body %q", test.method, test.path, rec.Body.String())
+		}
+	}
+	if url, err := rr.URLFor("user", "id", "7"); err != nil || url != "/users/7" {
+		t.Errorf("invalid url %q %v", url, err)
+	}
+}
+
+func TestLoadRoutesInvalid(t *testing.T) {
+	rr := New("/")
+	err := rr.LoadRoutes([]RouteSpec{
+		{Method: "GET", Path: "/users", Handler: "users.list"},
+		{Method: "GET", Path: "/users", Handler: "users.list"},
+		{Method: "FETCH", Path: "/users", Handler: "users.list"},
+		{Method: "GET", Path: "reports", Handler: "reports.list"},
+		{Method: "POST", Path: "/users"},
+		{Method: "GET", Path: "/users/:id/posts/:id", Handler: "users.list"},
+	}, map[string]http.HandlerFunc{
+		"users.list": func(w http.ResponseWriter, r *http.Request) {},
+	})
+
+	tableErr, ok := err.(*RouteTableError)
+	if !ok {
+		t.Fatalf("invalid error %v", err)
+	}
+	expected := []string{
+		"route 1 (GET /users): duplicates route 0",
+		`route 2 (FETCH /users): unknown method "FETCH"`,
+		`route 3 (GET reports): unknown handler "reports.list"`,
+		"route 3 (GET reports): path must start with /",
+		"route 4 (POST /users): missing handler",
+		"route 5 (GET /users/:id/posts/:id): duplicate param :id",
+	}
+	if strings.Join(tableErr.Problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("invalid problems\n%s", err)
+	}
+	if len(rr.Routes()) != 0 {
+		t.Error("invalid tables should not register any route")
+	}
+}
+
+func TestParseRouteTableUnknownField(t *testing.T) {
+	if _, err := ParseRouteTable(strings.NewReader(`[{"method": "GET", "path": "/", "handlr": "home"}]`)); err == nil {
+		t.Error("unknown fields should be rejected")
+	}
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// SpanContext identifies a span within a trace, as carried by the W3C `traceparent` header
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+
+	// TraceState is the vendor specific `tracestate` header, passed along untouched
+	TraceState string
+}
+
+// Valid reports whether the trace and span ids are set
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Extract parses the trace context of the headers, returning an invalid SpanContext if the
+// headers are missing or malformed
+func Extract(h http.Header) SpanContext {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(h.Get("traceparent")), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}
+	}
+	// version 00 has exactly four parts, later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}
+	}
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) {
+		return SpanContext{}
+	}
+	var flags [1]byte
+	if !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}
+	}
+	sc.Sampled = flags[0]&1 == 1
+	sc.TraceState = h.Get("tracestate")
+	if !sc.Valid() {
+		return SpanContext{}
+	}
+	return sc
+}
+
+// Inject sets the headers of an outgoing request to continue the trace
+func Inject(sc SpanContext, h http.Header) {
+	if !sc.Valid() {
+		return
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	h.Set("traceparent", "00-"+hex.EncodeToString(sc.TraceID[:])+"-"+hex.EncodeToString(sc.SpanID[:])+"-"+flags)
+	if sc.TraceState != "" {
+		h.Set("tracestate", sc.TraceState)
+	}
+}
+
+// decodeHex decodes the lowercase hex string, which must exactly fill dst
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
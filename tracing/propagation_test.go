@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01", false, false},
+		{"", false, false},
+	}
+	for _, test := range tests {
+		h := http.Header{}
+		h.Set("traceparent", test.header)
+		sc := Extract(h)
+		if sc.Valid() != test.valid || sc.Sampled != test.sampled {
+			t.Errorf("%q: invalid span context %+v", test.header, sc)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	in := http.Header{}
+	in.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	in.Set("tracestate", "vendor=value")
+
+	out := http.Header{}
+	Inject(Extract(in), out)
+	if out.Get("traceparent") != in.Get("traceparent") || out.Get("tracestate") != "vendor=value" {
+		t.Errorf("invalid headers %v", out)
+	}
+
+	empty := http.Header{}
+	Inject(SpanContext{}, empty)
+	if len(empty) != 0 {
+		t.Errorf("invalid span context should not be injected %v", empty)
+	}
+}
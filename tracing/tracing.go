@@ -0,0 +1,94 @@
+// Package tracing starts a span for each request served by a router. Spans are named after the
+// matched route's pattern, ex. `GET /users/:id`, continue the trace of the incoming `traceparent`
+// header, and record the response status and the request's error.
+//
+// The package doesn't depend on a tracing SDK; a Tracer adapts one, such as OpenTelemetry, by
+// starting its spans with the remote parent.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/chrisolsen/router"
+	"github.com/chrisolsen/router/internal/wrap"
+)
+
+var spanCtxKey = router.NewKey[Span]("span")
+
+// Attribute is a key/value pair recorded against a span
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Attr creates an attribute
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is a single traced request
+type Span interface {
+	SetName(name string)
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+
+	// SetStatus records the response's http status code
+	SetStatus(code int)
+	End()
+}
+
+// Tracer starts the spans. The parent is the caller's span taken from the request headers, and is
+// invalid when the request isn't part of an existing trace.
+type Tracer interface {
+	Start(c context.Context, name string, parent SpanContext) (context.Context, Span)
+}
+
+// Middleware wraps the router with a span per request. It should wrap the router directly so that
+// the matched route and errors remain visible to it.
+//
+//	h := tracing.Middleware(tracer)(rr)
+func Middleware(t Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent := Extract(r.Header)
+			c, span := t.Start(r.Context(), r.Method, parent)
+			defer span.End()
+
+			span.SetAttributes(
+				Attr("http.method", r.Method),
+				Attr("http.target", r.URL.Path),
+				Attr("http.host", r.Host),
+			)
+			r = router.Observe(r.WithContext(router.Set(c, spanCtxKey, span)))
+			sw := &wrap.StatusWriter{ResponseWriter: w}
+			next.ServeHTTP(wrap.Writer(sw, w), r)
+
+			// the router reports the pattern and error through the observed request
+			if pattern := router.RoutePattern(r.Context()); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(Attr("http.route", pattern))
+			}
+			if err := router.RequestError(r.Context()); err != nil {
+				span.RecordError(err)
+			}
+			if sw.Status == 0 {
+				sw.Status = http.StatusOK
+			}
+			span.SetStatus(sw.Status)
+		})
+	}
+}
+
+// SpanFromContext retrieves the request's span, nil outside of the middleware
+func SpanFromContext(c context.Context) Span {
+	span, _ := router.Get(c, spanCtxKey)
+	return span
+}
+
+// SetAttributes adds custom attributes to the request's span from within a handler
+func SetAttributes(c context.Context, attrs ...Attribute) {
+	if span := SpanFromContext(c); span != nil {
+		span.SetAttributes(attrs...)
+	}
+}
@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrisolsen/router"
+)
+
+type testSpan struct {
+	name   string
+	parent SpanContext
+	attrs  map[string]interface{}
+	err    error
+	status int
+	ended  bool
+}
+
+func (s *testSpan) SetName(name string)   { s.name = name }
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) SetStatus(code int)    { s.status = code }
+func (s *testSpan) End()                  { s.ended = true }
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(c context.Context, name string, parent SpanContext) (context.Context, Span) {
+	span := &testSpan{name: name, parent: parent, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return c, span
+}
+
+func TestMiddleware(t *testing.T) {
+	rr := router.New("/")
+	rr.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		SetAttributes(r.Context(), Attr("user.id", router.Param(r.Context(), "id")))
+		w.Write([]byte("ok"))
+	})
+	rr.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		router.Fail(r, errors.New("db down"))
+	})
+
+	tracer := &testTracer{}
+	h := Middleware(tracer)(rr)
+
+	req, _ := http.NewRequest("GET", "/users/5", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("POST", "/users", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	req, _ = http.NewRequest("GET", "/missing", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(tracer.spans) != 3 {
+		t.Errorf("invalid span count %d", len(tracer.spans))
+		return
+	}
+
+	get := tracer.spans[0]
+	if get.name != "GET /users/:id" || get.status != 200 || !get.ended {
+		t.Errorf("invalid span %+v", get)
+	}
+	if get.attrs["user.id"] != "5" || get.attrs["http.route"] != "/users/:id" {
+		t.Errorf("invalid attributes %v", get.attrs)
+	}
+	if !get.parent.Valid() || !get.parent.Sampled {
+		t.Errorf("parent not propagated %+v", get.parent)
+	}
+
+	post := tracer.spans[1]
+	if post.status != 500 || post.err == nil || post.err.Error() != "db down" {
+		t.Errorf("error not recorded %+v", post)
+	}
+	if post.parent.Valid() {
+		t.Error("span without a traceparent should have no parent")
+	}
+
+	missing := tracer.spans[2]
+	if missing.name != "GET" || missing.status != 404 {
+		t.Errorf("unmatched request should keep the method name %+v", missing)
+	}
+}
+
+func TestSetAttributesWithoutSpan(t *testing.T) {
+	SetAttributes(context.Background(), Attr("ignored", true))
+}
+
+func TestMiddlewareHijack(t *testing.T) {
+	rr := router.New("/")
+	rr.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	})
+	tracer := &testTracer{}
+	h := Middleware(tracer)(rr)
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		h.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	<-done
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("invalid status %d", res.StatusCode)
+	}
+	if len(tracer.spans) != 1 || tracer.spans[0].status != http.StatusSwitchingProtocols {
+		t.Errorf("hijacked connections should be recorded as switching protocols %+v", tracer.spans)
+	}
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+var filesCtxKey = NewKey[[]UploadedFile]("files")
+
+// UploadedFile describes a file uploaded within a multipart form
+type UploadedFile struct {
+	Field    string
+	Filename string
+
+	// ContentType is sniffed from the file's content rather than trusting the client
+	ContentType string
+	Size        int64
+
+	// Location is where the file was stored, ex. the path of a temp file or a storage key
+	Location string
+}
+
+// WithFiles returns a copy of the context containing the uploaded files, used by the upload
+// middleware and allowing handlers to be unit tested without a multipart body
+func WithFiles(c context.Context, files []UploadedFile) context.Context {
+	return Set(c, filesCtxKey, files)
+}
+
+// Files retrieves the files uploaded within the form field, as stored by the upload middleware
+func Files(r *http.Request, field string) []UploadedFile {
+	all, _ := Get(r.Context(), filesCtxKey)
+	var files []UploadedFile
+	for _, f := range all {
+		if f.Field == field {
+			files = append(files, f)
+		}
+	}
+	return files
+}
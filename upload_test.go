@@ -0,0 +1,25 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	r, _ := http.NewRequest("POST", "/", nil)
+	if files := Files(r, "avatar"); files != nil {
+		t.Errorf("invalid files without uploads %v", files)
+	}
+
+	r = r.WithContext(WithFiles(r.Context(), []UploadedFile{
+		{Field: "avatar", Filename: "me.png"},
+		{Field: "docs", Filename: "a.pdf"},
+		{Field: "docs", Filename: "b.pdf"},
+	}))
+	if files := Files(r, "avatar"); len(files) != 1 || files[0].Filename != "me.png" {
+		t.Errorf("invalid avatar files %v", files)
+	}
+	if files := Files(r, "docs"); len(files) != 2 || files[1].Filename != "b.pdf" {
+		t.Errorf("invalid docs files %v", files)
+	}
+}
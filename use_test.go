@@ -0,0 +1,82 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type userKey struct{}
+
+func TestUse(t *testing.T) {
+	var calls []string
+	use := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, name)))
+			})
+		}
+	}
+
+	rr := New("/")
+	rr.Before(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "before")
+	})
+	rr.Use(use("root"))
+	rr.Policy(testPolicy("policy"))
+	api := rr.SubRouter("/api")
+	api.Use(use("api"))
+	api.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+		w.Write([]byte(r.Context().Value(userKey{}).(string) + " " + Param(r.Context(), "id")))
+	})
+	api.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		Fail(r, errors.New("failed"))
+	})
+	api.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("panicked")
+	})
+	rr.InternalError(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(RequestError(r.Context()).Error() + " " + RoutePattern(r.Context())))
+	})
+
+	req, _ := http.NewRequest("GET", "/api/users/5", nil)
+	original := req.Context()
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if c := strings.Join(calls, ","); c != "before,root,api,handler" {
+		t.Errorf("invalid calls %s", c)
+	}
+	if rec.Body.String() != "policy>api 5" {
+		t.Errorf("invalid response %s", rec.Body.String())
+	}
+	if req.Context() != original {
+		t.Error("the caller's request shouldn't be changed")
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		// the handlers are passed requests derived by the Use middleware
+		{"/api/fail", "policy>failed /api/fail"},
+		{"/api/panic", "policy>panic: panicked /api/panic"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req = Observe(req)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Body.String() != test.expected {
+			t.Errorf("%s: invalid response %s", test.path, rec.Body.String())
+		}
+		if RequestError(req.Context()) == nil || RoutePattern(req.Context()) != test.path {
+			t.Errorf("%s: outcome not observed", test.path)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package router
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate checks the struct pointed to by v against its `validate` tags, returning
+// ValidationErrors for the body fields that fail. Nested structs, pointers and slices are checked
+// too, with fields named by their json tags.
+//
+//	type signup struct {
+//		Email string   `json:"email" validate:"required,email"`
+//		Name  string   `json:"name" validate:"required,max=50"`
+//		Plan  string   `json:"plan" validate:"oneof=free pro"`
+//		Tags  []string `json:"tags" validate:"max=5"`
+//	}
+//
+// Rules:
+//
+//	required      the value must not be the zero value
+//	min=N, max=N  the minimum and maximum of numbers, or length of strings, slices and maps
+//	len=N         the exact length of strings, slices and maps
+//	oneof=A B     the value must be one of the space separated values
+//	email         the value must be an email address
+//
+// Other rules are skipped for empty values that aren't required.
+func Validate(v interface{}) error {
+	return validateSource(v, SourceBody, "json")
+}
+
+// validateSource validates the struct, naming fields after the tag
+func validateSource(v interface{}, source, tag string) error {
+	var errs ValidationErrors
+	validateValue(reflect.ValueOf(v), source, tag, nil, &errs)
+	return errs.Err()
+}
+
+func validateValue(v reflect.Value, source, tag string, path []string, errs *ValidationErrors) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			validateValue(v.Elem(), source, tag, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), source, tag, append(path, strconv.Itoa(i)), errs)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			sf := t.Field(i)
+			if !exported(sf) {
+				continue
+			}
+			name, ok := fieldName(sf, tag)
+			if !ok {
+				continue
+			}
+			fieldPath := append(path[:len(path):len(path)], name)
+			if sf.Anonymous && sf.Tag.Get(tag) == "" {
+				fieldPath = path
+			}
+			field := v.Field(i)
+			if rules := sf.Tag.Get("validate"); rules != "" {
+				if msg := checkRules(field, rules); msg != "" {
+					errs.Add(source, msg, fieldPath...)
+					continue
+				}
+			}
+			validateValue(field, source, tag, fieldPath, errs)
+		}
+	}
+}
+
+// exported reports whether the field can be set, including the fields promoted from embedded
+// structs of unexported types
+func exported(sf reflect.StructField) bool {
+	return sf.PkgPath == "" || (sf.Anonymous && indirectType(sf.Type).Kind() == reflect.Struct)
+}
+
+// fieldName returns the name of the field within the tag, or false if the field is skipped
+func fieldName(sf reflect.StructField, tag string) (string, bool) {
+	name := strings.Split(sf.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name, true
+}
+
+// checkRules returns the message of the first rule the value fails
+func checkRules(v reflect.Value, rules string) string {
+	empty := v.IsZero()
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			name, arg = rule[:i], rule[i+1:]
+		}
+		if name == "required" {
+			if empty {
+				return "is required"
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+		if msg := checkRule(indirect(v), name, arg); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func checkRule(v reflect.Value, name, arg string) string {
+	switch name {
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("router: invalid %s rule %q", name, arg))
+		}
+		size, unit, ok := measure(v)
+		if !ok {
+			return ""
+		}
+		switch {
+		case name == "min" && size < n:
+			return strings.TrimSpace("must be at least " + arg + " " + unit)
+		case name == "max" && size > n:
+			return strings.TrimSpace("must be at most " + arg + " " + unit)
+		case name == "len" && size != n:
+			return strings.TrimSpace("must be exactly " + arg + " " + unit)
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		val := fmt.Sprint(v.Interface())
+		for _, option := range options {
+			if val == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(options, ", ")
+	case "email":
+		if v.Kind() != reflect.String {
+			return ""
+		}
+		if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+			return "must be a valid email address"
+		}
+	default:
+		panic(fmt.Sprintf("router: unknown validation rule %q", name))
+	}
+	return ""
+}
+
+// measure returns the value of numbers and the length of strings, slices and maps, along with the
+// unit the length is counted in
+func measure(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), "items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
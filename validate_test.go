@@ -0,0 +1,70 @@
+package router
+
+import (
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	Street string `json:"street" validate:"required"`
+	Zip    string `json:"zip" validate:"len=5"`
+}
+
+type testSignup struct {
+	Email   string         `json:"email" validate:"required,email"`
+	Name    string         `json:"name" validate:"required,min=2,max=10"`
+	Plan    string         `json:"plan" validate:"oneof=free pro"`
+	Age     int            `json:"age" validate:"min=18"`
+	Tags    []string       `json:"tags" validate:"max=2"`
+	Address *testAddress   `json:"address"`
+	Items   []testAddress  `json:"items"`
+	Ignored string         `json:"-" validate:"required"`
+	Meta    map[string]int `json:"meta,omitempty" validate:"max=1"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := testSignup{Email: "a@b.co", Name: "Al", Plan: "pro", Age: 20}
+	if err := Validate(&valid); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	invalid := testSignup{
+		Email:   "Al <al@b.co>",
+		Name:    "Ålexandriaa",
+		Plan:    "gold",
+		Age:     17,
+		Tags:    []string{"a", "b", "c"},
+		Address: &testAddress{Zip: "123"},
+		Items:   []testAddress{{Street: "Main"}, {}},
+	}
+	err := Validate(&invalid)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Errorf("invalid error %v", err)
+		return
+	}
+	expected := []string{
+		"body /email: must be a valid email address",
+		"body /name: must be at most 10 characters",
+		"body /plan: must be one of free, pro",
+		"body /age: must be at least 18",
+		"body /tags: must be at most 2 items",
+		"body /address/street: is required",
+		"body /address/zip: must be exactly 5 characters",
+		"body /items/1/street: is required",
+	}
+	if errs.Error() != strings.Join(expected, "; ") {
+		t.Errorf("invalid errors\n%v\n%v", errs, expected)
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	Validate(&struct {
+		Name string `validate:"unique"`
+	}{Name: "x"})
+}
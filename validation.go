@@ -0,0 +1,87 @@
+package router
+
+import (
+	"errors"
+	"strings"
+)
+
+// Validation error sources
+const (
+	SourcePath   = "path"
+	SourceQuery  = "query"
+	SourceHeader = "header"
+	SourceBody   = "body"
+)
+
+// FieldError describes a single invalid value of the request. Field is a JSON pointer to the value
+// within its source, ex. `/address/street` for a body field or `/id` for a url param.
+type FieldError struct {
+	Source  string `json:"source"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Source + " " + e.Field + ": " + e.Message
+}
+
+// ValidationErrors aggregates the field errors of a request across all of its sources. Failing a
+// request with it using Fail results in a 422 response listing the errors.
+type ValidationErrors []FieldError
+
+// Add appends an error for the field at the pointer tokens, which are escaped as needed
+func (v *ValidationErrors) Add(source, message string, field ...string) {
+	*v = append(*v, FieldError{Source: source, Field: JSONPointer(field...), Message: message})
+}
+
+// Merge appends the field errors contained within err, if any
+func (v *ValidationErrors) Merge(err error) {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		*v = append(*v, errs...)
+		return
+	}
+	var fe FieldError
+	if errors.As(err, &fe) {
+		*v = append(*v, fe)
+		return
+	}
+	var bindErr *BindError
+	if errors.As(err, &bindErr) && bindErr.Field != "" {
+		*v = append(*v, FieldError{Source: SourceBody, Field: bindErr.Field, Message: bindErr.Message})
+	}
+}
+
+// Err returns nil when there are no errors, allowing the result to be returned as an error
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// JSONPointer builds an RFC 6901 pointer from the unescaped reference tokens
+func JSONPointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		token = strings.ReplaceAll(token, "~", "~0")
+		sb.WriteString(strings.ReplaceAll(token, "/", "~1"))
+	}
+	return sb.String()
+}
+
+// validationErrors returns the aggregated field errors within err
+func validationErrors(err error) (ValidationErrors, bool) {
+	var errs ValidationErrors
+	errs.Merge(err)
+	return errs, len(errs) > 0
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		tokens   []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"name"}, "/name"},
+		{[]string{"items", "0", "sku"}, "/items/0/sku"},
+		{[]string{"a/b", "m~n"}, "/a~1b/m~0n"},
+	}
+	for _, test := range tests {
+		if p := JSONPointer(test.tokens...); p != test.expected {
+			t.Errorf("%v: %q != %q", test.tokens, p, test.expected)
+		}
+	}
+}
+
+func TestValidationErrorsMerge(t *testing.T) {
+	var errs ValidationErrors
+	if errs.Err() != nil {
+		t.Error("empty errors should be nil")
+	}
+
+	var query ValidationErrors
+	query.Add(SourceQuery, "must be a number", "page")
+	errs.Merge(fmt.Errorf("query: %w", query.Err()))
+	errs.Merge(FieldError{Source: SourcePath, Field: "/id", Message: "invalid"})
+	errs.Merge(fmt.Errorf("unrelated"))
+
+	if len(errs) != 2 {
+		t.Errorf("invalid error count %d", len(errs))
+		return
+	}
+	if errs[0].Field != "/page" || errs[1].Source != SourcePath {
+		t.Errorf("invalid errors %v", errs)
+	}
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	rr := New("/")
+	rr.InternalError(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("500 handler should not be called")
+	})
+	rr.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+		var errs ValidationErrors
+		errs.Add(SourceQuery, "is required", "store")
+		errs.Add(SourceBody, "must be greater than 0", "items", "0", "qty")
+		Fail(r, errs)
+	})
+
+	req, _ := http.NewRequest("POST", "/orders", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid status code %d", rec.Code)
+	}
+	var problem struct {
+		Status int
+		Errors []FieldError
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []FieldError{
+		{Source: "query", Field: "/store", Message: "is required"},
+		{Source: "body", Field: "/items/0/qty", Message: "must be greater than 0"},
+	}
+	if problem.Status != 422 || fmt.Sprint(problem.Errors) != fmt.Sprint(expected) {
+		t.Errorf("invalid problem %+v", problem)
+	}
+
+	req, _ = http.NewRequest("POST", "/orders", nil)
+	rec = httptest.NewRecorder()
+	rr.ServeHTTP(rec, req)
+	if body := rec.Body.String(); body != "Unprocessable Entity\nquery /store: is required\nbody /items/0/qty: must be greater than 0\n" {
+		t.Errorf("invalid text body %q", body)
+	}
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	"context"
+)
+
+// routeValue is a context value bound to the requests of a router
+type routeValue struct {
+	key, val interface{}
+}
+
+// WithValue binds the value to the context of every request handled by the router and its
+// subrouters, allowing groups of routes to be given different resources without globals.
+// Subrouters override the values of their parents.
+//
+//	eu := rr.SubRouter("/eu")
+//	eu.WithValue(dbKey, euDB)
+//	...
+//	db := r.Context().Value(dbKey).(*sql.DB)
+func (r *Router) WithValue(key, val interface{}) {
+	r.values = append(r.values, routeValue{key: key, val: val})
+}
+
+// bindValues derives the context with the values of the routers leading to the matched router
+func (r Router) bindValues(rr *Router, c context.Context) context.Context {
+	for _, router := range append([]*Router{&r}, r.routerPath(rr)...) {
+		for _, v := range router.values {
+			c = context.WithValue(c, v.key, v.val)
+		}
+	}
+	return c
+}
+
+// routerPath returns the subrouters leading to the target, outermost first. Routers are matched
+// by base path as findRouter returns copies.
+func (r Router) routerPath(target *Router) []*Router {
+	for _, child := range r.subRouters {
+		if child.basePath == target.basePath {
+			return []*Router{child}
+		}
+		if path := child.routerPath(target); path != nil {
+			return append([]*Router{child}, path...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithValue(t *testing.T) {
+	type key string
+	dbKey, regionKey := key("db"), key("region")
+
+	rr := New("/")
+	rr.WithValue(dbKey, "primary")
+	rr.WithValue(regionKey, "global")
+	eu := rr.SubRouter("/eu")
+	eu.WithValue(dbKey, "eu-replica")
+	de := eu.SubRouter("/de")
+	de.WithValue(regionKey, "de")
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Context().Value(dbKey).(string) + " " + r.Context().Value(regionKey).(string)))
+	}
+	rr.Get("/users", handler)
+	eu.Get("/users", handler)
+	de.Get("/users", handler)
+
+	tests := map[string]string{
+		"/users":       "primary global",
+		"/eu/users":    "eu-replica global",
+		"/eu/de/users": "eu-replica de",
+	}
+	for path, expected := range tests {
+		req, _ := http.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+		if rec.Body.String() != expected {
+			t.Errorf("%s: %q != %q", path, rec.Body.String(), expected)
+		}
+	}
+}
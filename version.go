@@ -0,0 +1,163 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var versionCtxKey = NewKey[string]("version")
+
+// VersionOptions configures how requests without a version in their path select one
+type VersionOptions struct {
+	// Header is the request header naming the version, ex. `API-Version: v2`
+	Header string
+
+	// MediaParam is the Accept header parameter naming the version, ex. `version` for
+	// `Accept: application/json; version=v2`
+	MediaParam string
+
+	// Default is the version used when the request doesn't name one
+	Default string
+}
+
+// Deprecation describes the retirement of a version
+type Deprecation struct {
+	// Since is when the version was deprecated, sent in the Deprecation header
+	Since time.Time
+
+	// Sunset is when the version will stop being served, sent in the Sunset header
+	Sunset time.Time
+
+	// Link is the documentation for migrating off the version
+	Link string
+}
+
+// Versioning sets how the router's versions are selected by requests that don't start with a
+// version's path
+func (r *Router) Versioning(opts VersionOptions) {
+	r.versioning = opts
+}
+
+// Version creates a group of routes for the API version. The routes are served under the version's
+// path, ex. `/v1/users`, or under the router's own path when the version is selected by header.
+//
+//	rr.Versioning(router.VersionOptions{Header: "API-Version", Default: "v2"})
+//	rr.Version("v1").Get("/users", listUsersV1)
+//	rr.Version("v2").Get("/users", listUsersV2)
+func (r *Router) Version(name string) *Router {
+	sub := r.SubRouter("/" + name)
+	sub.version = name
+	return sub
+}
+
+// Deprecate adds the Deprecation, Sunset and Link headers to all the responses of the router's
+// routes, including those of its subrouters
+//
+//	rr.Version("v1").Deprecate(router.Deprecation{Sunset: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)})
+func (r *Router) Deprecate(d Deprecation) {
+	r.deprecation = &d
+}
+
+// RequestVersion retrieves the API version of the router serving the request
+func RequestVersion(c context.Context) string {
+	version, _ := Get(c, versionCtxKey)
+	return version
+}
+
+// selectVersion returns a copy of the request whose path is prefixed with the version it names, if
+// the path doesn't already start with one of the router's versions
+func (r Router) selectVersion(rr *Router, h http.Header, req *http.Request) *http.Request {
+	opts := rr.versioning
+	version := ""
+	if opts.Header != "" {
+		h.Add("Vary", opts.Header)
+		version = strings.TrimSpace(req.Header.Get(opts.Header))
+	}
+	if version == "" && opts.MediaParam != "" {
+		h.Add("Vary", "Accept")
+		version = mediaParam(req.Header.Get("Accept"), opts.MediaParam)
+	}
+	if version == "" {
+		version = opts.Default
+	}
+
+	for _, sub := range rr.subRouters {
+		if sub.version == "" || sub.version != version {
+			continue
+		}
+		u := *req.URL
+		rest := "/" + strings.TrimLeft(strings.TrimPrefix(u.Path, rr.basePath), "/")
+		u.Path = sub.fullPath(rest)
+		if u.RawPath != "" {
+			rawRest := "/" + strings.TrimLeft(strings.TrimPrefix(u.RawPath, rr.basePath), "/")
+			u.RawPath = sub.fullPath(rawRest)
+		}
+		r2 := new(http.Request)
+		*r2 = *req
+		r2.URL = &u
+		return r2
+	}
+	return req
+}
+
+// bindVersion derives the context with the version of the routers leading to the matched router,
+// adding the headers of the innermost deprecation
+func (r Router) bindVersion(rr *Router, w http.ResponseWriter, c context.Context) context.Context {
+	version := ""
+	var deprecation *Deprecation
+	for _, router := range append([]*Router{&r}, r.routerPath(rr)...) {
+		if router.version != "" {
+			version = router.version
+		}
+		if router.deprecation != nil {
+			deprecation = router.deprecation
+		}
+	}
+	if version != "" {
+		c = Set(c, versionCtxKey, version)
+	}
+	if deprecation == nil {
+		return c
+	}
+
+	h := w.Header()
+	if deprecation.Since.IsZero() {
+		h.Set("Deprecation", "true")
+	} else {
+		h.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+	}
+	if !deprecation.Sunset.IsZero() {
+		h.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" {
+		h.Add("Link", "<"+deprecation.Link+`>; rel="deprecation"`)
+	}
+	return c
+}
+
+// hasVersions checks whether any of the router's direct subrouters are versions
+func (r Router) hasVersions() bool {
+	for _, sub := range r.subRouters {
+		if sub.version != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaParam returns the value of the parameter from the first media range of the Accept header
+// that has it
+func mediaParam(header, name string) string {
+	for _, part := range strings.Split(header, ",") {
+		for _, param := range strings.Split(part, ";")[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.EqualFold(kv[0], name) {
+				return strings.Trim(kv[1], `"`)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersion(t *testing.T) {
+	rr := New("/")
+	api := rr.SubRouter("/api")
+	api.Versioning(VersionOptions{Header: "API-Version", MediaParam: "version", Default: "v2"})
+	v1 := api.Version("v1")
+	v1.Deprecate(Deprecation{
+		Since:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		Link:   "https://example.com/migrate",
+	})
+	v1.Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestVersion(r.Context()) + " user " + Param(r.Context(), "id")))
+	})
+	api.Version("v2").Get("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestVersion(r.Context()) + " user " + Param(r.Context(), "id")))
+	})
+
+	tests := []struct {
+		path       string
+		header     string
+		accept     string
+		status     int
+		body       string
+		deprecated bool
+	}{
+		{"/api/v1/users/1", "", "", 200, "v1 user 1", true},
+		{"/api/v2/users/1", "", "", 200, "v2 user 1", false},
+		{"/api/users/1", "v1", "", 200, "v1 user 1", true},
+		{"/api/users/1", "", "application/json; version=v1", 200, "v1 user 1", true},
+		{"/api/users/1", "v2", "application/json; version=v1", 200, "v2 user 1", false},
+		{"/api/users/1", "", "", 200, "v2 user 1", false},
+		{"/api/users/1", "v3", "", 404, "Not Found\n", false},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		if test.header != "" {
+			req.Header.Set("API-Version", test.header)
+		}
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+		rec := httptest.NewRecorder()
+		rr.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("%s %s: invalid status %d != %d", test.path, test.header, rec.Code, test.status)
+		}
+		if req.URL.Path != test.path {
+			t.Errorf("%s %s: the caller's request should be left untouched, got %s", test.path, test.header, req.URL.Path)
+		}
+		if rec.Body.String() != test.body {
+			t.Errorf("%s %s: invalid body %q != %q", test.path, test.header, rec.Body.String(), test.body)
+		}
+		h := rec.Header()
+		if !test.deprecated {
+			if h.Get("Deprecation") != "" {
+				t.Errorf("%s %s: should not be deprecated", test.path, test.header)
+			}
+			continue
+		}
+		if d := h.Get("Deprecation"); d != "@1704067200" {
+			t.Errorf("%s %s: invalid deprecation %q", test.path, test.header, d)
+		}
+		if s := h.Get("Sunset"); s != "Sun, 01 Jun 2025 00:00:00 GMT" {
+			t.Errorf("%s %s: invalid sunset %q", test.path, test.header, s)
+		}
+		if l := h.Get("Link"); l != `<https://example.com/migrate>; rel="deprecation"` {
+			t.Errorf("%s %s: invalid link %q", test.path, test.header, l)
+		}
+	}
+}